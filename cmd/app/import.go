@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/w212w/GoProjectEM/internal/enricher"
+	"github.com/w212w/GoProjectEM/internal/importer"
+	"github.com/w212w/GoProjectEM/internal/logger"
+	"github.com/w212w/GoProjectEM/internal/repository"
+	"gorm.io/gorm"
+)
+
+// runImport выполняет подкоманду import [-format csv|ndjson] [-enrich] файл|-
+// и печатает отчет об импорте в stdout
+func runImport(db *gorm.DB, args []string) {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	format := flags.String("format", "", "input format: csv or ndjson (default: by file extension)")
+	enrich := flags.Bool("enrich", false, "fill empty fields from the external API")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		logger.Log.Fatal("Usage: import [-format csv|ndjson] [-enrich] file|-")
+	}
+
+	path := flags.Arg(0)
+	var input io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			logger.Log.Fatal("Failed to open import file:", err)
+		}
+		defer file.Close()
+		input = file
+	}
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+		if *format == "jsonl" {
+			*format = importer.FormatNDJSON
+		}
+	}
+
+	dec, err := importer.NewDecoder(input, *format)
+	if err != nil {
+		logger.Log.Fatal("Failed to read import file: ", err)
+	}
+
+	songs := repository.NewGormSongRepository(db)
+	enricherConfig := enricher.ConfigFromEnv()
+	if *enrich && enricherConfig.BaseURL == "" {
+		logger.Log.Fatal("EXTERNAL_API_BASE_URL is not set, cannot enrich songs")
+	}
+	im := importer.New(songs, songs, enricher.NewHTTPEnricher(enricherConfig), importer.ConfigFromEnv())
+
+	report, err := im.Run(context.Background(), dec, importer.Options{Enrich: *enrich})
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if report != nil {
+		encoder.Encode(report)
+	}
+	if err != nil {
+		logger.Log.Fatalf("Import stopped after %d rows: %v", len(report.Rows), err)
+	}
+	logger.Log.Infof("Imported %d songs, %d skipped, %d failed", report.Created, report.Skipped, report.Failed)
+}
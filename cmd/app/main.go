@@ -1,17 +1,27 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
 	httpSwagger "github.com/swaggo/http-swagger"
 	_ "github.com/w212w/GoProjectEM/docs"
+	"github.com/w212w/GoProjectEM/internal/config"
+	"github.com/w212w/GoProjectEM/internal/enricher"
 	"github.com/w212w/GoProjectEM/internal/handlers"
+	"github.com/w212w/GoProjectEM/internal/importer"
+	"github.com/w212w/GoProjectEM/internal/ingest"
 	"github.com/w212w/GoProjectEM/internal/logger"
-	"github.com/w212w/GoProjectEM/internal/models"
+	"github.com/w212w/GoProjectEM/internal/repository"
+	"github.com/w212w/GoProjectEM/internal/trash"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -42,7 +52,7 @@ func setupDatabase() *gorm.DB {
 	dsn := fmt.Sprintf("user=%s password=%s dbname=%s host=%s port=%s sslmode=%s",
 		dbUser, dbPassword, dbName, dbHost, dbPort, dbSSLMode)
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		logger.Log.Fatal("Failed to connect to the database:", err)
 	}
@@ -57,23 +67,121 @@ func main() {
 	db := setupDatabase()
 	logger.Log.Info("Connected to database")
 
-	if err := db.AutoMigrate(&models.Song{}); err != nil {
-		logger.Log.Fatal("Migartion failed:", err)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			runMigrate(db, os.Args[2:])
+			return
+		case "import":
+			checkSchema(db)
+			runImport(db, os.Args[2:])
+			return
+		default:
+			logger.Log.Fatalf("Unknown command %q, expected: migrate up|down [n]|status, import [-format csv|ndjson] [-enrich] file", os.Args[1])
+		}
 	}
-	logger.Log.Debug("Table created successfully")
 
-	router := mux.NewRouter()
+	checkSchema(db)
+	warnUnkeyedDuplicates(db)
+
+	songs := repository.NewGormSongRepository(db)
+
+	enricherConfig := enricher.ConfigFromEnv()
+	if enricherConfig.BaseURL == "" {
+		logger.Log.Warn("EXTERNAL_API_BASE_URL is not set, adding songs will fail")
+	}
+	songInfo := enricher.NewHTTPEnricher(enricherConfig)
+
+	jobs := repository.NewGormJobRepository(db)
+	ingestor := ingest.New(songs, jobs, songInfo, ingest.ConfigFromEnv())
+	if err := ingestor.Start(context.Background()); err != nil {
+		logger.Log.Fatal("Failed to start ingestor:", err)
+	}
 
-	router.HandleFunc("/api/songs", handlers.GetSongsHandler(db)).Methods("GET")
-	router.HandleFunc("/api/songs/{id}/text", handlers.GetSongTextHandler(db)).Methods("GET")
-	router.HandleFunc("/api/songs/{id}", handlers.DeleteSongHandler(db)).Methods("DELETE")
-	router.HandleFunc("/api/songs/{id}", handlers.UpdateSongHandler(db)).Methods("PUT")
-	router.HandleFunc("/api/songs", handlers.AddSongHandler(db)).Methods("POST")
+	purger := trash.New(songs, trash.ConfigFromEnv())
+	purger.Start(context.Background())
+
+	songImporter := importer.New(songs, songs, songInfo, importer.ConfigFromEnv())
+
+	idempotency := repository.NewGormIdempotencyRepository(db)
+	idempotencyTTL := config.Duration("IDEMPOTENCY_TTL", 24*time.Hour)
+	songsMaxLimit := config.Int("SONGS_MAX_LIMIT", 100)
+	if songsMaxLimit <= 0 {
+		logger.Log.Warnf("SONGS_MAX_LIMIT must be positive, got %d, using 100", songsMaxLimit)
+		songsMaxLimit = 100
+	}
+
+	router := mux.NewRouter()
+	router.Use(handlers.RequestIDMiddleware)
+	router.Use(handlers.ActorMiddleware)
+
+	router.HandleFunc("/api/songs", handlers.GetSongsHandler(songs, songs, songsMaxLimit)).Methods("GET")
+	// Регистрируется раньше /api/songs/{id}, иначе "export" будет принят за ID песни
+	router.HandleFunc("/api/songs/export", handlers.ExportSongsHandler(songs)).Methods("GET")
+	router.HandleFunc("/api/songs/{id}", handlers.GetSongHandler(songs)).Methods("GET")
+	router.HandleFunc("/api/songs/{id}/text", handlers.GetSongTextHandler(songs)).Methods("GET")
+	router.HandleFunc("/api/songs/{id}", handlers.DeleteSongHandler(songs)).Methods("DELETE")
+	router.HandleFunc("/api/songs/{id}", handlers.UpdateSongHandler(songs)).Methods("PUT")
+	router.HandleFunc("/api/songs/{id}", handlers.PatchSongHandler(songs)).Methods("PATCH")
+	router.HandleFunc("/api/songs", handlers.Idempotent(idempotency, idempotencyTTL, handlers.AddSongHandler(songs, songs, songInfo, ingestor))).Methods("POST")
+	router.HandleFunc("/api/songs/import", handlers.ImportSongsHandler(songImporter)).Methods("POST")
+	router.HandleFunc("/api/songs/tag", handlers.TagSongsHandler(songs)).Methods("POST")
+	router.HandleFunc("/api/songs/untag", handlers.UntagSongsHandler(songs)).Methods("POST")
+	router.HandleFunc("/api/genres", handlers.ListGenresHandler(songs)).Methods("GET")
+	router.HandleFunc("/api/songs/{id}/revisions", handlers.ListSongRevisionsHandler(songs)).Methods("GET")
+	router.HandleFunc("/api/songs/{id}/revisions/diff", handlers.DiffSongRevisionsHandler(songs)).Methods("GET")
+	router.HandleFunc("/api/songs/{id}/revisions/{rev}/restore", handlers.RestoreSongRevisionHandler(songs, songs)).Methods("POST")
+	router.HandleFunc("/api/songs/{id}/restore", handlers.RestoreSongHandler(songs)).Methods("POST")
+	router.HandleFunc("/api/trash", handlers.ListTrashHandler(songs)).Methods("GET")
+	router.HandleFunc("/api/albums", handlers.ListAlbumsHandler(songs)).Methods("GET")
+	router.HandleFunc("/api/albums", handlers.CreateAlbumHandler(songs)).Methods("POST")
+	router.HandleFunc("/api/albums/{id}", handlers.GetAlbumHandler(songs)).Methods("GET")
+	router.HandleFunc("/api/albums/{id}/tracks", handlers.SetAlbumTracksHandler(songs)).Methods("PUT")
+	router.HandleFunc("/api/playlists", handlers.ListPlaylistsHandler(songs)).Methods("GET")
+	router.HandleFunc("/api/playlists", handlers.CreatePlaylistHandler(songs)).Methods("POST")
+	router.HandleFunc("/api/playlists/{id}", handlers.GetPlaylistHandler(songs)).Methods("GET")
+	router.HandleFunc("/api/playlists/{id}", handlers.UpdatePlaylistHandler(songs)).Methods("PUT")
+	router.HandleFunc("/api/playlists/{id}", handlers.DeletePlaylistHandler(songs)).Methods("DELETE")
+	router.HandleFunc("/api/playlists/{id}/entries", handlers.AddPlaylistEntryHandler(songs)).Methods("POST")
+	router.HandleFunc("/api/playlists/{id}/entries/{entry_id}", handlers.DeletePlaylistEntryHandler(songs)).Methods("DELETE")
+	router.HandleFunc("/api/playlists/{id}/entries/{entry_id}/move", handlers.MovePlaylistEntryHandler(songs)).Methods("POST")
+	router.HandleFunc("/api/playlists/{id}/export", handlers.ExportPlaylistHandler(songs)).Methods("GET")
+	router.HandleFunc("/api/groups", handlers.ListGroupsHandler(songs)).Methods("GET")
+	router.HandleFunc("/api/groups", handlers.CreateGroupHandler(songs)).Methods("POST")
+	router.HandleFunc("/api/groups/{id}", handlers.GetGroupHandler(songs)).Methods("GET")
+	router.HandleFunc("/api/groups/{id}", handlers.UpdateGroupHandler(songs)).Methods("PUT")
+	router.HandleFunc("/api/groups/{id}", handlers.DeleteGroupHandler(songs)).Methods("DELETE")
+	router.HandleFunc("/api/groups/{id}/songs", handlers.ListGroupSongsHandler(songs)).Methods("GET")
+	router.HandleFunc("/api/admin/duplicates", handlers.ListDuplicatesHandler(songs)).Methods("GET")
+	router.HandleFunc("/api/admin/duplicates/merge", handlers.MergeDuplicatesHandler(songs)).Methods("POST")
+	router.HandleFunc("/api/jobs/{id}", handlers.GetJobHandler(jobs)).Methods("GET")
 
 	router.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
 
-	logger.Log.Info("Server is running on :8080")
-	if err := http.ListenAndServe(":8080", router); err != nil {
-		logger.Log.Fatalf("Error running server: %v", err)
+	server := &http.Server{Addr: ":8080", Handler: router}
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.Log.Info("Server is running on :8080")
+		serverErr <- server.ListenAndServe()
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	select {
+	case err := <-serverErr:
+		logger.Log.Errorf("Error running server: %v", err)
+	case <-ctx.Done():
+		logger.Log.Info("Shutting down server")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), config.Duration("SHUTDOWN_TIMEOUT", 15*time.Second))
+		if err := server.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Log.Errorf("Failed to shut down server gracefully: %v", err)
+		}
+		cancel()
 	}
+
+	// Обработчики HTTP завершены: новых задач больше не будет
+	ingestor.Stop()
+	purger.Stop()
+	logger.Log.Info("Server stopped")
 }
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/w212w/GoProjectEM/internal/logger"
+	"github.com/w212w/GoProjectEM/internal/migrations"
+	"github.com/w212w/GoProjectEM/internal/models"
+	"gorm.io/gorm"
+)
+
+// runMigrate выполняет подкоманду migrate up|down [n]|status
+func runMigrate(db *gorm.DB, args []string) {
+	migrator, err := migrations.New(db)
+	if err != nil {
+		logger.Log.Fatal("Failed to load migrations:", err)
+	}
+
+	if len(args) == 0 {
+		logger.Log.Fatal("Usage: migrate up|down [n]|status")
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, mig := range applied {
+			logger.Log.Infof("Applied migration %04d_%s", mig.Version, mig.Name)
+		}
+		if err != nil {
+			logger.Log.Fatal("Migration failed:", err)
+		}
+		if len(applied) == 0 {
+			logger.Log.Info("Schema is up to date")
+		}
+		warnUnkeyedDuplicates(db)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				logger.Log.Fatalf("Invalid number of steps %q", args[1])
+			}
+		}
+		rolledBack, err := migrator.Down(ctx, steps)
+		for _, mig := range rolledBack {
+			logger.Log.Infof("Rolled back migration %04d_%s", mig.Version, mig.Name)
+		}
+		if err != nil {
+			logger.Log.Fatal("Rollback failed:", err)
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			logger.Log.Fatal("Failed to read migration status:", err)
+		}
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(os.Stdout, "%04d_%-30s %s\n", status.Version, status.Name, applied)
+		}
+	default:
+		logger.Log.Fatalf("Unknown migrate command %q, expected: up, down [n], status", args[0])
+	}
+}
+
+// checkSchema не дает запустить сервер на базе с непримененными миграциями
+func checkSchema(db *gorm.DB) {
+	migrator, err := migrations.New(db)
+	if err != nil {
+		logger.Log.Fatal("Failed to load migrations:", err)
+	}
+
+	pending, err := migrator.Pending(context.Background())
+	if err != nil {
+		logger.Log.Fatal("Failed to check schema version:", err)
+	}
+	if len(pending) > 0 {
+		logger.Log.Fatalf("Database schema is not up to date: %d pending migrations, run \"migrate up\" first", len(pending))
+	}
+	logger.Log.Debug("Database schema is up to date")
+}
+
+// warnUnkeyedDuplicates сообщает о песнях без natural_key. Миграция 0009 оставляет
+// без ключа дубликаты, которые уже были в базе: изменение такой песни вернет 409,
+// пока ее не объединят с оригиналом.
+func warnUnkeyedDuplicates(db *gorm.DB) {
+	var count int64
+	if err := db.Model(&models.Song{}).Where("natural_key IS NULL").Count(&count).Error; err != nil {
+		logger.Log.Warnf("Failed to count songs without natural key: %v", err)
+		return
+	}
+	if count > 0 {
+		logger.Log.Warnf("%d songs duplicate the group and title of another song and cannot be updated until merged, "+
+			"see GET /api/admin/duplicates and POST /api/admin/duplicates/merge", count)
+	}
+}
@@ -15,9 +15,38 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/songs": {
+        "/api/admin/duplicates": {
             "get": {
-                "description": "Получить список песен с возможностью фильтрации по артисту и названию",
+                "description": "Получить группы песен, у которых группа и название совпадают без учета регистра, ё/е, пробелов и знаков препинания",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Получить похожие песни",
+                "responses": {
+                    "200": {
+                        "description": "Группы похожих песен",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.DuplicateGroup"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/duplicates/merge": {
+            "post": {
+                "description": "Дополнить песню target_id незаполненными полями песен source_ids и переместить их в корзину",
                 "consumes": [
                     "application/json"
                 ],
@@ -25,22 +54,77 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "songs"
+                    "admin"
                 ],
-                "summary": "Получить список песен",
+                "summary": "Объединить песни",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Фильтр по артисту",
-                        "name": "artist",
-                        "in": "query"
+                        "description": "Песни для объединения",
+                        "name": "merge",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.MergeSongsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Объединенная песня",
+                        "schema": {
+                            "$ref": "#/definitions/models.Song"
+                        }
                     },
-                    {
-                        "type": "string",
-                        "description": "Фильтр по названию",
-                        "name": "title",
-                        "in": "query"
+                    "400": {
+                        "description": "Неверный формат JSON",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Песня с такой группой и названием уже существует",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Песня была изменена",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибки валидации полей",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
                     },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/albums": {
+            "get": {
+                "description": "Получить альбомы без треков с пагинацией",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "albums"
+                ],
+                "summary": "Получить список альбомов",
+                "parameters": [
                     {
                         "type": "integer",
                         "default": 1,
@@ -58,20 +142,14 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Список песен",
+                        "description": "Список альбомов",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Song"
+                                "$ref": "#/definitions/models.Album"
                             }
                         }
                     },
-                    "400": {
-                        "description": "Неверные параметры",
-                        "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
-                        }
-                    },
                     "500": {
                         "description": "Ошибка сервера",
                         "schema": {
@@ -79,11 +157,9 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/songs": {
+            },
             "post": {
-                "description": "Добавляет песню в базу данных, получая информацию о песне из внешнего API",
+                "description": "Добавить альбом и, при необходимости, его треки в порядке song_ids. Группа создается, если ее еще нет",
                 "consumes": [
                     "application/json"
                 ],
@@ -91,35 +167,41 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "songs"
+                    "albums"
                 ],
-                "summary": "Добавить песню",
+                "summary": "Добавить альбом",
                 "parameters": [
                     {
-                        "description": "Данные для добавления песни",
-                        "name": "song",
+                        "description": "Данные альбома",
+                        "name": "album",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.AddSongRequest"
+                            "$ref": "#/definitions/models.AlbumRequest"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Песня успешно добавлена",
+                        "description": "Созданный альбом",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.Album"
                         }
                     },
                     "400": {
-                        "description": "Неверный формат данных",
+                        "description": "Неверный формат JSON",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибки валидации полей",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Ошибка при обработке запроса",
+                        "description": "Ошибка сервера",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -127,52 +209,40 @@ const docTemplate = `{
                 }
             }
         },
-        "/songs/{id}": {
-            "put": {
-                "description": "Обновить песню по ее ID",
-                "consumes": [
-                    "application/json"
-                ],
+        "/api/albums/{id}": {
+            "get": {
+                "description": "Получить альбом по его ID вместе с треками по порядку",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "songs"
+                    "albums"
                 ],
-                "summary": "Обновить информацию о песне",
+                "summary": "Получить альбом",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID песни",
+                        "description": "ID альбома",
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Данные для обновления песни",
-                        "name": "song",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.Song"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Песня обновлена успешно",
+                        "description": "Альбом",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.Album"
                         }
                     },
                     "400": {
-                        "description": "Неверный формат JSON",
+                        "description": "Неверный ID альбома",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Песня не найдена",
+                        "description": "Альбом не найден",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -184,9 +254,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Удалить песню по ее ID",
+            }
+        },
+        "/api/albums/{id}/tracks": {
+            "put": {
+                "description": "Задать полный список треков альбома в нужном порядке. Песни, которых нет в списке, исключаются из альбома",
                 "consumes": [
                     "application/json"
                 ],
@@ -194,33 +266,48 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "songs"
+                    "albums"
                 ],
-                "summary": "Удалить песню",
+                "summary": "Изменить порядок треков альбома",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID песни",
+                        "description": "ID альбома",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "ID песен по порядку",
+                        "name": "tracks",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AlbumTracksRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Песня удалена успешно",
+                        "description": "Альбом с новым порядком треков",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.Album"
                         }
                     },
                     "400": {
-                        "description": "ID не указан",
+                        "description": "Неверный формат JSON",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Песня не найдена",
+                        "description": "Альбом не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибки валидации полей",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -234,27 +321,46 @@ const docTemplate = `{
                 }
             }
         },
-        "/songs/{id}/text": {
+        "/api/genres": {
             "get": {
-                "description": "Получить текст песни по ее ID с возможностью пагинации по стихам",
-                "consumes": [
+                "description": "Получить жанры, которые можно назначать песням",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Получить словарь жанров",
+                "responses": {
+                    "200": {
+                        "description": "Жанры",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Genre"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/groups": {
+            "get": {
+                "description": "Получить группы в алфавитном порядке с пагинацией",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "songs"
+                    "groups"
                 ],
-                "summary": "Получить текст песни",
+                "summary": "Получить список групп",
                 "parameters": [
-                    {
-                        "type": "string",
-                        "description": "ID песни",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
                     {
                         "type": "integer",
                         "default": 1,
@@ -264,27 +370,74 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
-                        "default": 2,
-                        "description": "Количество стихов на странице",
+                        "default": 10,
+                        "description": "Количество результатов на странице",
                         "name": "limit",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Текст песни с пагинацией",
+                        "description": "Список групп",
                         "schema": {
-                            "$ref": "#/definitions/models.SongTextResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Group"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Добавить группу без песен. Имена групп уникальны без учета регистра, пробелов и ё/е",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Добавить группу",
+                "parameters": [
+                    {
+                        "description": "Данные группы",
+                        "name": "group",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.GroupRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Созданная группа",
+                        "schema": {
+                            "$ref": "#/definitions/models.Group"
                         }
                     },
                     "400": {
-                        "description": "Неверные параметры",
+                        "description": "Неверный формат JSON",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Песня не найдена",
+                    "409": {
+                        "description": "Группа с таким именем уже существует",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибки валидации полей",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -297,34 +450,2812 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.AddSongRequest": {
-            "type": "object",
-            "properties": {
-                "artist": {
-                    "type": "string"
-                },
-                "text": {
-                    "type": "string"
-                },
-                "title": {
-                    "type": "string"
-                }
-            }
         },
-        "models.ErrorResponse": {
-            "type": "object",
-            "properties": {
-                "code": {
-                    "type": "integer"
-                },
+        "/api/groups/{id}": {
+            "get": {
+                "description": "Получить группу по ее ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Получить группу",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID группы",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Группа",
+                        "schema": {
+                            "$ref": "#/definitions/models.Group"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID группы",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Группа не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Переименовать группу. Поле group всех ее песен меняется, изменения записываются в историю песен",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Переименовать группу",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID группы",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Данные группы",
+                        "name": "group",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.GroupRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Обновленная группа",
+                        "schema": {
+                            "$ref": "#/definitions/models.Group"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат JSON",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Группа не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Группа или песня с таким именем уже существует",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибки валидации полей",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удалить группу по ее ID. Группу с песнями удалить нельзя",
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Удалить группу",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID группы",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Группа удалена"
+                    },
+                    "400": {
+                        "description": "Неверный ID группы",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Группа не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "У группы есть песни",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/groups/{id}/songs": {
+            "get": {
+                "description": "Получить неудаленные песни группы с пагинацией",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Получить песни группы",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID группы",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Количество результатов на странице",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Песни группы",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Song"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID группы",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Группа не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/jobs/{id}": {
+            "get": {
+                "description": "Получить статус асинхронного добавления песни: число попыток и последнюю ошибку",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Получить статус задачи",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID задачи",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Статус задачи",
+                        "schema": {
+                            "$ref": "#/definitions/models.Job"
+                        }
+                    },
+                    "404": {
+                        "description": "Задача не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/playlists": {
+            "get": {
+                "description": "Получить публичные плейлисты и плейлисты текущего пользователя (заголовок X-User) без записей",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlists"
+                ],
+                "summary": "Получить список плейлистов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Пользователь",
+                        "name": "X-User",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Количество результатов на странице",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Список плейлистов",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Playlist"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Создать пустой плейлист. Владельцем становится текущий пользователь (заголовок X-User)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlists"
+                ],
+                "summary": "Создать плейлист",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Пользователь",
+                        "name": "X-User",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Данные плейлиста",
+                        "name": "playlist",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaylistRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Созданный плейлист",
+                        "schema": {
+                            "$ref": "#/definitions/models.Playlist"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат JSON",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Не указан пользователь",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибки валидации полей",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/playlists/{id}": {
+            "get": {
+                "description": "Получить плейлист по его ID вместе с записями по порядку. Чужой приватный плейлист не найден",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlists"
+                ],
+                "summary": "Получить плейлист",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Пользователь",
+                        "name": "X-User",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID плейлиста",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Плейлист",
+                        "schema": {
+                            "$ref": "#/definitions/models.Playlist"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID плейлиста",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Плейлист не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Изменить название и видимость плейлиста. Доступно только владельцу",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlists"
+                ],
+                "summary": "Изменить плейлист",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Пользователь",
+                        "name": "X-User",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID плейлиста",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Данные плейлиста",
+                        "name": "playlist",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaylistRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Обновленный плейлист",
+                        "schema": {
+                            "$ref": "#/definitions/models.Playlist"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат JSON",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Не указан пользователь",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Плейлист принадлежит другому пользователю",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Плейлист не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибки валидации полей",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удалить плейлист вместе с записями. Песни не удаляются. Доступно только владельцу",
+                "tags": [
+                    "playlists"
+                ],
+                "summary": "Удалить плейлист",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Пользователь",
+                        "name": "X-User",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID плейлиста",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Плейлист удален"
+                    },
+                    "400": {
+                        "description": "Неверный ID плейлиста",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Не указан пользователь",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Плейлист принадлежит другому пользователю",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Плейлист не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/playlists/{id}/entries": {
+            "post": {
+                "description": "Вставить песню перед before_id или после after_id, без них - в конец. Остальные записи не переписываются. Доступно только владельцу",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlists"
+                ],
+                "summary": "Добавить песню в плейлист",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Пользователь",
+                        "name": "X-User",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID плейлиста",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Песня и место вставки",
+                        "name": "entry",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PlaylistEntryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Плейлист с новой записью",
+                        "schema": {
+                            "$ref": "#/definitions/models.Playlist"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат JSON",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Не указан пользователь",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Плейлист принадлежит другому пользователю",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Плейлист не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Песня или соседняя запись не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/playlists/{id}/entries/{entry_id}": {
+            "delete": {
+                "description": "Удалить запись из плейлиста. Песня не удаляется. Доступно только владельцу",
+                "tags": [
+                    "playlists"
+                ],
+                "summary": "Удалить запись плейлиста",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Пользователь",
+                        "name": "X-User",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID плейлиста",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID записи",
+                        "name": "entry_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Запись удалена"
+                    },
+                    "400": {
+                        "description": "Неверный ID",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Не указан пользователь",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Плейлист принадлежит другому пользователю",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Плейлист или запись не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/playlists/{id}/entries/{entry_id}/move": {
+            "post": {
+                "description": "Поставить запись перед before_id или после after_id, без них - в конец. Остальные записи не переписываются. Доступно только владельцу",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlists"
+                ],
+                "summary": "Переместить запись плейлиста",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Пользователь",
+                        "name": "X-User",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID плейлиста",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID записи",
+                        "name": "entry_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новое место записи",
+                        "name": "position",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.MoveEntryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Плейлист с новым порядком",
+                        "schema": {
+                            "$ref": "#/definitions/models.Playlist"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат JSON",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Не указан пользователь",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Плейлист принадлежит другому пользователю",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Плейлист или запись не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Соседняя запись не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/playlists/{id}/export": {
+            "get": {
+                "description": "Выгрузить плейлист в M3U или XSPF со ссылками песен. Записи без ссылки пропускаются",
+                "produces": [
+                    "audio/x-mpegurl",
+                    "application/xspf+xml"
+                ],
+                "tags": [
+                    "playlists"
+                ],
+                "summary": "Выгрузить плейлист",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Пользователь",
+                        "name": "X-User",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID плейлиста",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "m3u",
+                        "description": "Формат: m3u или xspf",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Файл плейлиста",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID или формат",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Плейлист не найден",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/songs": {
+            "get": {
+                "description": "Получить список песен с возможностью фильтрации по артисту, названию, тегам и жанрам\nи полнотекстового поиска по тексту песни. При поиске песни сортируются по релевантности (score).\nОтвет - страница с общим количеством песен и курсорами соседних страниц, они же в заголовке Link (RFC 8288).\nКурсорная пагинация устойчива к добавлению песен; page оставлен для совместимости.\nС facets=true страница содержит количество подходящих песен по тегам и жанрам.\nТекст песен в список не входит, если не указан в fields.\nФормат ответа выбирается по заголовку Accept: JSON, XML, CSV, YAML или text/plain (строка на песню)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "text/csv",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Получить список песен",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Фильтр по артисту",
+                        "name": "artist",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Фильтр по названию",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Полнотекстовый поиск по названию, группе и тексту",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Фильтр по группе",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Только песни со ссылкой (true) или без нее (false)",
+                        "name": "has_link",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дата релиза не раньше (2006, 2006-07, 16.07.2006)",
+                        "name": "released_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дата релиза не позже (2006, 2006-07, 16.07.2006)",
+                        "name": "released_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Создана не раньше (дата или RFC 3339)",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Создана не позже (дата или RFC 3339)",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Изменена не раньше (дата или RFC 3339)",
+                        "name": "updated_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Изменена не позже (дата или RFC 3339)",
+                        "name": "updated_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Выражение фильтра: сравнения полей (=, !=, \u003e, \u003e=, \u003c, \u003c=, ~ - подстрока) с and, or, not и скобками, например release_date\u003e=2000 and group~muse",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID альбома: только его треки, без sort и q - в порядке альбома",
+                        "name": "album",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Тег, можно повторять: песни со всеми тегами",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Жанр из словаря, можно повторять: песни со всеми жанрами",
+                        "name": "genre",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Вернуть объект с песнями и фасетами по тегам и жанрам",
+                        "name": "facets",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Сортировка: поля через запятую, минус - по убыванию (любая колонка песни, кроме text)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Поля песен через запятую, например id,title,group. По умолчанию все, кроме text",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор страницы из next_cursor или prev_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Номер страницы, если cursor не задан",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Количество результатов на странице, не больше SONGS_MAX_LIMIT",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Страница песен",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongPage"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "Ссылки на первую, предыдущую и следующую страницы"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "406": {
+                        "description": "Нет формата из заголовка Accept",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/songs/export": {
+            "get": {
+                "description": "Потоково выгружает все песни, подходящие под фильтры списка песен, в NDJSON, CSV или JSON.\nПесни читаются из базы курсором, поэтому размер выгрузки не ограничен памятью сервера.\nПо умолчанию выгружаются все поля, включая текст; в CSV теги и жанры разделены \";\"",
+                "produces": [
+                    "application/x-ndjson",
+                    "text/csv",
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Выгрузить каталог песен",
+                "parameters": [
+                    {
+                        "enum": [
+                            "ndjson",
+                            "csv",
+                            "json"
+                        ],
+                        "type": "string",
+                        "default": "ndjson",
+                        "description": "Формат выгрузки",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Фильтр по артисту",
+                        "name": "artist",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Фильтр по названию",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Полнотекстовый поиск по названию, группе и тексту",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Фильтр по группе",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Только песни со ссылкой (true) или без нее (false)",
+                        "name": "has_link",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дата релиза не раньше (2006, 2006-07, 16.07.2006)",
+                        "name": "released_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дата релиза не позже (2006, 2006-07, 16.07.2006)",
+                        "name": "released_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Создана не раньше (дата или RFC 3339)",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Создана не позже (дата или RFC 3339)",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Изменена не раньше (дата или RFC 3339)",
+                        "name": "updated_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Изменена не позже (дата или RFC 3339)",
+                        "name": "updated_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Выражение фильтра, как в GET /api/songs",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID альбома: только его треки",
+                        "name": "album",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Тег, можно повторять: песни со всеми тегами",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Жанр из словаря, можно повторять: песни со всеми жанрами",
+                        "name": "genre",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Сортировка, как в GET /api/songs",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Поля песен через запятую, по умолчанию все",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Песни",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Song"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/songs/import": {
+            "post": {
+                "description": "Массово добавляет песни из CSV (с заголовком: group, title, artist, release_date, text, link)\nили NDJSON (по объекту models.ImportSongRequest в строке). Формат задается параметром format\nили заголовком Content-Type: text/csv, application/x-ndjson. Данные читаются потоком\nи сохраняются пачками в транзакциях. Песни, которые уже есть в каталоге, пропускаются.\nС enrich=true пустые поля заполняются из внешнего API.\nОтвет содержит результат каждой строки: created, skipped или failed",
+                "consumes": [
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Импортировать песни",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "ndjson"
+                        ],
+                        "type": "string",
+                        "description": "Формат входных данных",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Заполнить пустые поля из внешнего API",
+                        "name": "enrich",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Отчет об импорте",
+                        "schema": {
+                            "$ref": "#/definitions/models.ImportReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный заголовок CSV или входные данные не удалось дочитать",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Неподдерживаемый формат",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/songs/tag": {
+            "post": {
+                "description": "Добавить теги и жанры из словаря сразу нескольким песням. Каждая измененная песня получает новую версию",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Добавить теги и жанры песням",
+                "parameters": [
+                    {
+                        "description": "Песни, теги и жанры",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.BulkTagRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Количество измененных песен",
+                        "schema": {
+                            "$ref": "#/definitions/models.BulkTagResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат JSON",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибки валидации полей",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/songs/untag": {
+            "post": {
+                "description": "Удалить теги и жанры сразу у нескольких песен. Каждая измененная песня получает новую версию",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Удалить теги и жанры у песен",
+                "parameters": [
+                    {
+                        "description": "Песни, теги и жанры",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.BulkTagRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Количество измененных песен",
+                        "schema": {
+                            "$ref": "#/definitions/models.BulkTagResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат JSON",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибки валидации полей",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/songs/{id}/restore": {
+            "post": {
+                "description": "Вернуть удаленную песню по ее ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Восстановить песню из корзины",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Восстановленная песня",
+                        "schema": {
+                            "$ref": "#/definitions/models.Song"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID песни",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена в корзине",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Песня с такой группой и названием уже существует",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/songs/{id}/revisions": {
+            "get": {
+                "description": "Получить все ревизии песни: кто, когда и какие поля изменил, и снимок песни после изменения",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "revisions"
+                ],
+                "summary": "Получить историю изменений песни",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ревизии песни по возрастанию номера",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SongRevision"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID песни",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/songs/{id}/revisions/diff": {
+            "get": {
+                "description": "Получить изменения полей и секций текста (куплетов, припевов) между двумя ревизиями.\nПо умолчанию to - последняя ревизия, from - предыдущая перед to",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "revisions"
+                ],
+                "summary": "Сравнить две ревизии песни",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер исходной ревизии",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер конечной ревизии",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Разница между ревизиями",
+                        "schema": {
+                            "$ref": "#/definitions/models.RevisionDiff"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня или ревизия не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/songs/{id}/revisions/{rev}/restore": {
+            "post": {
+                "description": "Вернуть песне состояние из указанной ревизии. Восстановление записывается как новая ревизия",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "revisions"
+                ],
+                "summary": "Восстановить ревизию песни",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер ревизии",
+                        "name": "rev",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag песни",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Восстановленная песня",
+                        "schema": {
+                            "$ref": "#/definitions/models.Song"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня или ревизия не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Песня с такой группой и названием уже существует",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Песня была изменена",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/trash": {
+            "get": {
+                "description": "Получить удаленные песни, начиная с удаленных последними. Песни хранятся в корзине ограниченное время, после чего удаляются окончательно",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Получить песни из корзины",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Количество результатов на странице",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Список удаленных песен",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Song"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs": {
+            "post": {
+                "description": "Добавляет песню в базу данных, получая информацию о песне из внешнего API.\nС параметром async=true или заголовком \"Prefer: respond-async\" песня сохраняется в статусе pending,\nа данные внешнего API заполняются в фоне; статус доступен по GET /api/jobs/{id}.\nС заголовком Idempotency-Key первый ответ сохраняется и возвращается на повторы с тем же ключом.\nЕсли песня с той же группой и названием (без учета регистра, пробелов и ё/е) уже есть,\nвозвращается 409 со ссылкой на нее в заголовке Location, а с on_conflict=update она обновляется.\nПесня, которую не удалось добавить в фоне (статус failed), добавляется заново без on_conflict, как новая.\nС album_id песня добавляется в конец альбома",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Добавить песню",
+                "parameters": [
+                    {
+                        "description": "Данные для добавления песни",
+                        "name": "song",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.AddSongRequest"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Асинхронное добавление",
+                        "name": "async",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ключ идемпотентности запроса",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "enum": [
+                            "error",
+                            "update"
+                        ],
+                        "type": "string",
+                        "default": "error",
+                        "description": "Поведение, если песня уже существует",
+                        "name": "on_conflict",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Существующая песня обновлена (on_conflict=update)",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "201": {
+                        "description": "Песня успешно добавлена",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "202": {
+                        "description": "Задача на добавление принята",
+                        "schema": {
+                            "$ref": "#/definitions/models.JobAcceptedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат данных",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена во внешнем API",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Песня уже существует или запрос с этим ключом идемпотентности еще выполняется",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибки валидации полей или ключ идемпотентности использован с другим запросом",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при обработке запроса",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Некорректный ответ внешнего API",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Внешний API недоступен или очередь фоновых задач заполнена",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}": {
+            "get": {
+                "description": "Получить песню по ее ID. Ответ содержит ETag, зависящий от версии песни и формата ответа;\nс заголовком If-None-Match возвращается 304, если песня не изменилась.\nФормат ответа выбирается по заголовку Accept: JSON, XML, CSV, YAML или text/plain (только текст песни)",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "text/csv",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Получить песню",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag песни",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Песня",
+                        "schema": {
+                            "$ref": "#/definitions/models.Song"
+                        }
+                    },
+                    "304": {
+                        "description": "Песня не изменилась"
+                    },
+                    "400": {
+                        "description": "Неверный ID песни",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "406": {
+                        "description": "Нет формата из заголовка Accept",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Обновить песню по ее ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Обновить информацию о песне",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag песни",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Данные для обновления песни",
+                        "name": "song",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateSongRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Песня обновлена успешно",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат JSON",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Песня с такой группой и названием уже существует",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Песня была изменена",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибки валидации полей",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Переместить песню в корзину по ее ID. С заголовком If-Match песня удаляется, только если ее ETag совпадает",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Удалить песню",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag песни",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Песня удалена успешно",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный ID песни",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Песня была изменена",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Изменить только переданные поля песни. Поддерживаются JSON Merge Patch (RFC 7396,\nContent-Type: application/merge-patch+json или application/json) и JSON Patch\n(RFC 6902, Content-Type: application/json-patch+json). Пути и ключи соответствуют полям UpdateSongRequest.\nПроверяются только поля, измененные патчем. Формат ответа выбирается по заголовку Accept, как в GET",
+                "consumes": [
+                    "application/json",
+                    "application/merge-patch+json",
+                    "application/json-patch+json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "text/csv",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Частично обновить песню",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag песни",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Патч песни",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateSongRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Обновленная песня",
+                        "schema": {
+                            "$ref": "#/definitions/models.Song"
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат патча",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Не выполнена операция test или песня с такой группой и названием уже существует",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Песня была изменена",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "406": {
+                        "description": "Нет формата из заголовка Accept",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Неподдерживаемый Content-Type",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Патч не применим или ошибки валидации полей",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/text": {
+            "get": {
+                "description": "Получить текст песни по ее ID, разбитый на секции (куплет, припев, бридж, вступление, концовка),\nс возможностью пагинации по секциям. ETag ответа зависит от версии песни, параметров page, limit, section и collapse и формата ответа.\nФормат ответа выбирается по заголовку Accept: JSON, XML, CSV, YAML или text/plain (секции через пустую строку)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "text/csv",
+                    "application/yaml",
+                    "text/plain"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Получить текст песни",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 2,
+                        "description": "Количество секций на странице",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "verse",
+                            "chorus",
+                            "bridge",
+                            "intro",
+                            "outro"
+                        ],
+                        "type": "string",
+                        "description": "Только секции указанного типа",
+                        "name": "section",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Схлопнуть повторяющиеся припевы",
+                        "name": "collapse",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag страницы текста",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Текст песни с пагинацией",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongTextResponse"
+                        }
+                    },
+                    "304": {
+                        "description": "Песня не изменилась"
+                    },
+                    "400": {
+                        "description": "Неверные параметры",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "406": {
+                        "description": "Нет формата из заголовка Accept",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "models.AddSongRequest": {
+            "description": "Группа и название песни, остальные данные запрашиваются во внешнем API",
+            "type": "object",
+            "required": [
+                "group",
+                "song"
+            ],
+            "properties": {
+                "album_id": {
+                    "description": "AlbumID альбом, в конец которого добавляется песня",
+                    "type": "integer",
+                    "example": 1
+                },
+                "group": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "Muse"
+                },
+                "song": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "Supermassive Black Hole"
+                }
+            }
+        },
+        "models.Album": {
+            "description": "Альбом группы. Песня может входить в несколько альбомов",
+            "type": "object",
+            "properties": {
+                "cover_link": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "group_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "release_date": {
+                    "type": "string",
+                    "example": "2006-07-03"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "tracks": {
+                    "description": "Tracks заполняется только при получении одного альбома",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AlbumTrack"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AlbumRequest": {
+            "description": "Альбом и, при необходимости, его треки в порядке song_ids",
+            "type": "object",
+            "required": [
+                "title"
+            ],
+            "properties": {
+                "cover_link": {
+                    "type": "string",
+                    "maxLength": 2048
+                },
+                "group": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "Muse"
+                },
+                "release_date": {
+                    "type": "string",
+                    "example": "03.07.2006"
+                },
+                "song_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        1,
+                        2
+                    ]
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "Black Holes and Revelations"
+                }
+            }
+        },
+        "models.AlbumTrack": {
+            "description": "Песня на позиции в альбоме",
+            "type": "object",
+            "properties": {
+                "position": {
+                    "type": "integer"
+                },
+                "song_id": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AlbumTracksRequest": {
+            "description": "Полный список ID песен альбома в нужном порядке: отсутствующие в списке песни исключаются из альбома",
+            "type": "object",
+            "properties": {
+                "song_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        2,
+                        1
+                    ]
+                }
+            }
+        },
+        "models.BulkTagRequest": {
+            "description": "Теги и жанры, которые добавляются песням song_ids или удаляются у них",
+            "type": "object",
+            "properties": {
+                "genres": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "rock"
+                    ]
+                },
+                "song_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        1,
+                        2
+                    ]
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "live",
+                        "cover"
+                    ]
+                }
+            }
+        },
+        "models.BulkTagResult": {
+            "description": "Количество песен, у которых изменились теги или жанры",
+            "type": "object",
+            "properties": {
+                "updated": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "models.DuplicateGroup": {
+            "description": "Группа похожих песен, кандидатов на объединение",
+            "type": "object",
+            "properties": {
+                "key": {
+                    "type": "string"
+                },
+                "songs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Song"
+                    }
+                }
+            }
+        },
+        "models.ErrorResponse": {
+            "description": "Ошибка в формате problem+json с машиночитаемым кодом",
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FieldError"
+                    }
+                },
+                "instance": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.FacetCount": {
+            "description": "Значение фасета и число подходящих песен",
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "value": {
+                    "type": "string",
+                    "example": "rock"
+                }
+            }
+        },
+        "models.Facets": {
+            "description": "Фасеты по тегам и жанрам, от самых частых значений",
+            "type": "object",
+            "properties": {
+                "genres": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FacetCount"
+                    }
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FacetCount"
+                    }
+                }
+            }
+        },
+        "models.FieldChange": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.FieldError": {
+            "description": "Ошибка в поле тела или параметре запроса",
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "field": {
+                    "type": "string"
+                },
                 "message": {
                     "type": "string"
                 }
             }
         },
+        "models.Genre": {
+            "description": "Жанр, который можно назначить песне",
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "Rock"
+                },
+                "slug": {
+                    "type": "string",
+                    "example": "rock"
+                }
+            }
+        },
+        "models.Group": {
+            "description": "Группа, с которой связаны песни. Группы создаются автоматически по полю group песни",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.GroupRequest": {
+            "description": "Название группы. При переименовании меняется и поле group связанных песен",
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "Muse"
+                }
+            }
+        },
+        "models.ImportReport": {
+            "description": "Количество созданных, пропущенных и ошибочных строк и результат каждой строки",
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ImportRowResult"
+                    }
+                },
+                "skipped": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ImportRowResult": {
+            "description": "Строка создана, пропущена как уже существующая или не импортирована из-за ошибки",
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FieldError"
+                    }
+                },
+                "line": {
+                    "description": "Line номер строки во входных данных, включая заголовок CSV",
+                    "type": "integer",
+                    "example": 2
+                },
+                "song_id": {
+                    "description": "SongID созданная песня или уже существующая для пропущенной строки",
+                    "type": "integer",
+                    "example": 1
+                },
+                "status": {
+                    "type": "string",
+                    "example": "created"
+                }
+            }
+        },
+        "models.Job": {
+            "description": "Статус асинхронного добавления песни",
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "max_attempts": {
+                    "type": "integer"
+                },
+                "song_id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.JobAcceptedResponse": {
+            "description": "Ответ 202 с идентификатором задачи",
+            "type": "object",
+            "properties": {
+                "job_id": {
+                    "type": "string"
+                },
+                "song_id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.LineDiff": {
+            "type": "object",
+            "properties": {
+                "op": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.MergeSongsRequest": {
+            "description": "Песни source_ids переносятся в корзину, их незаполненные у target_id поля копируются в target_id",
+            "type": "object",
+            "properties": {
+                "source_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        2,
+                        3
+                    ]
+                },
+                "target_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.MoveEntryRequest": {
+            "description": "Запись ставится перед before_id или после after_id, без них - в конец плейлиста",
+            "type": "object",
+            "properties": {
+                "after_id": {
+                    "type": "integer"
+                },
+                "before_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Playlist": {
+            "description": "Упорядоченный список песен. Приватный плейлист виден только владельцу",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "entries": {
+                    "description": "Entries заполняется только при получении одного плейлиста",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PlaylistEntry"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "owner": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "visibility": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PlaylistEntry": {
+            "description": "Песня на позиции в плейлисте",
+            "type": "object",
+            "properties": {
+                "added_at": {
+                    "type": "string"
+                },
+                "artist": {
+                    "type": "string"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "link": {
+                    "type": "string"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "song_id": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PlaylistEntryRequest": {
+            "description": "Песня вставляется перед before_id или после after_id, без них - в конец плейлиста",
+            "type": "object",
+            "properties": {
+                "after_id": {
+                    "type": "integer"
+                },
+                "before_id": {
+                    "type": "integer"
+                },
+                "song_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.PlaylistRequest": {
+            "description": "Название и видимость плейлиста, по умолчанию private",
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "Road trip"
+                },
+                "visibility": {
+                    "type": "string",
+                    "enum": [
+                        "public",
+                        "private"
+                    ],
+                    "example": "private"
+                }
+            }
+        },
+        "models.RevisionDiff": {
+            "description": "Изменения полей и секций текста между двумя ревизиями",
+            "type": "object",
+            "properties": {
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FieldChange"
+                    }
+                },
+                "from": {
+                    "type": "integer"
+                },
+                "sections": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SectionDiff"
+                    }
+                },
+                "song_id": {
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SectionDiff": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.LineDiff"
+                    }
+                },
+                "op": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
         "models.Song": {
             "description": "Структура для описания песни",
             "type": "object",
@@ -335,18 +3266,47 @@ const docTemplate = `{
                 "created_at": {
                     "type": "string"
                 },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "genres": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "group": {
                     "type": "string"
                 },
                 "id": {
                     "type": "integer"
                 },
+                "language": {
+                    "type": "string"
+                },
                 "link": {
                     "type": "string"
                 },
+                "position": {
+                    "type": "integer"
+                },
                 "release_date": {
+                    "type": "string",
+                    "example": "2006-07-16"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "status": {
                     "type": "string"
                 },
+                "tags": {
+                    "description": "Tags и Genres заполняются хранилищем при получении песни и списка песен",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "text": {
                     "type": "string"
                 },
@@ -355,6 +3315,112 @@ const docTemplate = `{
                 },
                 "updated_at": {
                     "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.SongPage": {
+            "description": "Песни на странице, общее количество подходящих песен и курсоры соседних страниц",
+            "type": "object",
+            "properties": {
+                "facets": {
+                    "description": "Facets заполняются по запросу facets=true",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Facets"
+                        }
+                    ]
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "next_cursor": {
+                    "description": "NextCursor и PrevCursor пусты, если соседней страницы нет",
+                    "type": "string"
+                },
+                "prev_cursor": {
+                    "type": "string"
+                },
+                "songs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Song"
+                    }
+                },
+                "total": {
+                    "description": "Total количество всех песен, подходящих под фильтры",
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "models.SongRevision": {
+            "description": "Ревизия песни: кто, когда и какие поля изменил",
+            "type": "object",
+            "properties": {
+                "artist": {
+                    "type": "string"
+                },
+                "changed_by": {
+                    "type": "string"
+                },
+                "changed_fields": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "link": {
+                    "type": "string"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "release_date": {
+                    "type": "string",
+                    "example": "2006-07-16"
+                },
+                "revision": {
+                    "type": "integer"
+                },
+                "song_id": {
+                    "type": "integer"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SongSection": {
+            "description": "Куплет, припев, бридж, вступление или концовка",
+            "type": "object",
+            "properties": {
+                "index": {
+                    "type": "integer"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "repeats": {
+                    "type": "integer"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
                 }
             }
         },
@@ -368,6 +3434,12 @@ const docTemplate = `{
                 "page": {
                     "type": "integer"
                 },
+                "sections": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SongSection"
+                    }
+                },
                 "total_verses": {
                     "type": "integer"
                 },
@@ -378,6 +3450,36 @@ const docTemplate = `{
                     }
                 }
             }
+        },
+        "models.UpdateSongRequest": {
+            "description": "Поля песни для обновления, отсутствующие поля сохраняют текущее значение",
+            "type": "object",
+            "properties": {
+                "artist": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "group": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "link": {
+                    "type": "string",
+                    "maxLength": 2048
+                },
+                "release_date": {
+                    "type": "string",
+                    "example": "16.07.2006"
+                },
+                "text": {
+                    "type": "string",
+                    "maxLength": 50000
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
         }
     }
 }`
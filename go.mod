@@ -33,13 +33,13 @@ require (
 	golang.org/x/sys v0.25.0 // indirect
 	golang.org/x/text v0.18.0 // indirect
 	golang.org/x/tools v0.24.0 // indirect
-	gopkg.in/yaml.v2 v2.4.0 // indirect
 )
 
 require (
 	github.com/joho/godotenv v1.5.1
 	github.com/sirupsen/logrus v1.9.3
 	github.com/swaggo/http-swagger v1.3.4
+	gopkg.in/yaml.v2 v2.4.0
 	gorm.io/driver/postgres v1.5.10
 	gorm.io/gorm v1.25.12
 )
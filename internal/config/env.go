@@ -0,0 +1,39 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/w212w/GoProjectEM/internal/logger"
+)
+
+// Int читает целое число из переменной окружения key, при отсутствии
+// или ошибке разбора возвращает def.
+func Int(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		logger.Log.Warnf("Invalid integer in %s: %v", key, err)
+		return def
+	}
+	return n
+}
+
+// Duration читает длительность (например, "5s") из переменной окружения key,
+// при отсутствии или ошибке разбора возвращает def.
+func Duration(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		logger.Log.Warnf("Invalid duration in %s: %v", key, err)
+		return def
+	}
+	return d
+}
@@ -0,0 +1,76 @@
+package enricher
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	b := newCircuitBreaker(2, time.Minute)
+	b.now = func() time.Time { return now }
+
+	b.failure()
+	if !b.allow() {
+		t.Fatal("breaker opened before threshold")
+	}
+	b.failure()
+	if b.allow() {
+		t.Fatal("breaker is closed after threshold failures")
+	}
+
+	now = now.Add(time.Minute)
+	if !b.allow() {
+		t.Fatal("no trial request after cooldown")
+	}
+	if b.allow() {
+		t.Fatal("second request allowed while half-open")
+	}
+
+	// Неудачный пробный запрос снова размыкает breaker на cooldown
+	b.failure()
+	if b.allow() {
+		t.Fatal("breaker is closed after failed trial")
+	}
+
+	now = now.Add(time.Minute)
+	if !b.allow() {
+		t.Fatal("no trial request after second cooldown")
+	}
+	b.success()
+	if !b.allow() || !b.allow() {
+		t.Fatal("breaker is not closed after successful trial")
+	}
+}
+
+func TestCircuitBreakerRelease(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	b := newCircuitBreaker(1, time.Minute)
+	b.now = func() time.Time { return now }
+
+	b.failure()
+	now = now.Add(time.Minute)
+	if !b.allow() {
+		t.Fatal("no trial request after cooldown")
+	}
+
+	// Отмененный пробный запрос не считается ни успехом, ни ошибкой:
+	// следующий запрос снова пробный
+	b.release()
+	if !b.allow() {
+		t.Fatal("no trial request after release")
+	}
+	if b.allow() {
+		t.Fatal("second request allowed while half-open")
+	}
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	b := newCircuitBreaker(0, time.Minute)
+	for range 10 {
+		b.failure()
+	}
+	if !b.allow() {
+		t.Error("disabled breaker rejected request")
+	}
+}
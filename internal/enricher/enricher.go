@@ -0,0 +1,47 @@
+package enricher
+
+import (
+	"context"
+	"errors"
+
+	"github.com/w212w/GoProjectEM/internal/logger"
+	"github.com/w212w/GoProjectEM/internal/models"
+)
+
+// Типизированные ошибки обогащения. Реализации оборачивают их через fmt.Errorf("%w"),
+// поэтому проверять их следует через errors.Is.
+var (
+	// ErrNotFound внешний API не знает о запрошенной песне
+	ErrNotFound = errors.New("song info not found")
+	// ErrUnavailable внешний API недоступен: таймаут, 5xx или открыт circuit breaker
+	ErrUnavailable = errors.New("song info provider unavailable")
+	// ErrBadPayload внешний API вернул ответ, который не удалось разобрать
+	ErrBadPayload = errors.New("bad song info payload")
+)
+
+// SongInfo метаданные песни, полученные из внешнего источника
+type SongInfo struct {
+	Artist      string `json:"artist"`
+	ReleaseDate string `json:"releaseDate"`
+	Text        string `json:"text"`
+	Link        string `json:"link"`
+}
+
+// Apply переносит метаданные в песню. Нераспознанная дата релиза не считается
+// ошибкой: песня сохраняется без даты, а в лог пишется предупреждение.
+func (i *SongInfo) Apply(song *models.Song) {
+	song.Artist = i.Artist
+	song.Text = i.Text
+	song.Link = i.Link
+
+	releaseDate, err := models.ParseReleaseDate(i.ReleaseDate)
+	if err != nil {
+		logger.Log.Warnf("Enricher: %v", err)
+	}
+	song.ReleaseDate = releaseDate
+}
+
+// Enricher источник метаданных песни по группе и названию
+type Enricher interface {
+	Enrich(ctx context.Context, group, song string) (*SongInfo, error)
+}
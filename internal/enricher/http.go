@@ -0,0 +1,176 @@
+package enricher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/w212w/GoProjectEM/internal/config"
+	"github.com/w212w/GoProjectEM/internal/logger"
+)
+
+// Config настройки HTTP-клиента внешнего API
+type Config struct {
+	BaseURL          string
+	Timeout          time.Duration
+	MaxRetries       int
+	BaseBackoff      time.Duration
+	MaxBackoff       time.Duration
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+// DefaultConfig настройки по умолчанию
+func DefaultConfig() Config {
+	return Config{
+		Timeout:          5 * time.Second,
+		MaxRetries:       3,
+		BaseBackoff:      200 * time.Millisecond,
+		MaxBackoff:       2 * time.Second,
+		BreakerThreshold: 5,
+		BreakerCooldown:  30 * time.Second,
+	}
+}
+
+// ConfigFromEnv читает настройки из переменных окружения EXTERNAL_API_*,
+// для отсутствующих значений используются значения по умолчанию.
+func ConfigFromEnv() Config {
+	cfg := DefaultConfig()
+	cfg.BaseURL = os.Getenv("EXTERNAL_API_BASE_URL")
+	cfg.Timeout = config.Duration("EXTERNAL_API_TIMEOUT", cfg.Timeout)
+	cfg.MaxRetries = config.Int("EXTERNAL_API_MAX_RETRIES", cfg.MaxRetries)
+	cfg.BaseBackoff = config.Duration("EXTERNAL_API_BACKOFF", cfg.BaseBackoff)
+	cfg.MaxBackoff = config.Duration("EXTERNAL_API_MAX_BACKOFF", cfg.MaxBackoff)
+	cfg.BreakerThreshold = config.Int("EXTERNAL_API_BREAKER_THRESHOLD", cfg.BreakerThreshold)
+	cfg.BreakerCooldown = config.Duration("EXTERNAL_API_BREAKER_COOLDOWN", cfg.BreakerCooldown)
+	return cfg
+}
+
+// HTTPEnricher получает метаданные песни из внешнего API (GET {BaseURL}/info)
+// с таймаутом, повторами с jitter и circuit breaker.
+type HTTPEnricher struct {
+	cfg     Config
+	client  *http.Client
+	breaker *circuitBreaker
+}
+
+func NewHTTPEnricher(cfg Config) *HTTPEnricher {
+	return &HTTPEnricher{
+		cfg:     cfg,
+		client:  &http.Client{Timeout: cfg.Timeout},
+		breaker: newCircuitBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+	}
+}
+
+func (e *HTTPEnricher) Enrich(ctx context.Context, group, song string) (*SongInfo, error) {
+	if e.cfg.BaseURL == "" {
+		return nil, fmt.Errorf("%w: external API base URL not configured", ErrUnavailable)
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= e.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if err := sleep(ctx, e.backoff(attempt)); err != nil {
+				return nil, err
+			}
+		}
+
+		if !e.breaker.allow() {
+			return nil, fmt.Errorf("%w: circuit breaker is open", ErrUnavailable)
+		}
+
+		info, err := e.fetch(ctx, group, song)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			// Запрос отменен вызывающим: это не сбой внешнего API,
+			// повторять его и учитывать в circuit breaker нельзя
+			e.breaker.release()
+			return nil, ctxErr
+		}
+		if err == nil {
+			e.breaker.success()
+			return info, nil
+		}
+		if !errors.Is(err, ErrUnavailable) {
+			// Ответ получен, сервис жив: повтор не поможет
+			e.breaker.success()
+			return nil, err
+		}
+
+		e.breaker.failure()
+		lastErr = err
+		logger.Log.Warnf("Enricher: attempt %d/%d failed: %v", attempt+1, e.cfg.MaxRetries+1, err)
+	}
+	return nil, lastErr
+}
+
+func (e *HTTPEnricher) fetch(ctx context.Context, group, song string) (*SongInfo, error) {
+	query := url.Values{}
+	query.Set("group", group)
+	query.Set("song", song)
+	endpoint := e.cfg.BaseURL + "/info?" + query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadPayload, err)
+	}
+
+	logger.Log.Debugf("Enricher: making request to external API: %s", endpoint)
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s - %s", ErrNotFound, group, song)
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return nil, fmt.Errorf("%w: upstream returned %s", ErrUnavailable, resp.Status)
+	default:
+		return nil, fmt.Errorf("%w: unexpected upstream status %s", ErrBadPayload, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+
+	var info SongInfo
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadPayload, err)
+	}
+	return &info, nil
+}
+
+// backoff экспоненциальная задержка с full jitter
+func (e *HTTPEnricher) backoff(attempt int) time.Duration {
+	if e.cfg.BaseBackoff <= 0 {
+		return 0
+	}
+	d := e.cfg.BaseBackoff << (attempt - 1)
+	if d <= 0 || (e.cfg.MaxBackoff > 0 && d > e.cfg.MaxBackoff) {
+		d = e.cfg.MaxBackoff
+	}
+	return time.Duration(rand.Int64N(int64(d) + 1))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
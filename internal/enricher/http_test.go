@@ -0,0 +1,142 @@
+package enricher
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/w212w/GoProjectEM/internal/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// newTestAPI запускает внешний API, отвечающий по порядку статусами statuses;
+// последний статус повторяется. Возвращает количество полученных запросов.
+func newTestAPI(t *testing.T, body string, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1))
+		if r.URL.Path != "/info" || r.URL.Query().Get("group") != "Muse" || r.URL.Query().Get("song") != "Uprising" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		status := statuses[min(n, len(statuses))-1]
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			io.WriteString(w, body)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func testConfig(baseURL string) Config {
+	return Config{BaseURL: baseURL, Timeout: time.Second, MaxRetries: 2}
+}
+
+func TestHTTPEnricherEnrich(t *testing.T) {
+	const info = `{"artist":"Matthew Bellamy","releaseDate":"07.09.2009","text":"Paranoia is in bloom","link":"https://example.com"}`
+
+	tests := []struct {
+		name     string
+		body     string
+		statuses []int
+		wantErr  error
+		calls    int32
+	}{
+		{"ok", info, []int{http.StatusOK}, nil, 1},
+		{"retried until success", info, []int{http.StatusInternalServerError, http.StatusTooManyRequests, http.StatusOK}, nil, 3},
+		{"retries exhausted", info, []int{http.StatusBadGateway}, ErrUnavailable, 3},
+		{"not found is not retried", info, []int{http.StatusNotFound}, ErrNotFound, 1},
+		{"client error is not retried", info, []int{http.StatusBadRequest}, ErrBadPayload, 1},
+		{"invalid payload", `{"artist":`, []int{http.StatusOK}, ErrBadPayload, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, calls := newTestAPI(t, tt.body, tt.statuses...)
+			e := NewHTTPEnricher(testConfig(server.URL))
+
+			got, err := e.Enrich(context.Background(), "Muse", "Uprising")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && (got.Artist != "Matthew Bellamy" || got.ReleaseDate != "07.09.2009") {
+				t.Errorf("info = %+v", got)
+			}
+			if n := calls.Load(); n != tt.calls {
+				t.Errorf("requests = %d, want %d", n, tt.calls)
+			}
+		})
+	}
+}
+
+func TestHTTPEnricherBreakerOpens(t *testing.T) {
+	server, calls := newTestAPI(t, "", http.StatusServiceUnavailable)
+	cfg := testConfig(server.URL)
+	cfg.MaxRetries = 0
+	cfg.BreakerThreshold = 2
+	cfg.BreakerCooldown = time.Hour
+	e := NewHTTPEnricher(cfg)
+
+	for range 3 {
+		if _, err := e.Enrich(context.Background(), "Muse", "Uprising"); !errors.Is(err, ErrUnavailable) {
+			t.Fatalf("err = %v, want ErrUnavailable", err)
+		}
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("requests = %d, want 2: open breaker must not call the API", n)
+	}
+}
+
+func TestHTTPEnricherNotConfigured(t *testing.T) {
+	e := NewHTTPEnricher(Config{})
+	if _, err := e.Enrich(context.Background(), "Muse", "Uprising"); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("err = %v, want ErrUnavailable", err)
+	}
+}
+
+func TestHTTPEnricherCanceled(t *testing.T) {
+	server, _ := newTestAPI(t, "", http.StatusServiceUnavailable)
+	cfg := testConfig(server.URL)
+	cfg.BreakerThreshold = 1
+	e := NewHTTPEnricher(cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := e.Enrich(ctx, "Muse", "Uprising"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	// Отмена вызывающим не размыкает breaker
+	if !e.breaker.allow() {
+		t.Error("breaker opened by canceled request")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	e := NewHTTPEnricher(Config{BaseBackoff: 100 * time.Millisecond, MaxBackoff: time.Second})
+	for attempt := 1; attempt <= 70; attempt++ {
+		limit := time.Second
+		if attempt <= 4 {
+			limit = 100 * time.Millisecond << (attempt - 1)
+		}
+		for range 20 {
+			if d := e.backoff(attempt); d < 0 || d > limit {
+				t.Fatalf("backoff(%d) = %s, want within [0, %s]", attempt, d, limit)
+			}
+		}
+	}
+
+	if d := NewHTTPEnricher(Config{}).backoff(3); d != 0 {
+		t.Errorf("backoff without BaseBackoff = %s, want 0", d)
+	}
+}
@@ -0,0 +1,283 @@
+// Package filterexpr разбирает компактные выражения фильтра списка песен,
+// например release_date>=2000 and group~"muse", и переводит их в
+// параметризованный SQL или проверяет ими песни в памяти.
+package filterexpr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/w212w/GoProjectEM/internal/models"
+)
+
+// Expr разобранное выражение фильтра
+type Expr interface {
+	// SQL возвращает условие WHERE по таблице songs с плейсхолдерами ? и их значения.
+	// Условие никогда не равно NULL, поэтому not ведет себя так же, как Match.
+	SQL() (string, []any)
+	// Match проверяет песню тем же условием
+	Match(s *models.Song) bool
+}
+
+type fieldKind int
+
+const (
+	kindInt fieldKind = iota
+	kindString
+	kindDate
+	kindTime
+)
+
+type field struct {
+	column string
+	kind   fieldKind
+	get    func(s *models.Song) any
+}
+
+// fields поля, доступные в выражениях. Ключ совпадает с именем колонки таблицы songs.
+var fields = map[string]field{
+	"id":       {kind: kindInt, get: func(s *models.Song) any { return int64(s.ID) }},
+	"version":  {kind: kindInt, get: func(s *models.Song) any { return int64(s.Version) }},
+	"title":    {kind: kindString, get: func(s *models.Song) any { return s.Title }},
+	"artist":   {kind: kindString, get: func(s *models.Song) any { return s.Artist }},
+	"group":    {kind: kindString, get: func(s *models.Song) any { return s.Group }},
+	"link":     {kind: kindString, get: func(s *models.Song) any { return s.Link }},
+	"text":     {kind: kindString, get: func(s *models.Song) any { return s.Text }},
+	"status":   {kind: kindString, get: func(s *models.Song) any { return s.Status }},
+	"language": {kind: kindString, get: func(s *models.Song) any { return s.Language }},
+	"release_date": {kind: kindDate, get: func(s *models.Song) any {
+		if s.ReleaseDate.IsZero() {
+			return nil
+		}
+		return s.ReleaseDate.Start()
+	}},
+	"created_at": {kind: kindTime, get: func(s *models.Song) any { return s.CreatedAt }},
+	"updated_at": {kind: kindTime, get: func(s *models.Song) any { return s.UpdatedAt }},
+}
+
+func init() {
+	for name, f := range fields {
+		f.column = `songs."` + name + `"`
+		fields[name] = f
+	}
+}
+
+type logical struct {
+	op          string
+	left, right Expr
+}
+
+func (e *logical) SQL() (string, []any) {
+	l, largs := e.left.SQL()
+	r, rargs := e.right.SQL()
+	return "(" + l + " " + e.op + " " + r + ")", append(largs, rargs...)
+}
+
+func (e *logical) Match(s *models.Song) bool {
+	if e.op == "AND" {
+		return e.left.Match(s) && e.right.Match(s)
+	}
+	return e.left.Match(s) || e.right.Match(s)
+}
+
+type not struct {
+	inner Expr
+}
+
+func (e *not) SQL() (string, []any) {
+	sql, args := e.inner.SQL()
+	return "NOT " + sql, args
+}
+
+func (e *not) Match(s *models.Song) bool {
+	return !e.inner.Match(s)
+}
+
+// comparison сравнение поля со значением. Для дат значение задает период
+// [start, end): release_date=2006 означает любую дату 2006 года.
+type comparison struct {
+	field  field
+	op     string
+	isNull bool
+	// value значение для int и string
+	value any
+	// start и end период для date и time
+	start, end time.Time
+}
+
+func newComparison(f field, op, raw string, isNull bool) (*comparison, error) {
+	c := &comparison{field: f, op: op, isNull: isNull}
+	if isNull {
+		if op != "=" && op != "!=" {
+			return nil, fmt.Errorf("null can only be compared with = or !=")
+		}
+		if f.kind == kindInt || f.kind == kindTime {
+			return nil, fmt.Errorf("field is never null")
+		}
+		return c, nil
+	}
+	if (op == "~" || op == "!~") && f.kind != kindString {
+		return nil, fmt.Errorf("operator %s applies only to text fields", op)
+	}
+
+	switch f.kind {
+	case kindInt:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected an integer, got %q", raw)
+		}
+		c.value = n
+	case kindString:
+		if op != "=" && op != "!=" && op != "~" && op != "!~" {
+			return nil, fmt.Errorf("text fields support only =, !=, ~ and !~")
+		}
+		c.value = raw
+	case kindDate, kindTime:
+		start, end, err := ParseTimeRange(raw)
+		if err != nil {
+			return nil, err
+		}
+		c.start, c.end = start, end
+	}
+	return c, nil
+}
+
+// ParseTimeRange разбирает момент времени в RFC 3339 или дату с точностью до года,
+// месяца или дня (форматы models.ParseReleaseDate) и возвращает период [start, end)
+func ParseTimeRange(raw string) (start, end time.Time, err error) {
+	if t, err := time.Parse(time.RFC3339Nano, raw); err == nil && strings.Contains(raw, "T") {
+		// Время в PostgreSQL хранится с точностью до микросекунды
+		t = t.Truncate(time.Microsecond)
+		return t, t.Add(time.Microsecond), nil
+	}
+	date, err := models.ParseReleaseDate(raw)
+	if err != nil || date.IsZero() {
+		return time.Time{}, time.Time{}, fmt.Errorf("expected a date or RFC 3339 time, got %q", raw)
+	}
+	return date.Start(), date.End(), nil
+}
+
+// sqlValue значение границы периода для SQL. Дата релиза передается строкой,
+// чтобы сравнение с колонкой типа date не зависело от часового пояса сессии.
+func (c *comparison) sqlValue(t time.Time) any {
+	if c.field.kind == kindDate {
+		return t.Format(time.DateOnly)
+	}
+	return t
+}
+
+func (c *comparison) SQL() (string, []any) {
+	col := c.field.column
+	if c.isNull {
+		empty := col + " IS NULL"
+		if c.field.kind == kindString {
+			empty = "(" + col + " IS NULL OR " + col + " = '')"
+		}
+		if c.op == "!=" {
+			return "NOT " + empty, nil
+		}
+		return empty, nil
+	}
+
+	// Проверка IS NOT NULL делает результат сравнения всегда истинным или ложным
+	notNull := "(" + col + " IS NOT NULL AND "
+	switch c.field.kind {
+	case kindInt, kindString:
+		switch c.op {
+		case "~", "!~":
+			like := "ILIKE"
+			if c.op == "!~" {
+				like = "NOT ILIKE"
+			}
+			return notNull + col + " " + like + " ?)", []any{"%" + escapeLike(c.value.(string)) + "%"}
+		case "!=":
+			return notNull + col + " <> ?)", []any{c.value}
+		default:
+			return notNull + col + " " + c.op + " ?)", []any{c.value}
+		}
+	default:
+		start, end := c.sqlValue(c.start), c.sqlValue(c.end)
+		switch c.op {
+		case "=":
+			return notNull + col + " >= ? AND " + col + " < ?)", []any{start, end}
+		case "!=":
+			return notNull + "(" + col + " < ? OR " + col + " >= ?))", []any{start, end}
+		case ">":
+			return notNull + col + " >= ?)", []any{end}
+		case ">=":
+			return notNull + col + " >= ?)", []any{start}
+		case "<":
+			return notNull + col + " < ?)", []any{start}
+		default: // <=
+			return notNull + col + " < ?)", []any{end}
+		}
+	}
+}
+
+func (c *comparison) Match(s *models.Song) bool {
+	v := c.field.get(s)
+	if c.isNull {
+		empty := v == nil || v == ""
+		return empty == (c.op == "=")
+	}
+	if v == nil {
+		return false
+	}
+
+	switch c.field.kind {
+	case kindInt:
+		return compareOrdered(v.(int64), c.value.(int64), c.op)
+	case kindString:
+		str, want := v.(string), c.value.(string)
+		switch c.op {
+		case "~":
+			return strings.Contains(strings.ToLower(str), strings.ToLower(want))
+		case "!~":
+			return !strings.Contains(strings.ToLower(str), strings.ToLower(want))
+		case "=":
+			return str == want
+		default:
+			return str != want
+		}
+	default:
+		t := v.(time.Time)
+		switch c.op {
+		case "=":
+			return !t.Before(c.start) && t.Before(c.end)
+		case "!=":
+			return t.Before(c.start) || !t.Before(c.end)
+		case ">":
+			return !t.Before(c.end)
+		case ">=":
+			return !t.Before(c.start)
+		case "<":
+			return t.Before(c.start)
+		default:
+			return t.Before(c.end)
+		}
+	}
+}
+
+func compareOrdered(a, b int64, op string) bool {
+	switch op {
+	case "=":
+		return a == b
+	case "!=":
+		return a != b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	case "<":
+		return a < b
+	default:
+		return a <= b
+	}
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,203 @@
+package filterexpr
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/w212w/GoProjectEM/internal/models"
+)
+
+func testSong() *models.Song {
+	return &models.Song{
+		ID:          7,
+		Version:     3,
+		Group:       "Muse",
+		Title:       "Supermassive Black Hole",
+		Artist:      "Matthew Bellamy",
+		ReleaseDate: models.NewReleaseDate(time.Date(2006, 7, 16, 0, 0, 0, 0, time.UTC), models.PrecisionDay),
+		Status:      models.SongStatusReady,
+		Language:    models.LanguageEnglish,
+		CreatedAt:   time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		UpdatedAt:   time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"id=7", true},
+		{"id!=7", false},
+		{"version>2 and version<=3", true},
+		{"version>=4", false},
+		{"group=Muse", true},
+		{"group=muse", false},
+		{"group~muse", true},
+		{"title!~hole", false},
+		{`title="Supermassive Black Hole"`, true},
+		{`title~"black hole"`, true},
+		{"link=null", true},
+		{"link!=null", false},
+		{"artist!=null", true},
+		{"release_date=2006", true},
+		{"release_date=2006-07", true},
+		{"release_date=16.07.2006", true},
+		{"release_date=2006-07-17", false},
+		{"release_date>2005", true},
+		{"release_date>2006", false},
+		{"release_date>=2006", true},
+		{"release_date<2006", false},
+		{"release_date<=2006", true},
+		{"release_date!=2006", false},
+		{"created_at>=2024-03-01T12:00:00Z", true},
+		{"created_at<2024-03-01T12:00:00Z", false},
+		{"updated_at=2024-03", true},
+		{"group=Queen or title~hole", true},
+		{"group=Queen and title~hole", false},
+		{"not group=Queen", true},
+		{"not (group=Muse and version=3)", false},
+		{"group=Queen or group=Muse and version=1", false},
+		{"(group=Queen or group=Muse) and version=3", true},
+		{"GROUP=Muse AND Status=ready", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			expr, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if got := expr.Match(testSong()); got != tt.want {
+				t.Errorf("Match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchEmptyReleaseDate(t *testing.T) {
+	song := testSong()
+	song.ReleaseDate = models.ReleaseDate{}
+
+	for expr, want := range map[string]bool{
+		"release_date=null":  true,
+		"release_date>2000":  false,
+		"release_date<2000":  false,
+		"release_date!=2006": false,
+	} {
+		e, err := Parse(expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", expr, err)
+		}
+		if got := e.Match(song); got != want {
+			t.Errorf("%s: Match = %v, want %v", expr, got, want)
+		}
+	}
+}
+
+func TestSQL(t *testing.T) {
+	tests := []struct {
+		expr string
+		sql  string
+		args []any
+	}{
+		{"id=7", `(songs."id" IS NOT NULL AND songs."id" = ?)`, []any{int64(7)}},
+		{"version!=2", `(songs."version" IS NOT NULL AND songs."version" <> ?)`, []any{int64(2)}},
+		{`group~"mu_se%"`, `(songs."group" IS NOT NULL AND songs."group" ILIKE ?)`, []any{`%mu\_se\%%`}},
+		{"title!~hole", `(songs."title" IS NOT NULL AND songs."title" NOT ILIKE ?)`, []any{"%hole%"}},
+		{"link=null", `(songs."link" IS NULL OR songs."link" = '')`, nil},
+		{"release_date!=null", `NOT songs."release_date" IS NULL`, nil},
+		{"release_date=2006", `(songs."release_date" IS NOT NULL AND songs."release_date" >= ? AND songs."release_date" < ?)`, []any{"2006-01-01", "2007-01-01"}},
+		{"release_date>2006-07", `(songs."release_date" IS NOT NULL AND songs."release_date" >= ?)`, []any{"2006-08-01"}},
+		{"release_date<=2006-07-16", `(songs."release_date" IS NOT NULL AND songs."release_date" < ?)`, []any{"2006-07-17"}},
+		{
+			"group=Muse and not (id=1 or id=2)",
+			`((songs."group" IS NOT NULL AND songs."group" = ?) AND NOT ((songs."id" IS NOT NULL AND songs."id" = ?) OR (songs."id" IS NOT NULL AND songs."id" = ?)))`,
+			[]any{"Muse", int64(1), int64(2)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			expr, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			sql, args := expr.SQL()
+			if sql != tt.sql {
+				t.Errorf("SQL = %s\nwant  %s", sql, tt.sql)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %#v, want %#v", args, tt.args)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		pos  int
+	}{
+		{"", 0},
+		{"album=1", 0},
+		{"title", 5},
+		{"title=", 6},
+		{"id=abc", 3},
+		{"title>abc", 6},
+		{"release_date~2006", 13},
+		{"release_date=someday", 13},
+		{"id=null", 3},
+		{"link>null", 5},
+		{`title="unterminated`, 6},
+		{"(id=1", 5},
+		{"id=1)", 4},
+		{"id=1 title=2", 5},
+		{"id=1 and", 8},
+		{"id#1", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Parse(tt.expr)
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("err = %v, want *SyntaxError", err)
+			}
+			if syntaxErr.Pos != tt.pos {
+				t.Errorf("Pos = %d, want %d (%v)", syntaxErr.Pos, tt.pos, err)
+			}
+		})
+	}
+}
+
+func TestParseUnicode(t *testing.T) {
+	song := testSong()
+	song.Group = "Кино"
+
+	expr, err := Parse(`group=Кино and title!~Группа`)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !expr.Match(song) {
+		t.Error("Match = false, want true")
+	}
+
+	_, err = Parse("group=Кино «")
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("err = %v, want *SyntaxError", err)
+	}
+	if syntaxErr.Pos != len("group=Кино ") || !strings.Contains(syntaxErr.Msg, "«") {
+		t.Errorf("err = %v, want unexpected «", err)
+	}
+}
+
+func TestParseLimits(t *testing.T) {
+	if _, err := Parse(strings.Repeat(" ", MaxLength+1)); err == nil {
+		t.Error("expression longer than MaxLength parsed")
+	}
+	if _, err := Parse(strings.Repeat("not ", maxDepth+1) + "id=1"); err == nil {
+		t.Error("expression nested deeper than maxDepth parsed")
+	}
+}
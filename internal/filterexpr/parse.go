@@ -0,0 +1,263 @@
+package filterexpr
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// MaxLength максимальная длина выражения в символах
+	MaxLength = 1000
+	// maxDepth максимальная вложенность скобок и отрицаний
+	maxDepth = 32
+)
+
+// SyntaxError ошибка разбора выражения
+type SyntaxError struct {
+	// Pos позиция в выражении (в байтах), с которой начинается ошибка
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("filter: %s at position %d", e.Msg, e.Pos)
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokString
+	tokOp
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// operators операторы сравнения, двухсимвольные проверяются первыми
+var operators = []string{">=", "<=", "!=", "!~", "=", ">", "<", "~"}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_.:+-", r)
+}
+
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			i++
+		case r == '(':
+			tokens = append(tokens, token{tokLParen, "(", i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokRParen, ")", i})
+			i++
+		case r == '"':
+			text, n, err := readString(s[i:])
+			if err != nil {
+				return nil, &SyntaxError{Pos: i, Msg: err.Error()}
+			}
+			tokens = append(tokens, token{tokString, text, i})
+			i += n
+		default:
+			if op := matchOperator(s[i:]); op != "" {
+				tokens = append(tokens, token{tokOp, op, i})
+				i += len(op)
+				continue
+			}
+			end := strings.IndexFunc(s[i:], func(r rune) bool { return !isWordRune(r) })
+			if end == 0 {
+				return nil, &SyntaxError{Pos: i, Msg: fmt.Sprintf("unexpected character %q", s[i:i+size])}
+			}
+			if end < 0 {
+				end = len(s) - i
+			}
+			tokens = append(tokens, token{tokWord, s[i : i+end], i})
+			i += end
+		}
+	}
+	return append(tokens, token{tokEOF, "", len(s)}), nil
+}
+
+func matchOperator(s string) string {
+	for _, op := range operators {
+		if strings.HasPrefix(s, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// readString читает строку в двойных кавычках, \" и \\ экранируют символы.
+// Возвращает значение и длину строки вместе с кавычками.
+func readString(s string) (string, int, error) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 == len(s) {
+				return "", 0, fmt.Errorf("unterminated string")
+			}
+			i++
+			b.WriteByte(s[i])
+		case '"':
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	depth  int
+}
+
+// Parse разбирает выражение фильтра:
+//
+//	expr       = term { "or" term }
+//	term       = factor { "and" factor }
+//	factor     = "not" factor | "(" expr ")" | comparison
+//	comparison = field op value
+//	op         = "=" | "!=" | ">" | ">=" | "<" | "<=" | "~" | "!~"
+//	value      = слово | строка в двойных кавычках | null
+//
+// Ключевые слова and, or, not и null не зависят от регистра.
+// Пример: release_date>=2000 and (group~"muse" or not link=null).
+func Parse(s string) (Expr, error) {
+	if len(s) > MaxLength {
+		return nil, &SyntaxError{Pos: MaxLength, Msg: fmt.Sprintf("expression is longer than %d characters", MaxLength)}
+	}
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, &SyntaxError{Pos: tok.pos, Msg: fmt.Sprintf("unexpected %q", tok.text)}
+	}
+	return expr, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// keyword сообщает, является ли следующий токен ключевым словом kw, и пропускает его
+func (p *parser) keyword(kw string) bool {
+	tok := p.peek()
+	if tok.kind == tokWord && strings.EqualFold(tok.text, kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logical{op: "OR", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		left = &logical{op: "AND", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseFactor() (Expr, error) {
+	if p.depth++; p.depth > maxDepth {
+		return nil, &SyntaxError{Pos: p.peek().pos, Msg: "expression is nested too deeply"}
+	}
+	defer func() { p.depth-- }()
+
+	if p.keyword("not") {
+		inner, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		return &not{inner: inner}, nil
+	}
+	if p.peek().kind == tokLParen {
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if tok := p.next(); tok.kind != tokRParen {
+			return nil, &SyntaxError{Pos: tok.pos, Msg: "expected \")\""}
+		}
+		return inner, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (Expr, error) {
+	name := p.next()
+	if name.kind != tokWord {
+		return nil, &SyntaxError{Pos: name.pos, Msg: "expected field name"}
+	}
+	f, ok := fields[strings.ToLower(name.text)]
+	if !ok {
+		return nil, &SyntaxError{Pos: name.pos, Msg: fmt.Sprintf("unknown field %q", name.text)}
+	}
+
+	op := p.next()
+	if op.kind != tokOp {
+		return nil, &SyntaxError{Pos: op.pos, Msg: "expected comparison operator"}
+	}
+
+	value := p.next()
+	if value.kind != tokWord && value.kind != tokString {
+		return nil, &SyntaxError{Pos: value.pos, Msg: "expected value"}
+	}
+	isNull := value.kind == tokWord && strings.EqualFold(value.text, "null")
+
+	c, err := newComparison(f, op.text, value.text, isNull)
+	if err != nil {
+		return nil, &SyntaxError{Pos: value.pos, Msg: err.Error()}
+	}
+	return c, nil
+}
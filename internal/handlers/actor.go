@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/w212w/GoProjectEM/internal/logger"
+	"github.com/w212w/GoProjectEM/internal/models"
+	"github.com/w212w/GoProjectEM/internal/repository"
+)
+
+const actorHeader = "X-User"
+
+// ActorMiddleware берет автора изменений из заголовка X-User и передает его
+// в контекст, откуда он попадает в ревизии песен.
+func ActorMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor := strings.TrimSpace(r.Header.Get(actorHeader))
+		if len(actor) > 255 {
+			actor = actor[:255]
+		}
+		ctx := repository.WithChangeInfo(r.Context(), repository.ChangeInfo{Actor: actor})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// requireActor возвращает текущего пользователя. Анонимный запрос не может
+// ничем владеть: он получает 401, а requireActor возвращает false.
+func requireActor(w http.ResponseWriter, r *http.Request, handler string) (string, bool) {
+	actor := repository.ChangeInfoFromContext(r.Context()).Actor
+	if actor == models.AnonymousUser {
+		logger.Log.Errorf("%s: %s header is missing", handler, actorHeader)
+		writeError(w, r, http.StatusUnauthorized, models.ErrCodeUnauthorized, actorHeader+" header is required")
+		return "", false
+	}
+	return actor, true
+}
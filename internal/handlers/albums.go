@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/w212w/GoProjectEM/internal/logger"
+	"github.com/w212w/GoProjectEM/internal/models"
+	"github.com/w212w/GoProjectEM/internal/repository"
+	"github.com/w212w/GoProjectEM/internal/validation"
+)
+
+func parseAlbumID(r *http.Request) (uint, error) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid album id %q", mux.Vars(r)["id"])
+	}
+	return uint(id), nil
+}
+
+// checkTrackIDs проверяет, что в списке треков нет повторов
+func checkTrackIDs(ids []uint) []models.FieldError {
+	seen := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			return []models.FieldError{{Field: "song_ids", Code: "duplicate", Message: fmt.Sprintf("song %d is listed more than once", id)}}
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+var unknownTracksError = models.FieldError{Field: "song_ids", Code: "not_found", Message: "some songs do not exist or are deleted"}
+
+func writeAlbum(w http.ResponseWriter, status int, album *models.Album) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(album); err != nil {
+		logger.Log.Errorf("Failed to encode album: %v", err)
+	}
+}
+
+// ListAlbumsHandler godoc
+// @Summary Получить список альбомов
+// @Description Получить альбомы без треков с пагинацией
+// @Tags albums
+// @Produce json
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество результатов на странице" default(10)
+// @Success 200 {array} models.Album "Список альбомов"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Router /api/albums [get]
+func ListAlbumsHandler(albums repository.AlbumRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Log.Debug("ListAlbumsHandler: Start processing request")
+
+		offset, limit := parsePage(r, 10)
+		list, err := albums.ListAlbums(r.Context(), offset, limit)
+		if err != nil {
+			logger.Log.Errorf("ListAlbumsHandler: Failed to retrieve albums: %v", err)
+			writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to retrieve albums")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(list); err != nil {
+			logger.Log.Error("ListAlbumsHandler: Failed to encode response")
+			writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to encode response")
+			return
+		}
+
+		logger.Log.Info("ListAlbumsHandler: Successfully responded with albums")
+	}
+}
+
+// GetAlbumHandler godoc
+// @Summary Получить альбом
+// @Description Получить альбом по его ID вместе с треками по порядку
+// @Tags albums
+// @Produce json
+// @Param id path string true "ID альбома"
+// @Success 200 {object} models.Album "Альбом"
+// @Failure 400 {object} models.ErrorResponse "Неверный ID альбома"
+// @Failure 404 {object} models.ErrorResponse "Альбом не найден"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Router /api/albums/{id} [get]
+func GetAlbumHandler(albums repository.AlbumRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Log.Debug("GetAlbumHandler: Start processing request")
+
+		id, err := parseAlbumID(r)
+		if err != nil {
+			logger.Log.Error("GetAlbumHandler: Invalid album ID")
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidID, "Invalid album ID")
+			return
+		}
+
+		album, err := albums.GetAlbum(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				logger.Log.Error("GetAlbumHandler: Album not found")
+				writeError(w, r, http.StatusNotFound, models.ErrCodeAlbumNotFound, "Album not found")
+			} else {
+				logger.Log.Errorf("GetAlbumHandler: Failed to retrieve album: %v", err)
+				writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to retrieve album")
+			}
+			return
+		}
+
+		writeAlbum(w, http.StatusOK, album)
+		logger.Log.Info("GetAlbumHandler: Successfully responded with album")
+	}
+}
+
+// CreateAlbumHandler godoc
+// @Summary Добавить альбом
+// @Description Добавить альбом и, при необходимости, его треки в порядке song_ids. Группа создается, если ее еще нет
+// @Tags albums
+// @Accept json
+// @Produce json
+// @Param album body models.AlbumRequest true "Данные альбома"
+// @Success 201 {object} models.Album "Созданный альбом"
+// @Failure 400 {object} models.ErrorResponse "Неверный формат JSON"
+// @Failure 422 {object} models.ErrorResponse "Ошибки валидации полей"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Router /api/albums [post]
+func CreateAlbumHandler(albums repository.AlbumRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Log.Debug("CreateAlbumHandler: Start processing request")
+
+		var input models.AlbumRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			logger.Log.Error("CreateAlbumHandler: Invalid JSON format")
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidJSON, "Invalid JSON format")
+			return
+		}
+		fieldErrors := append(validation.Validate(&input), checkTrackIDs(input.SongIDs)...)
+		if len(fieldErrors) > 0 {
+			logger.Log.Errorf("CreateAlbumHandler: Validation failed: %v", fieldErrors)
+			writeValidationError(w, r, fieldErrors)
+			return
+		}
+
+		album := models.Album{
+			Title:     input.Title,
+			Group:     input.Group,
+			CoverLink: input.CoverLink,
+		}
+		album.ReleaseDate, _ = models.ParseReleaseDate(input.ReleaseDate)
+
+		if err := albums.CreateAlbum(r.Context(), &album, input.SongIDs); err != nil {
+			if errors.Is(err, repository.ErrInvalidReference) {
+				logger.Log.Error("CreateAlbumHandler: Unknown songs in track list")
+				writeValidationError(w, r, []models.FieldError{unknownTracksError})
+			} else {
+				logger.Log.Errorf("CreateAlbumHandler: Failed to create album: %v", err)
+				writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to create album")
+			}
+			return
+		}
+
+		created, err := albums.GetAlbum(r.Context(), album.ID)
+		if err != nil {
+			logger.Log.Errorf("CreateAlbumHandler: Failed to retrieve created album: %v", err)
+			created = &album
+		}
+
+		w.Header().Set("Location", fmt.Sprintf("/api/albums/%d", album.ID))
+		writeAlbum(w, http.StatusCreated, created)
+		logger.Log.Infof("CreateAlbumHandler: Album %d created", album.ID)
+	}
+}
+
+// SetAlbumTracksHandler godoc
+// @Summary Изменить порядок треков альбома
+// @Description Задать полный список треков альбома в нужном порядке. Песни, которых нет в списке, исключаются из альбома
+// @Tags albums
+// @Accept json
+// @Produce json
+// @Param id path string true "ID альбома"
+// @Param tracks body models.AlbumTracksRequest true "ID песен по порядку"
+// @Success 200 {object} models.Album "Альбом с новым порядком треков"
+// @Failure 400 {object} models.ErrorResponse "Неверный формат JSON"
+// @Failure 404 {object} models.ErrorResponse "Альбом не найден"
+// @Failure 422 {object} models.ErrorResponse "Ошибки валидации полей"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Router /api/albums/{id}/tracks [put]
+func SetAlbumTracksHandler(albums repository.AlbumRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Log.Debug("SetAlbumTracksHandler: Start processing request")
+
+		id, err := parseAlbumID(r)
+		if err != nil {
+			logger.Log.Error("SetAlbumTracksHandler: Invalid album ID")
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidID, "Invalid album ID")
+			return
+		}
+
+		var input models.AlbumTracksRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			logger.Log.Error("SetAlbumTracksHandler: Invalid JSON format")
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidJSON, "Invalid JSON format")
+			return
+		}
+		if fieldErrors := checkTrackIDs(input.SongIDs); len(fieldErrors) > 0 {
+			logger.Log.Errorf("SetAlbumTracksHandler: Validation failed: %v", fieldErrors)
+			writeValidationError(w, r, fieldErrors)
+			return
+		}
+
+		if err := albums.SetTracks(r.Context(), id, input.SongIDs); err != nil {
+			switch {
+			case errors.Is(err, repository.ErrNotFound):
+				logger.Log.Error("SetAlbumTracksHandler: Album not found")
+				writeError(w, r, http.StatusNotFound, models.ErrCodeAlbumNotFound, "Album not found")
+			case errors.Is(err, repository.ErrInvalidReference):
+				logger.Log.Error("SetAlbumTracksHandler: Unknown songs in track list")
+				writeValidationError(w, r, []models.FieldError{unknownTracksError})
+			default:
+				logger.Log.Errorf("SetAlbumTracksHandler: Failed to set tracks: %v", err)
+				writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to set tracks")
+			}
+			return
+		}
+
+		album, err := albums.GetAlbum(r.Context(), id)
+		if err != nil {
+			logger.Log.Errorf("SetAlbumTracksHandler: Failed to retrieve album: %v", err)
+			writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to retrieve album")
+			return
+		}
+
+		writeAlbum(w, http.StatusOK, album)
+		logger.Log.Infof("SetAlbumTracksHandler: Album %d now has %d tracks", id, len(input.SongIDs))
+	}
+}
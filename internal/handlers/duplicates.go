@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/w212w/GoProjectEM/internal/logger"
+	"github.com/w212w/GoProjectEM/internal/models"
+	"github.com/w212w/GoProjectEM/internal/repository"
+)
+
+// Значения параметра on_conflict при добавлении песни
+const (
+	onConflictError  = "error"
+	onConflictUpdate = "update"
+)
+
+// writeSongExists отвечает 409 со ссылкой на существующую песню в заголовке Location
+func writeSongExists(w http.ResponseWriter, r *http.Request, id uint) {
+	location := fmt.Sprintf("/api/songs/%d", id)
+	w.Header().Set("Location", location)
+	writeError(w, r, http.StatusConflict, models.ErrCodeSongExists,
+		"Song with the same group and title already exists: "+location+", merge duplicates via POST /api/admin/duplicates/merge")
+}
+
+// writeDuplicate отвечает 409 на repository.ErrDuplicate, по возможности со ссылкой на существующую песню
+func writeDuplicate(w http.ResponseWriter, r *http.Request, repo repository.SongRepository, group, title string) {
+	existing, err := repo.FindByNaturalKey(r.Context(), group, title)
+	if err != nil {
+		writeError(w, r, http.StatusConflict, models.ErrCodeSongExists, "Song with the same group and title already exists")
+		return
+	}
+	writeSongExists(w, r, existing.ID)
+}
+
+// ListDuplicatesHandler godoc
+// @Summary Получить похожие песни
+// @Description Получить группы песен, у которых группа и название совпадают без учета регистра, ё/е, пробелов и знаков препинания
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.DuplicateGroup "Группы похожих песен"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Router /api/admin/duplicates [get]
+func ListDuplicatesHandler(duplicates repository.DuplicateRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Log.Debug("ListDuplicatesHandler: Start processing request")
+
+		groups, err := duplicates.ListDuplicates(r.Context())
+		if err != nil {
+			logger.Log.Errorf("ListDuplicatesHandler: Failed to find duplicates: %v", err)
+			writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to find duplicates")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(groups); err != nil {
+			logger.Log.Error("ListDuplicatesHandler: Failed to encode response")
+			writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to encode response")
+			return
+		}
+
+		logger.Log.Infof("ListDuplicatesHandler: Found %d groups of duplicates", len(groups))
+	}
+}
+
+// MergeDuplicatesHandler godoc
+// @Summary Объединить песни
+// @Description Дополнить песню target_id незаполненными полями песен source_ids и переместить их в корзину
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param merge body models.MergeSongsRequest true "Песни для объединения"
+// @Success 200 {object} models.Song "Объединенная песня"
+// @Failure 400 {object} models.ErrorResponse "Неверный формат JSON"
+// @Failure 404 {object} models.ErrorResponse "Песня не найдена"
+// @Failure 409 {object} models.ErrorResponse "Песня с такой группой и названием уже существует"
+// @Failure 412 {object} models.ErrorResponse "Песня была изменена"
+// @Failure 422 {object} models.ErrorResponse "Ошибки валидации полей"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Router /api/admin/duplicates/merge [post]
+func MergeDuplicatesHandler(duplicates repository.DuplicateRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Log.Debug("MergeDuplicatesHandler: Start processing request")
+
+		var input models.MergeSongsRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			logger.Log.Error("MergeDuplicatesHandler: Invalid JSON format")
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidJSON, "Invalid JSON format")
+			return
+		}
+
+		slices.Sort(input.SourceIDs)
+		input.SourceIDs = slices.Compact(input.SourceIDs)
+
+		var fieldErrors []models.FieldError
+		if input.TargetID == 0 {
+			fieldErrors = append(fieldErrors, models.FieldError{Field: "target_id", Code: "required", Message: "field is required"})
+		}
+		if len(input.SourceIDs) == 0 {
+			fieldErrors = append(fieldErrors, models.FieldError{Field: "source_ids", Code: "required", Message: "field is required"})
+		} else if slices.Contains(input.SourceIDs, input.TargetID) {
+			fieldErrors = append(fieldErrors, models.FieldError{Field: "source_ids", Code: "invalid", Message: "must not contain target_id"})
+		}
+		if len(fieldErrors) > 0 {
+			logger.Log.Errorf("MergeDuplicatesHandler: Validation failed: %v", fieldErrors)
+			writeValidationError(w, r, fieldErrors)
+			return
+		}
+
+		sources := make([]string, len(input.SourceIDs))
+		for i, id := range input.SourceIDs {
+			sources[i] = fmt.Sprint(id)
+		}
+		info := repository.ChangeInfoFromContext(r.Context())
+		info.Note = "merged songs " + strings.Join(sources, ", ")
+		ctx := repository.WithChangeInfo(r.Context(), info)
+
+		song, err := duplicates.Merge(ctx, input.TargetID, input.SourceIDs)
+		if err != nil {
+			switch {
+			case errors.Is(err, repository.ErrNotFound):
+				logger.Log.Error("MergeDuplicatesHandler: Song not found")
+				writeError(w, r, http.StatusNotFound, models.ErrCodeSongNotFound, "Song not found")
+			case errors.Is(err, repository.ErrConflict):
+				logger.Log.Error("MergeDuplicatesHandler: Song has been modified concurrently")
+				writeError(w, r, http.StatusPreconditionFailed, models.ErrCodePreconditionFailed, "Song has been modified")
+			case errors.Is(err, repository.ErrDuplicate):
+				logger.Log.Error("MergeDuplicatesHandler: Song with the same group and title already exists")
+				writeError(w, r, http.StatusConflict, models.ErrCodeSongExists, "Song with the same group and title already exists")
+			default:
+				logger.Log.Errorf("MergeDuplicatesHandler: Failed to merge songs: %v", err)
+				writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to merge songs")
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", jsonSongETag(song))
+		if err := json.NewEncoder(w).Encode(song); err != nil {
+			logger.Log.Error("MergeDuplicatesHandler: Failed to encode response")
+			writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to encode response")
+			return
+		}
+
+		logger.Log.Infof("MergeDuplicatesHandler: Songs %v merged into %d", input.SourceIDs, input.TargetID)
+	}
+}
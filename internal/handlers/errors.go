@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/w212w/GoProjectEM/internal/logger"
+	"github.com/w212w/GoProjectEM/internal/models"
+)
+
+const problemContentType = "application/problem+json"
+
+// writeError отвечает ошибкой в формате RFC 7807 с машиночитаемым кодом,
+// ID запроса и, при наличии, ошибками в отдельных полях.
+func writeError(w http.ResponseWriter, r *http.Request, status int, code, detail string, fieldErrors ...models.FieldError) {
+	problem := models.ErrorResponse{
+		Type:      "urn:problem-type:" + code,
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		Code:      code,
+		RequestID: RequestIDFromContext(r.Context()),
+		Errors:    fieldErrors,
+	}
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(problem); err != nil {
+		logger.Log.Errorf("Failed to encode error response: %v", err)
+	}
+}
+
+// writeValidationError отвечает 422 со всеми нарушениями в полях запроса
+func writeValidationError(w http.ResponseWriter, r *http.Request, fieldErrors []models.FieldError) {
+	writeError(w, r, http.StatusUnprocessableEntity, models.ErrCodeValidationFailed,
+		"Request validation failed", fieldErrors...)
+}
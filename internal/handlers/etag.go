@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/w212w/GoProjectEM/internal/models"
+)
+
+// songETag строгий ETag песни, построенный по ее версии
+func songETag(song *models.Song) string {
+	return `"` + strconv.Itoa(song.Version) + `"`
+}
+
+// representationETag добавляет к ETag песни формат ответа: представления одной
+// версии в разных форматах не должны подменять друг друга в кеше
+func representationETag(etag string, enc responseEncoder) string {
+	return strings.TrimSuffix(etag, `"`) + "-" + enc.name + `"`
+}
+
+// jsonSongETag ETag песни для ответов, которые всегда передают ее в JSON
+func jsonSongETag(song *models.Song) string {
+	return representationETag(songETag(song), responseEncoders[0])
+}
+
+// setChangedSongETag задает ETag ответа на изменение песни, в котором нет самой песни:
+// это ETag представления, которое тот же клиент получит в GET со своим Accept,
+// или JSON, если ни один формат не подходит
+func setChangedSongETag(w http.ResponseWriter, r *http.Request, song *models.Song) {
+	enc, ok := preferredEncoder(r, supportedEncoders(song))
+	if !ok {
+		enc = responseEncoders[0]
+	}
+	w.Header().Add("Vary", "Accept")
+	w.Header().Set("ETag", representationETag(songETag(song), enc))
+}
+
+// etagVersion возвращает версию песни, из которой построен ETag
+func etagVersion(etag string) string {
+	version, _, _ := strings.Cut(strings.Trim(etag, `"`), "-")
+	return version
+}
+
+// songTextETag ETag страницы текста песни: разные страницы и выборки секций
+// одной версии песни - разные представления
+func songTextETag(song *models.Song, page, limit int, section string, collapse bool) string {
+	if section == "" {
+		section = "all"
+	}
+	return fmt.Sprintf(`"%d-text-%d-%d-%s-%t"`, song.Version, page, limit, section, collapse)
+}
+
+// ifMatch проверяет заголовок If-Match (строгое сравнение, RFC 9110). Сравниваются
+// только версии песни, поэтому подходит ETag любого представления этой версии.
+// Отсутствующий заголовок считается выполненным условием.
+func ifMatch(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return true
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if !strings.HasPrefix(candidate, "W/") && etagVersion(candidate) == etagVersion(etag) {
+			return true
+		}
+	}
+	return false
+}
+
+// ifNoneMatch сообщает, что клиент уже имеет актуальную версию
+// (слабое сравнение, RFC 9110) и можно ответить 304.
+func ifNoneMatch(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// writeNotModified отвечает 304 с текущим ETag
+func writeNotModified(w http.ResponseWriter, etag string) {
+	w.Header().Set("ETag", etag)
+	w.WriteHeader(http.StatusNotModified)
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/w212w/GoProjectEM/internal/logger"
+	"github.com/w212w/GoProjectEM/internal/models"
+	"github.com/w212w/GoProjectEM/internal/repository"
+)
+
+// songStreamWriter пишет песни выгрузки по одной. Close завершает документ
+// и должен вызываться и для пустой выгрузки.
+type songStreamWriter interface {
+	WriteSong(song models.SongView) error
+	Close() error
+}
+
+// exportFormat формат выгрузки каталога
+type exportFormat struct {
+	contentType string
+	extension   string
+	newWriter   func(w io.Writer, fields []string) songStreamWriter
+}
+
+var exportFormats = map[string]exportFormat{
+	"ndjson": {"application/x-ndjson", "ndjson", newNDJSONSongWriter},
+	"json":   {"application/json", "json", newJSONArraySongWriter},
+	"csv":    {"text/csv; charset=utf-8", "csv", newCSVSongWriter},
+}
+
+// ndjsonSongWriter по объекту песни в строке
+type ndjsonSongWriter struct {
+	encoder *json.Encoder
+}
+
+func newNDJSONSongWriter(w io.Writer, fields []string) songStreamWriter {
+	return &ndjsonSongWriter{encoder: json.NewEncoder(w)}
+}
+
+func (sw *ndjsonSongWriter) WriteSong(song models.SongView) error { return sw.encoder.Encode(song) }
+func (sw *ndjsonSongWriter) Close() error                         { return nil }
+
+// jsonArraySongWriter JSON-массив песен, который пишется по одному элементу
+type jsonArraySongWriter struct {
+	w     io.Writer
+	count int
+}
+
+func newJSONArraySongWriter(w io.Writer, fields []string) songStreamWriter {
+	return &jsonArraySongWriter{w: w}
+}
+
+func (sw *jsonArraySongWriter) WriteSong(song models.SongView) error {
+	data, err := json.Marshal(song)
+	if err != nil {
+		return err
+	}
+	sep := ",\n"
+	if sw.count == 0 {
+		sep = "[\n"
+	}
+	sw.count++
+	if _, err := io.WriteString(sw.w, sep); err != nil {
+		return err
+	}
+	_, err = sw.w.Write(data)
+	return err
+}
+
+func (sw *jsonArraySongWriter) Close() error {
+	end := "\n]\n"
+	if sw.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(sw.w, end)
+	return err
+}
+
+// csvSongWriter CSV с заголовком из имен полей, значения как в models.SongView.Strings
+type csvSongWriter struct {
+	writer *csv.Writer
+	fields []string
+	header bool
+}
+
+func newCSVSongWriter(w io.Writer, fields []string) songStreamWriter {
+	return &csvSongWriter{writer: csv.NewWriter(w), fields: fields}
+}
+
+func (sw *csvSongWriter) writeHeader() error {
+	if sw.header {
+		return nil
+	}
+	sw.header = true
+	return sw.writer.Write(sw.fields)
+}
+
+func (sw *csvSongWriter) WriteSong(song models.SongView) error {
+	if err := sw.writeHeader(); err != nil {
+		return err
+	}
+	return sw.writer.Write(song.Strings())
+}
+
+func (sw *csvSongWriter) Close() error {
+	if err := sw.writeHeader(); err != nil {
+		return err
+	}
+	sw.writer.Flush()
+	return sw.writer.Error()
+}
+
+// trackingWriter запоминает, начата ли запись ответа
+type trackingWriter struct {
+	w       io.Writer
+	written bool
+}
+
+func (t *trackingWriter) Write(p []byte) (int, error) {
+	t.written = true
+	return t.w.Write(p)
+}
+
+// ExportSongsHandler godoc
+// @Summary Выгрузить каталог песен
+// @Description Потоково выгружает все песни, подходящие под фильтры списка песен, в NDJSON, CSV или JSON.
+// @Description Песни читаются из базы курсором, поэтому размер выгрузки не ограничен памятью сервера.
+// @Description По умолчанию выгружаются все поля, включая текст; в CSV теги и жанры разделены ";"
+// @Tags songs
+// @Produce application/x-ndjson
+// @Produce text/csv
+// @Produce json
+// @Param format query string false "Формат выгрузки" Enums(ndjson, csv, json) default(ndjson)
+// @Param artist query string false "Фильтр по артисту"
+// @Param title query string false "Фильтр по названию"
+// @Param q query string false "Полнотекстовый поиск по названию, группе и тексту"
+// @Param group query string false "Фильтр по группе"
+// @Param has_link query bool false "Только песни со ссылкой (true) или без нее (false)"
+// @Param released_from query string false "Дата релиза не раньше (2006, 2006-07, 16.07.2006)"
+// @Param released_to query string false "Дата релиза не позже (2006, 2006-07, 16.07.2006)"
+// @Param created_from query string false "Создана не раньше (дата или RFC 3339)"
+// @Param created_to query string false "Создана не позже (дата или RFC 3339)"
+// @Param updated_from query string false "Изменена не раньше (дата или RFC 3339)"
+// @Param updated_to query string false "Изменена не позже (дата или RFC 3339)"
+// @Param filter query string false "Выражение фильтра, как в GET /api/songs"
+// @Param album query int false "ID альбома: только его треки"
+// @Param tag query []string false "Тег, можно повторять: песни со всеми тегами" collectionFormat(multi)
+// @Param genre query []string false "Жанр из словаря, можно повторять: песни со всеми жанрами" collectionFormat(multi)
+// @Param sort query string false "Сортировка, как в GET /api/songs"
+// @Param fields query string false "Поля песен через запятую, по умолчанию все"
+// @Success 200 {array} models.Song "Песни"
+// @Failure 400 {object} models.ErrorResponse "Неверные параметры"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Router /api/songs/export [get]
+func ExportSongsHandler(repo repository.ExportRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Log.Debug("ExportSongsHandler: Start processing request")
+
+		name := r.URL.Query().Get("format")
+		if name == "" {
+			name = "ndjson"
+		}
+		format, ok := exportFormats[name]
+		if !ok {
+			logger.Log.Errorf("ExportSongsHandler: Invalid format: %s", name)
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidParameter, "Invalid format",
+				models.FieldError{Field: "format", Code: "invalid", Message: "must be one of: ndjson, csv, json"})
+			return
+		}
+
+		query, fieldErr := parseSongQuery(r, models.SongFields)
+		if fieldErr != nil {
+			logger.Log.Errorf("ExportSongsHandler: Invalid %s: %s", fieldErr.Field, fieldErr.Message)
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidParameter, "Invalid "+fieldErr.Field, *fieldErr)
+			return
+		}
+
+		w.Header().Set("Content-Type", format.contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="songs.%s"`, format.extension))
+		out := &trackingWriter{w: w}
+		writer := format.newWriter(out, query.Fields)
+
+		count := 0
+		opts := repository.ListOptions{Filter: query.Filter, Sort: query.Sort, Fields: query.Fields}
+		err := repo.Export(r.Context(), opts, func(song *models.Song) error {
+			count++
+			return writer.WriteSong(models.SongView{Song: song, Fields: query.Fields})
+		})
+		if err == nil {
+			err = writer.Close()
+		}
+		if err != nil {
+			if r.Context().Err() != nil {
+				logger.Log.Warnf("ExportSongsHandler: Export cancelled after %d songs", count)
+				return
+			}
+			logger.Log.Errorf("ExportSongsHandler: Export failed after %d songs: %v", count, err)
+			// После начала ответа статус изменить нельзя: клиент получит оборванную выгрузку
+			if !out.written {
+				w.Header().Del("Content-Disposition")
+				writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to export songs")
+			}
+			return
+		}
+
+		logger.Log.Infof("ExportSongsHandler: Exported %d songs", count)
+	}
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/w212w/GoProjectEM/internal/models"
+	"github.com/w212w/GoProjectEM/internal/repository"
+)
+
+// newExportServer обслуживает выгрузку каталога из repo
+func newExportServer(t *testing.T, repo repository.ExportRepository) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(ExportSongsHandler(repo))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// brokenExport выгружает written песен, а затем возвращает ошибку
+type brokenExport struct {
+	written int
+}
+
+func (e brokenExport) Export(ctx context.Context, opts repository.ListOptions, fn func(song *models.Song) error) error {
+	for i := 0; i < e.written; i++ {
+		if err := fn(&models.Song{ID: uint(i + 1), Group: "Muse", Title: "Uprising"}); err != nil {
+			return err
+		}
+	}
+	return errors.New("connection reset")
+}
+
+func TestExportSongsHandler(t *testing.T) {
+	_, songs := newTestServer(t, stubEnricher{}, 0)
+	server := newExportServer(t, songs)
+
+	tests := []struct {
+		name        string
+		query       string
+		contentType string
+		filename    string
+		want        string
+	}{
+		{
+			"ndjson by default", "?fields=id,title", "application/x-ndjson", "songs.ndjson",
+			`{"id":1,"title":"Supermassive Black Hole"}` + "\n" + `{"id":2,"title":"Uprising"}` + "\n" + `{"id":3,"title":"Bohemian Rhapsody"}` + "\n",
+		},
+		{
+			"json", "?format=json&fields=id&group=Muse", "application/json", "songs.json",
+			"[\n" + `{"id":1}` + ",\n" + `{"id":2}` + "\n]\n",
+		},
+		{"empty json", "?format=json&group=Nobody", "application/json", "songs.json", "[]\n"},
+		{
+			"csv", "?format=csv&fields=id,title,release_date&sort=-id", "text/csv; charset=utf-8", "songs.csv",
+			"id,title,release_date\n3,Bohemian Rhapsody,1975-10-31\n2,Uprising,2009\n1,Supermassive Black Hole,2006-07-16\n",
+		},
+		{"empty csv", "?format=csv&fields=id,title&group=Nobody", "text/csv; charset=utf-8", "songs.csv", "id,title\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, data := doRequest(t, server, "GET", "/"+tt.query, "", nil)
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", resp.StatusCode, data)
+			}
+			if got := resp.Header.Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			if got := resp.Header.Get("Content-Disposition"); !strings.Contains(got, tt.filename) {
+				t.Errorf("Content-Disposition = %q, want %s", got, tt.filename)
+			}
+			if string(data) != tt.want {
+				t.Errorf("body =\n%s\nwant\n%s", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportSongsHandlerAllFields(t *testing.T) {
+	_, songs := newTestServer(t, stubEnricher{}, 0)
+	server := newExportServer(t, songs)
+
+	resp, data := doRequest(t, server, "GET", "/?format=json&group=Queen", "", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", resp.StatusCode, data)
+	}
+	var got []models.Song
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("decode export: %v\n%s", err, data)
+	}
+	if len(got) != 1 || got[0].Title != "Bohemian Rhapsody" || got[0].Artist != "Freddie Mercury" || got[0].Version != 1 {
+		t.Errorf("export = %+v, want the full Queen song", got)
+	}
+}
+
+func TestExportSongsHandlerErrors(t *testing.T) {
+	_, songs := newTestServer(t, stubEnricher{}, 0)
+
+	tests := []struct {
+		name   string
+		repo   repository.ExportRepository
+		query  string
+		status int
+		code   string
+	}{
+		{"invalid format", songs, "?format=xml", http.StatusBadRequest, models.ErrCodeInvalidParameter},
+		{"invalid fields", songs, "?fields=id,album", http.StatusBadRequest, models.ErrCodeInvalidParameter},
+		{"failure before the first song", brokenExport{}, "", http.StatusInternalServerError, models.ErrCodeInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, data := doRequest(t, newExportServer(t, tt.repo), "GET", "/"+tt.query, "", nil)
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tt.status, data)
+			}
+			if problem := decodeProblem(t, data); problem.Code != tt.code {
+				t.Errorf("code = %q, want %q", problem.Code, tt.code)
+			}
+			if got := resp.Header.Get("Content-Disposition"); got != "" {
+				t.Errorf("Content-Disposition = %q on error", got)
+			}
+		})
+	}
+}
+
+func TestExportSongsHandlerFailureAfterStart(t *testing.T) {
+	// Статус уже отправлен: клиент получает оборванную выгрузку без завершающей скобки
+	server := newExportServer(t, brokenExport{written: 2})
+
+	resp, data := doRequest(t, server, "GET", "/?format=json&fields=id", "", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if want := "[\n" + `{"id":1}` + ",\n" + `{"id":2}`; string(data) != want {
+		t.Errorf("body = %q, want %q", data, want)
+	}
+}
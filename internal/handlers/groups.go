@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/w212w/GoProjectEM/internal/logger"
+	"github.com/w212w/GoProjectEM/internal/models"
+	"github.com/w212w/GoProjectEM/internal/repository"
+	"github.com/w212w/GoProjectEM/internal/validation"
+)
+
+func parseGroupID(r *http.Request) (uint, error) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid group id %q", mux.Vars(r)["id"])
+	}
+	return uint(id), nil
+}
+
+// writeGroupExists отвечает 409 со ссылкой на группу с тем же именем, если она найдена
+func writeGroupExists(w http.ResponseWriter, r *http.Request, groups repository.GroupRepository, name string) {
+	detail := "Group with the same name already exists"
+	if existing, err := groups.FindGroupByName(r.Context(), name); err == nil {
+		location := fmt.Sprintf("/api/groups/%d", existing.ID)
+		w.Header().Set("Location", location)
+		detail += ": " + location
+	}
+	writeError(w, r, http.StatusConflict, models.ErrCodeGroupExists, detail)
+}
+
+func writeGroup(w http.ResponseWriter, status int, group *models.Group) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(group); err != nil {
+		logger.Log.Errorf("Failed to encode group: %v", err)
+	}
+}
+
+// ListGroupsHandler godoc
+// @Summary Получить список групп
+// @Description Получить группы в алфавитном порядке с пагинацией
+// @Tags groups
+// @Produce json
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество результатов на странице" default(10)
+// @Success 200 {array} models.Group "Список групп"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Router /api/groups [get]
+func ListGroupsHandler(groups repository.GroupRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Log.Debug("ListGroupsHandler: Start processing request")
+
+		offset, limit := parsePage(r, 10)
+		list, err := groups.ListGroups(r.Context(), offset, limit)
+		if err != nil {
+			logger.Log.Errorf("ListGroupsHandler: Failed to retrieve groups: %v", err)
+			writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to retrieve groups")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(list); err != nil {
+			logger.Log.Error("ListGroupsHandler: Failed to encode response")
+			writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to encode response")
+			return
+		}
+
+		logger.Log.Info("ListGroupsHandler: Successfully responded with groups")
+	}
+}
+
+// GetGroupHandler godoc
+// @Summary Получить группу
+// @Description Получить группу по ее ID
+// @Tags groups
+// @Produce json
+// @Param id path string true "ID группы"
+// @Success 200 {object} models.Group "Группа"
+// @Failure 400 {object} models.ErrorResponse "Неверный ID группы"
+// @Failure 404 {object} models.ErrorResponse "Группа не найдена"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Router /api/groups/{id} [get]
+func GetGroupHandler(groups repository.GroupRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Log.Debug("GetGroupHandler: Start processing request")
+
+		id, err := parseGroupID(r)
+		if err != nil {
+			logger.Log.Error("GetGroupHandler: Invalid group ID")
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidID, "Invalid group ID")
+			return
+		}
+
+		group, err := groups.GetGroup(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				logger.Log.Error("GetGroupHandler: Group not found")
+				writeError(w, r, http.StatusNotFound, models.ErrCodeGroupNotFound, "Group not found")
+			} else {
+				logger.Log.Errorf("GetGroupHandler: Failed to retrieve group: %v", err)
+				writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to retrieve group")
+			}
+			return
+		}
+
+		writeGroup(w, http.StatusOK, group)
+		logger.Log.Info("GetGroupHandler: Successfully responded with group")
+	}
+}
+
+// CreateGroupHandler godoc
+// @Summary Добавить группу
+// @Description Добавить группу без песен. Имена групп уникальны без учета регистра, пробелов и ё/е
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param group body models.GroupRequest true "Данные группы"
+// @Success 201 {object} models.Group "Созданная группа"
+// @Failure 400 {object} models.ErrorResponse "Неверный формат JSON"
+// @Failure 409 {object} models.ErrorResponse "Группа с таким именем уже существует"
+// @Failure 422 {object} models.ErrorResponse "Ошибки валидации полей"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Router /api/groups [post]
+func CreateGroupHandler(groups repository.GroupRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Log.Debug("CreateGroupHandler: Start processing request")
+
+		var input models.GroupRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			logger.Log.Error("CreateGroupHandler: Invalid JSON format")
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidJSON, "Invalid JSON format")
+			return
+		}
+		if fieldErrors := validation.Validate(&input); len(fieldErrors) > 0 {
+			logger.Log.Errorf("CreateGroupHandler: Validation failed: %v", fieldErrors)
+			writeValidationError(w, r, fieldErrors)
+			return
+		}
+
+		group := models.Group{Name: input.Name}
+		if err := groups.CreateGroup(r.Context(), &group); err != nil {
+			if errors.Is(err, repository.ErrDuplicate) {
+				logger.Log.Error("CreateGroupHandler: Group already exists")
+				writeGroupExists(w, r, groups, input.Name)
+			} else {
+				logger.Log.Errorf("CreateGroupHandler: Failed to create group: %v", err)
+				writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to create group")
+			}
+			return
+		}
+
+		w.Header().Set("Location", fmt.Sprintf("/api/groups/%d", group.ID))
+		writeGroup(w, http.StatusCreated, &group)
+		logger.Log.Infof("CreateGroupHandler: Group %d created", group.ID)
+	}
+}
+
+// UpdateGroupHandler godoc
+// @Summary Переименовать группу
+// @Description Переименовать группу. Поле group всех ее песен меняется, изменения записываются в историю песен
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param id path string true "ID группы"
+// @Param group body models.GroupRequest true "Данные группы"
+// @Success 200 {object} models.Group "Обновленная группа"
+// @Failure 400 {object} models.ErrorResponse "Неверный формат JSON"
+// @Failure 404 {object} models.ErrorResponse "Группа не найдена"
+// @Failure 409 {object} models.ErrorResponse "Группа или песня с таким именем уже существует"
+// @Failure 422 {object} models.ErrorResponse "Ошибки валидации полей"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Router /api/groups/{id} [put]
+func UpdateGroupHandler(groups repository.GroupRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Log.Debug("UpdateGroupHandler: Start processing request")
+
+		id, err := parseGroupID(r)
+		if err != nil {
+			logger.Log.Error("UpdateGroupHandler: Invalid group ID")
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidID, "Invalid group ID")
+			return
+		}
+
+		var input models.GroupRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			logger.Log.Error("UpdateGroupHandler: Invalid JSON format")
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidJSON, "Invalid JSON format")
+			return
+		}
+		if fieldErrors := validation.Validate(&input); len(fieldErrors) > 0 {
+			logger.Log.Errorf("UpdateGroupHandler: Validation failed: %v", fieldErrors)
+			writeValidationError(w, r, fieldErrors)
+			return
+		}
+
+		info := repository.ChangeInfoFromContext(r.Context())
+		info.Note = fmt.Sprintf("group %d renamed", id)
+		ctx := repository.WithChangeInfo(r.Context(), info)
+
+		group := models.Group{ID: id, Name: input.Name}
+		if err := groups.UpdateGroup(ctx, &group); err != nil {
+			switch {
+			case errors.Is(err, repository.ErrNotFound):
+				logger.Log.Error("UpdateGroupHandler: Group not found")
+				writeError(w, r, http.StatusNotFound, models.ErrCodeGroupNotFound, "Group not found")
+			case errors.Is(err, repository.ErrDuplicate):
+				logger.Log.Error("UpdateGroupHandler: Group or song with the same name already exists")
+				if existing, err := groups.FindGroupByName(r.Context(), input.Name); err == nil && existing.ID != id {
+					writeGroupExists(w, r, groups, input.Name)
+				} else {
+					writeError(w, r, http.StatusConflict, models.ErrCodeSongExists, "Renaming would duplicate an existing song of this group")
+				}
+			default:
+				logger.Log.Errorf("UpdateGroupHandler: Failed to update group: %v", err)
+				writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to update group")
+			}
+			return
+		}
+
+		writeGroup(w, http.StatusOK, &group)
+		logger.Log.Infof("UpdateGroupHandler: Group %d updated", id)
+	}
+}
+
+// DeleteGroupHandler godoc
+// @Summary Удалить группу
+// @Description Удалить группу по ее ID. Группу с песнями удалить нельзя
+// @Tags groups
+// @Param id path string true "ID группы"
+// @Success 204 "Группа удалена"
+// @Failure 400 {object} models.ErrorResponse "Неверный ID группы"
+// @Failure 404 {object} models.ErrorResponse "Группа не найдена"
+// @Failure 409 {object} models.ErrorResponse "У группы есть песни"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Router /api/groups/{id} [delete]
+func DeleteGroupHandler(groups repository.GroupRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Log.Debug("DeleteGroupHandler: Start processing request")
+
+		id, err := parseGroupID(r)
+		if err != nil {
+			logger.Log.Error("DeleteGroupHandler: Invalid group ID")
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidID, "Invalid group ID")
+			return
+		}
+
+		if err := groups.DeleteGroup(r.Context(), id); err != nil {
+			switch {
+			case errors.Is(err, repository.ErrNotFound):
+				logger.Log.Error("DeleteGroupHandler: Group not found")
+				writeError(w, r, http.StatusNotFound, models.ErrCodeGroupNotFound, "Group not found")
+			case errors.Is(err, repository.ErrInUse):
+				logger.Log.Error("DeleteGroupHandler: Group has songs")
+				writeError(w, r, http.StatusConflict, models.ErrCodeGroupInUse,
+					fmt.Sprintf("Group has songs, see /api/groups/%d/songs", id))
+			default:
+				logger.Log.Errorf("DeleteGroupHandler: Failed to delete group: %v", err)
+				writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to delete group")
+			}
+			return
+		}
+
+		logger.Log.Infof("DeleteGroupHandler: Group %d deleted", id)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// ListGroupSongsHandler godoc
+// @Summary Получить песни группы
+// @Description Получить неудаленные песни группы с пагинацией
+// @Tags groups
+// @Produce json
+// @Param id path string true "ID группы"
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество результатов на странице" default(10)
+// @Success 200 {array} models.Song "Песни группы"
+// @Failure 400 {object} models.ErrorResponse "Неверный ID группы"
+// @Failure 404 {object} models.ErrorResponse "Группа не найдена"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Router /api/groups/{id}/songs [get]
+func ListGroupSongsHandler(groups repository.GroupRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Log.Debug("ListGroupSongsHandler: Start processing request")
+
+		id, err := parseGroupID(r)
+		if err != nil {
+			logger.Log.Error("ListGroupSongsHandler: Invalid group ID")
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidID, "Invalid group ID")
+			return
+		}
+
+		offset, limit := parsePage(r, 10)
+		songs, err := groups.ListGroupSongs(r.Context(), id, offset, limit)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				logger.Log.Error("ListGroupSongsHandler: Group not found")
+				writeError(w, r, http.StatusNotFound, models.ErrCodeGroupNotFound, "Group not found")
+			} else {
+				logger.Log.Errorf("ListGroupSongsHandler: Failed to retrieve songs: %v", err)
+				writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to retrieve songs")
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(songs); err != nil {
+			logger.Log.Error("ListGroupSongsHandler: Failed to encode response")
+			writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to encode response")
+			return
+		}
+
+		logger.Log.Info("ListGroupSongsHandler: Successfully responded with songs")
+	}
+}
@@ -1,40 +1,119 @@
 package handlers
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 
 	"github.com/gorilla/mux"
+	"github.com/w212w/GoProjectEM/internal/enricher"
+	"github.com/w212w/GoProjectEM/internal/ingest"
+	"github.com/w212w/GoProjectEM/internal/jsonpatch"
 	"github.com/w212w/GoProjectEM/internal/logger"
+	"github.com/w212w/GoProjectEM/internal/lyrics"
 	"github.com/w212w/GoProjectEM/internal/models"
-	"gorm.io/gorm"
+	"github.com/w212w/GoProjectEM/internal/repository"
+	"github.com/w212w/GoProjectEM/internal/validation"
 )
 
+func parseSongID(r *http.Request) (uint, error) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid song id %q", mux.Vars(r)["id"])
+	}
+	return uint(id), nil
+}
+
+// parsePage читает параметры page и limit и возвращает смещение и размер страницы.
+// Некорректные значения заменяются значениями по умолчанию.
+func parsePage(r *http.Request, defaultLimit int) (offset, limit int) {
+	page := 1
+	limit = defaultLimit
+	if p, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil && p > 0 {
+		page = p
+	}
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+		limit = l
+	}
+	return (page - 1) * limit, limit
+}
+
+func wantsAsync(r *http.Request) bool {
+	if async, err := strconv.ParseBool(r.URL.Query().Get("async")); err == nil {
+		return async
+	}
+	for _, pref := range strings.Split(r.Header.Get("Prefer"), ",") {
+		if strings.TrimSpace(pref) == "respond-async" {
+			return true
+		}
+	}
+	return false
+}
+
 // GetSongsHandler godoc
 // @Summary Получить список песен
-// @Description Получить список песен с возможностью фильтрации по артисту и названию
+// @Description Получить список песен с возможностью фильтрации по артисту, названию, тегам и жанрам
+// @Description и полнотекстового поиска по тексту песни. При поиске песни сортируются по релевантности (score).
+// @Description Ответ - страница с общим количеством песен и курсорами соседних страниц, они же в заголовке Link (RFC 8288).
+// @Description Курсорная пагинация устойчива к добавлению песен; page оставлен для совместимости.
+// @Description С facets=true страница содержит количество подходящих песен по тегам и жанрам.
+// @Description Текст песен в список не входит, если не указан в fields.
+// @Description Формат ответа выбирается по заголовку Accept: JSON, XML, CSV, YAML или text/plain (строка на песню)
 // @Tags songs
 // @Accept json
 // @Produce json
+// @Produce xml
+// @Produce text/csv
+// @Produce application/yaml
+// @Produce plain
 // @Param artist query string false "Фильтр по артисту"
 // @Param title query string false "Фильтр по названию"
-// @Param page query int false "Номер страницы" default(1)
-// @Param limit query int false "Количество результатов на странице" default(10)
-// @Success 200 {array} models.Song "Список песен"
+// @Param q query string false "Полнотекстовый поиск по названию, группе и тексту"
+// @Param group query string false "Фильтр по группе"
+// @Param has_link query bool false "Только песни со ссылкой (true) или без нее (false)"
+// @Param released_from query string false "Дата релиза не раньше (2006, 2006-07, 16.07.2006)"
+// @Param released_to query string false "Дата релиза не позже (2006, 2006-07, 16.07.2006)"
+// @Param created_from query string false "Создана не раньше (дата или RFC 3339)"
+// @Param created_to query string false "Создана не позже (дата или RFC 3339)"
+// @Param updated_from query string false "Изменена не раньше (дата или RFC 3339)"
+// @Param updated_to query string false "Изменена не позже (дата или RFC 3339)"
+// @Param filter query string false "Выражение фильтра: сравнения полей (=, !=, >, >=, <, <=, ~ - подстрока) с and, or, not и скобками, например release_date>=2000 and group~muse"
+// @Param album query int false "ID альбома: только его треки, без sort и q - в порядке альбома"
+// @Param tag query []string false "Тег, можно повторять: песни со всеми тегами" collectionFormat(multi)
+// @Param genre query []string false "Жанр из словаря, можно повторять: песни со всеми жанрами" collectionFormat(multi)
+// @Param facets query bool false "Вернуть объект с песнями и фасетами по тегам и жанрам"
+// @Param sort query string false "Сортировка: поля через запятую, минус - по убыванию (любая колонка песни, кроме text)"
+// @Param fields query string false "Поля песен через запятую, например id,title,group. По умолчанию все, кроме text"
+// @Param cursor query string false "Курсор страницы из next_cursor или prev_cursor"
+// @Param page query int false "Номер страницы, если cursor не задан" default(1)
+// @Param limit query int false "Количество результатов на странице, не больше SONGS_MAX_LIMIT" default(10)
+// @Success 200 {object} models.SongPage "Страница песен"
+// @Header 200 {string} Link "Ссылки на первую, предыдущую и следующую страницы"
 // @Failure 400 {object} models.ErrorResponse "Неверные параметры"
+// @Failure 406 {object} models.ErrorResponse "Нет формата из заголовка Accept"
 // @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
 // @Router /api/songs [get]
-func GetSongsHandler(db *gorm.DB) http.HandlerFunc {
+func GetSongsHandler(repo repository.SongRepository, tags repository.TagRepository, maxLimit int) http.HandlerFunc {
+	if maxLimit < 1 {
+		maxLimit = 1
+	}
 	return func(w http.ResponseWriter, r *http.Request) {
 		logger.Log.Debug("GetSongsHandler: Start processing request")
 
+		enc, ok := negotiate(w, r, "GetSongsHandler", models.SongPage{})
+		if !ok {
+			return
+		}
+
 		artist := r.URL.Query().Get("artist")
 		title := r.URL.Query().Get("title")
+		q := r.URL.Query().Get("q")
 		pageStr := r.URL.Query().Get("page")
 		limitStr := r.URL.Query().Get("limit")
 
@@ -51,32 +130,86 @@ func GetSongsHandler(db *gorm.DB) http.HandlerFunc {
 				limit = l
 			}
 		}
+		limit = min(limit, maxLimit)
 
-		logger.Log.Debugf("GetSongsHandler: Parameters received - artist: %s, title: %s, page: %d, limit: %d", artist, title, page, limit)
-
-		var songs []models.Song
-		query := db.Model(&models.Song{})
+		logger.Log.Debugf("GetSongsHandler: Parameters received - artist: %s, title: %s, q: %s, page: %d, limit: %d", artist, title, q, page, limit)
 
-		if artist != "" {
-			query = query.Where("artist ILIKE ?", "%"+artist+"%")
+		query, fieldErr := parseSongQuery(r, models.DefaultListFields)
+		if fieldErr != nil {
+			logger.Log.Errorf("GetSongsHandler: Invalid %s: %s", fieldErr.Field, fieldErr.Message)
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidParameter, "Invalid "+fieldErr.Field, *fieldErr)
+			return
 		}
-		if title != "" {
-			query = query.Where("title ILIKE ?", "%"+title+"%")
+		filter := query.Filter
+		withFacets, _ := strconv.ParseBool(r.URL.Query().Get("facets"))
+
+		opts := repository.ListOptions{
+			Filter: filter,
+			Sort:   query.Sort,
+			Fields: query.Fields,
+			Offset: (page - 1) * limit,
+			// Лишняя песня показывает, есть ли следующая (или предыдущая) страница
+			Limit: limit + 1,
+		}
+		if c := r.URL.Query().Get("cursor"); c != "" {
+			var err error
+			if opts.Cursor, err = repository.ParseCursor(c); err != nil {
+				logger.Log.Error("GetSongsHandler: Invalid cursor")
+				writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidParameter, "Invalid cursor",
+					models.FieldError{Field: "cursor", Code: "invalid", Message: "cursor is malformed"})
+				return
+			}
 		}
 
-		offset := (page - 1) * limit
-		if err := query.Offset(offset).Limit(limit).Find(&songs).Error; err != nil {
-			logger.Log.Error("GetSongsHandler: Failed to retrieve songs")
-			http.Error(w, "Failed to retrieve songs", http.StatusInternalServerError)
+		songs, err := repo.List(r.Context(), opts)
+		if err != nil {
+			if errors.Is(err, repository.ErrInvalidCursor) {
+				logger.Log.Error("GetSongsHandler: Cursor does not match sort")
+				writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidParameter, "Invalid cursor",
+					models.FieldError{Field: "cursor", Code: "invalid", Message: "cursor was issued for another sort, q or album"})
+			} else {
+				logger.Log.Error("GetSongsHandler: Failed to retrieve songs")
+				writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to retrieve songs")
+			}
+			return
+		}
+		total, err := repo.Count(r.Context(), filter)
+		if err != nil {
+			logger.Log.Errorf("GetSongsHandler: Failed to count songs: %v", err)
+			writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to count songs")
 			return
 		}
 
 		logger.Log.Debug("GetSongsHandler: Songs retrieved successfully")
 
-		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(songs); err != nil {
-			logger.Log.Error("GetSongsHandler: Failed to encode response")
-			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		result := models.SongPage{Songs: songs, Total: total, Limit: limit, Fields: query.Fields}
+		hasPrev, hasNext := opts.Cursor != nil || opts.Offset > 0, false
+		if opts.Cursor != nil && opts.Cursor.Before {
+			hasPrev, hasNext = len(songs) > limit, true
+			if hasPrev {
+				result.Songs = songs[1:]
+			}
+		} else if hasNext = len(songs) > limit; hasNext {
+			result.Songs = songs[:limit]
+		}
+		if n := len(result.Songs); n > 0 {
+			if hasNext {
+				result.NextCursor = repository.NewCursor(opts, &result.Songs[n-1], false)
+			}
+			if hasPrev {
+				result.PrevCursor = repository.NewCursor(opts, &result.Songs[0], true)
+			}
+		}
+		if withFacets {
+			if result.Facets, err = tags.Facets(r.Context(), filter); err != nil {
+				logger.Log.Errorf("GetSongsHandler: Failed to count facets: %v", err)
+				writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to count facets")
+				return
+			}
+		}
+
+		setPageLinks(w, r, result.NextCursor, result.PrevCursor)
+		if !writeEncoded(w, r, "GetSongsHandler", enc, result) {
 			return
 		}
 
@@ -84,36 +217,123 @@ func GetSongsHandler(db *gorm.DB) http.HandlerFunc {
 	}
 }
 
+// GetSongHandler godoc
+// @Summary Получить песню
+// @Description Получить песню по ее ID. Ответ содержит ETag, зависящий от версии песни и формата ответа;
+// @Description с заголовком If-None-Match возвращается 304, если песня не изменилась.
+// @Description Формат ответа выбирается по заголовку Accept: JSON, XML, CSV, YAML или text/plain (только текст песни)
+// @Tags songs
+// @Produce json
+// @Produce xml
+// @Produce text/csv
+// @Produce application/yaml
+// @Produce plain
+// @Param id path string true "ID песни"
+// @Param If-None-Match header string false "ETag песни"
+// @Success 200 {object} models.Song "Песня"
+// @Success 304 "Песня не изменилась"
+// @Failure 400 {object} models.ErrorResponse "Неверный ID песни"
+// @Failure 404 {object} models.ErrorResponse "Песня не найдена"
+// @Failure 406 {object} models.ErrorResponse "Нет формата из заголовка Accept"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Router /songs/{id} [get]
+func GetSongHandler(repo repository.SongRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Log.Debug("GetSongHandler: Start processing request")
+
+		enc, ok := negotiate(w, r, "GetSongHandler", (*models.Song)(nil))
+		if !ok {
+			return
+		}
+
+		id, err := parseSongID(r)
+		if err != nil {
+			logger.Log.Error("GetSongHandler: Invalid song ID")
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidID, "Invalid song ID")
+			return
+		}
+
+		logger.Log.Debugf("GetSongHandler: Song ID received: %d", id)
+
+		song, err := repo.Get(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				logger.Log.Error("GetSongHandler: Song not found")
+				writeError(w, r, http.StatusNotFound, models.ErrCodeSongNotFound, "Song not found")
+			} else {
+				logger.Log.Errorf("GetSongHandler: Failed to retrieve song: %v", err)
+				writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to retrieve song")
+			}
+			return
+		}
+
+		etag := representationETag(songETag(song), enc)
+		if ifNoneMatch(r, etag) {
+			logger.Log.Debug("GetSongHandler: Not modified")
+			writeNotModified(w, etag)
+			return
+		}
+
+		w.Header().Set("ETag", etag)
+		if !writeEncoded(w, r, "GetSongHandler", enc, song) {
+			return
+		}
+
+		logger.Log.Info("GetSongHandler: Successfully responded with song")
+	}
+}
+
 // GetSongTextHandler godoc
 // @Summary Получить текст песни
-// @Description Получить текст песни по ее ID с возможностью пагинации по стихам
+// @Description Получить текст песни по ее ID, разбитый на секции (куплет, припев, бридж, вступление, концовка),
+// @Description с возможностью пагинации по секциям. ETag ответа зависит от версии песни, параметров page, limit, section и collapse и формата ответа.
+// @Description Формат ответа выбирается по заголовку Accept: JSON, XML, CSV, YAML или text/plain (секции через пустую строку)
 // @Tags songs
 // @Accept json
 // @Produce json
+// @Produce xml
+// @Produce text/csv
+// @Produce application/yaml
+// @Produce plain
 // @Param id path string true "ID песни"
 // @Param page query int false "Номер страницы" default(1)
-// @Param limit query int false "Количество стихов на странице" default(2)
+// @Param limit query int false "Количество секций на странице" default(2)
+// @Param section query string false "Только секции указанного типа" Enums(verse, chorus, bridge, intro, outro)
+// @Param collapse query bool false "Схлопнуть повторяющиеся припевы"
+// @Param If-None-Match header string false "ETag страницы текста"
 // @Success 200 {object} models.SongTextResponse "Текст песни с пагинацией"
+// @Success 304 "Песня не изменилась"
 // @Failure 400 {object} models.ErrorResponse "Неверные параметры"
 // @Failure 404 {object} models.ErrorResponse "Песня не найдена"
+// @Failure 406 {object} models.ErrorResponse "Нет формата из заголовка Accept"
 // @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
 // @Router /songs/{id}/text [get]
-func GetSongTextHandler(db *gorm.DB) http.HandlerFunc {
+func GetSongTextHandler(repo repository.SongRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		logger.Log.Debug("GetSongTextHandler: Start processing request")
 
-		vars := mux.Vars(r)
-		id := vars["id"]
+		enc, ok := negotiate(w, r, "GetSongTextHandler", models.SongTextResponse{})
+		if !ok {
+			return
+		}
+
+		id, err := parseSongID(r)
+		if err != nil {
+			logger.Log.Error("GetSongTextHandler: Invalid song ID")
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidID, "Invalid song ID")
+			return
+		}
 
-		logger.Log.Debugf("GetSongTextHandler: Song ID received: %s", id)
+		logger.Log.Debugf("GetSongTextHandler: Song ID received: %d", id)
 
-		var song models.Song
-		if err := db.First(&song, id).Error; err != nil {
-			logger.Log.Error("GetSongTextHandler: Song not found")
-			if err == gorm.ErrRecordNotFound {
-				http.Error(w, "Song not found", http.StatusNotFound)
+		song, err := repo.Get(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				logger.Log.Error("GetSongTextHandler: Song not found")
+				writeError(w, r, http.StatusNotFound, models.ErrCodeSongNotFound, "Song not found")
 			} else {
-				http.Error(w, "Failed to retrieve song", http.StatusInternalServerError)
+				logger.Log.Errorf("GetSongTextHandler: Failed to retrieve song: %v", err)
+				writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to retrieve song")
 			}
 			return
 		}
@@ -128,33 +348,60 @@ func GetSongTextHandler(db *gorm.DB) http.HandlerFunc {
 			limit = 2
 		}
 
-		verses := strings.Split(song.Text, "\n\n")
-		totalVerses := len(verses)
+		sectionType := r.URL.Query().Get("section")
+		if sectionType != "" && !lyrics.IsType(sectionType) {
+			logger.Log.Errorf("GetSongTextHandler: Unknown section type %q", sectionType)
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidParameter, "Unknown section type",
+				models.FieldError{Field: "section", Code: "invalid", Message: "must be one of verse, chorus, bridge, intro, outro"})
+			return
+		}
+		collapse, _ := strconv.ParseBool(r.URL.Query().Get("collapse"))
+
+		etag := representationETag(songTextETag(song, page, limit, sectionType, collapse), enc)
+		if ifNoneMatch(r, etag) {
+			logger.Log.Debug("GetSongTextHandler: Not modified")
+			writeNotModified(w, etag)
+			return
+		}
+
+		sections := lyrics.Parse(song.Text)
+		if sectionType != "" {
+			sections = lyrics.FilterType(sections, sectionType)
+		}
+		if collapse {
+			sections = lyrics.CollapseRepeats(sections)
+		}
+		totalVerses := len(sections)
 
 		start := (page - 1) * limit
 		end := start + limit
 		if start > totalVerses {
 			logger.Log.Error("GetSongTextHandler: Page out of range")
-			http.Error(w, "Page out of range", http.StatusBadRequest)
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidParameter, "Page out of range",
+				models.FieldError{Field: "page", Code: "out_of_range", Message: fmt.Sprintf("song has %d sections", totalVerses)})
 			return
 		}
 		if end > totalVerses {
 			end = totalVerses
 		}
 
+		verses := make([]string, 0, end-start)
+		for _, section := range sections[start:end] {
+			verses = append(verses, section.Text)
+		}
+
 		response := models.SongTextResponse{
 			TotalVerses: totalVerses,
 			Page:        page,
 			Limit:       limit,
-			Verses:      verses[start:end],
+			Verses:      verses,
+			Sections:    sections[start:end],
 		}
 
 		logger.Log.Debug("GetSongTextHandler: Response prepared successfully")
 
-		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(response); err != nil {
-			logger.Log.Error("GetSongTextHandler: Failed to encode response")
-			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		w.Header().Set("ETag", etag)
+		if !writeEncoded(w, r, "GetSongTextHandler", enc, response) {
 			return
 		}
 
@@ -164,44 +411,63 @@ func GetSongTextHandler(db *gorm.DB) http.HandlerFunc {
 
 // DeleteSongHandler godoc
 // @Summary Удалить песню
-// @Description Удалить песню по ее ID
+// @Description Переместить песню в корзину по ее ID. С заголовком If-Match песня удаляется, только если ее ETag совпадает
 // @Tags songs
 // @Accept json
 // @Produce json
 // @Param id path string true "ID песни"
+// @Param If-Match header string false "ETag песни"
 // @Success 200 {string} string "Песня удалена успешно"
-// @Failure 400 {object} models.ErrorResponse "ID не указан"
+// @Failure 400 {object} models.ErrorResponse "Неверный ID песни"
 // @Failure 404 {object} models.ErrorResponse "Песня не найдена"
+// @Failure 412 {object} models.ErrorResponse "Песня была изменена"
 // @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
 // @Router /songs/{id} [delete]
-func DeleteSongHandler(db *gorm.DB) http.HandlerFunc {
+func DeleteSongHandler(repo repository.SongRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		logger.Log.Debug("DeleteSongHandler: Start processing request")
-		vars := mux.Vars(r)
-		id := vars["id"]
-
-		logger.Log.Debugf("DeleteSongHandler: Song ID received: %s", id)
 
-		if id == "" {
-			logger.Log.Error("DeleteSongHandler: ID is required")
-			http.Error(w, "ID is required", http.StatusBadRequest)
+		id, err := parseSongID(r)
+		if err != nil {
+			logger.Log.Error("DeleteSongHandler: Invalid song ID")
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidID, "Invalid song ID")
 			return
 		}
 
-		var song models.Song
-		if err := db.First(&song, "id = ?", id).Error; err != nil {
-			logger.Log.Error("DeleteSongHandler: Song not found")
-			if err == gorm.ErrRecordNotFound {
-				http.Error(w, "Song not found", http.StatusNotFound)
-			} else {
-				http.Error(w, "Failed to find song", http.StatusInternalServerError)
+		logger.Log.Debugf("DeleteSongHandler: Song ID received: %d", id)
+
+		version := 0
+		if r.Header.Get("If-Match") != "" {
+			song, err := repo.Get(r.Context(), id)
+			if err != nil {
+				if errors.Is(err, repository.ErrNotFound) {
+					logger.Log.Error("DeleteSongHandler: Song not found")
+					writeError(w, r, http.StatusNotFound, models.ErrCodeSongNotFound, "Song not found")
+				} else {
+					logger.Log.Errorf("DeleteSongHandler: Failed to find song: %v", err)
+					writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to find song")
+				}
+				return
 			}
-			return
+			if !ifMatch(r, songETag(song)) {
+				logger.Log.Error("DeleteSongHandler: If-Match precondition failed")
+				writeError(w, r, http.StatusPreconditionFailed, models.ErrCodePreconditionFailed, "Song has been modified")
+				return
+			}
+			version = song.Version
 		}
 
-		if err := db.Delete(&song).Error; err != nil {
-			logger.Log.Error("DeleteSongHandler: Failed to delete song")
-			http.Error(w, "Failed to delete song", http.StatusInternalServerError)
+		if err := repo.Delete(r.Context(), id, version); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				logger.Log.Error("DeleteSongHandler: Song not found")
+				writeError(w, r, http.StatusNotFound, models.ErrCodeSongNotFound, "Song not found")
+			} else if errors.Is(err, repository.ErrConflict) {
+				logger.Log.Error("DeleteSongHandler: Song has been modified concurrently")
+				writeError(w, r, http.StatusPreconditionFailed, models.ErrCodePreconditionFailed, "Song has been modified")
+			} else {
+				logger.Log.Errorf("DeleteSongHandler: Failed to delete song: %v", err)
+				writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to delete song")
+			}
 			return
 		}
 
@@ -218,57 +484,74 @@ func DeleteSongHandler(db *gorm.DB) http.HandlerFunc {
 // @Accept json
 // @Produce json
 // @Param id path string true "ID песни"
-// @Param song body models.Song true "Данные для обновления песни"
+// @Param If-Match header string false "ETag песни"
+// @Param song body models.UpdateSongRequest true "Данные для обновления песни"
 // @Success 200 {string} string "Песня обновлена успешно"
 // @Failure 400 {object} models.ErrorResponse "Неверный формат JSON"
 // @Failure 404 {object} models.ErrorResponse "Песня не найдена"
+// @Failure 409 {object} models.ErrorResponse "Песня с такой группой и названием уже существует"
+// @Failure 412 {object} models.ErrorResponse "Песня была изменена"
+// @Failure 422 {object} models.ErrorResponse "Ошибки валидации полей"
 // @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
 // @Router /songs/{id} [put]
-func UpdateSongHandler(db *gorm.DB) http.HandlerFunc {
+func UpdateSongHandler(repo repository.SongRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		logger.Log.Debug("UpdateSongHandler: Start processing request")
 
-		vars := mux.Vars(r)
-		id := vars["id"]
-
-		logger.Log.Debugf("UpdateSongHandler: Song ID received: %s", id)
-
-		if id == "" {
-			logger.Log.Error("UpdateSongHandler: ID is required")
-			http.Error(w, "ID is required", http.StatusBadRequest)
+		id, err := parseSongID(r)
+		if err != nil {
+			logger.Log.Error("UpdateSongHandler: Invalid song ID")
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidID, "Invalid song ID")
 			return
 		}
 
-		var song models.Song
-		if err := db.First(&song, "id = ?", id).Error; err != nil {
-			logger.Log.Error("UpdateSongHandler: Song not found")
-			if err == gorm.ErrRecordNotFound {
-				http.Error(w, "Song not found", http.StatusNotFound)
+		logger.Log.Debugf("UpdateSongHandler: Song ID received: %d", id)
+
+		song, err := repo.Get(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				logger.Log.Error("UpdateSongHandler: Song not found")
+				writeError(w, r, http.StatusNotFound, models.ErrCodeSongNotFound, "Song not found")
 			} else {
-				http.Error(w, "Failed to find song", http.StatusInternalServerError)
+				logger.Log.Errorf("UpdateSongHandler: Failed to find song: %v", err)
+				writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to find song")
 			}
 			return
 		}
+		if !ifMatch(r, songETag(song)) {
+			logger.Log.Error("UpdateSongHandler: If-Match precondition failed")
+			writeError(w, r, http.StatusPreconditionFailed, models.ErrCodePreconditionFailed, "Song has been modified")
+			return
+		}
 
-		var updatedData models.Song
-		if err := json.NewDecoder(r.Body).Decode(&updatedData); err != nil {
+		var input models.UpdateSongRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
 			logger.Log.Error("UpdateSongHandler: Invalid JSON format")
-			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidJSON, "Invalid JSON format")
+			return
+		}
+		if fieldErrors := validation.Validate(&input); len(fieldErrors) > 0 {
+			logger.Log.Errorf("UpdateSongHandler: Validation failed: %v", fieldErrors)
+			writeValidationError(w, r, fieldErrors)
 			return
 		}
 
-		song.Artist = updatedData.Artist
-		song.Title = updatedData.Title
-		song.ReleaseDate = updatedData.ReleaseDate
-		song.Text = updatedData.Text
-		song.Link = updatedData.Link
-		song.Group = updatedData.Group
+		input.Apply(song)
 
-		if err := db.Save(&song).Error; err != nil {
-			logger.Log.Error("UpdateSongHandler: Failed to update song")
-			http.Error(w, "Failed to update song", http.StatusInternalServerError)
+		if err := repo.Update(r.Context(), song); err != nil {
+			if errors.Is(err, repository.ErrConflict) {
+				logger.Log.Error("UpdateSongHandler: Song has been modified concurrently")
+				writeError(w, r, http.StatusPreconditionFailed, models.ErrCodePreconditionFailed, "Song has been modified")
+			} else if errors.Is(err, repository.ErrDuplicate) {
+				logger.Log.Error("UpdateSongHandler: Song with the same group and title already exists")
+				writeDuplicate(w, r, repo, song.Group, song.Title)
+			} else {
+				logger.Log.Errorf("UpdateSongHandler: Failed to update song: %v", err)
+				writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to update song")
+			}
 			return
 		}
+		setChangedSongETag(w, r, song)
 
 		logger.Log.Info("UpdateSongHandler: Song updated successfully")
 		w.WriteHeader(http.StatusOK)
@@ -276,94 +559,383 @@ func UpdateSongHandler(db *gorm.DB) http.HandlerFunc {
 	}
 }
 
+// PatchSongHandler godoc
+// @Summary Частично обновить песню
+// @Description Изменить только переданные поля песни. Поддерживаются JSON Merge Patch (RFC 7396,
+// @Description Content-Type: application/merge-patch+json или application/json) и JSON Patch
+// @Description (RFC 6902, Content-Type: application/json-patch+json). Пути и ключи соответствуют полям UpdateSongRequest.
+// @Description Проверяются только поля, измененные патчем. Формат ответа выбирается по заголовку Accept, как в GET
+// @Tags songs
+// @Accept json
+// @Accept application/merge-patch+json
+// @Accept application/json-patch+json
+// @Produce json
+// @Produce xml
+// @Produce text/csv
+// @Produce application/yaml
+// @Produce plain
+// @Param id path string true "ID песни"
+// @Param If-Match header string false "ETag песни"
+// @Param patch body models.UpdateSongRequest true "Патч песни"
+// @Success 200 {object} models.Song "Обновленная песня"
+// @Failure 400 {object} models.ErrorResponse "Неверный формат патча"
+// @Failure 404 {object} models.ErrorResponse "Песня не найдена"
+// @Failure 409 {object} models.ErrorResponse "Не выполнена операция test или песня с такой группой и названием уже существует"
+// @Failure 412 {object} models.ErrorResponse "Песня была изменена"
+// @Failure 406 {object} models.ErrorResponse "Нет формата из заголовка Accept"
+// @Failure 415 {object} models.ErrorResponse "Неподдерживаемый Content-Type"
+// @Failure 422 {object} models.ErrorResponse "Патч не применим или ошибки валидации полей"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Router /songs/{id} [patch]
+func PatchSongHandler(repo repository.SongRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Log.Debug("PatchSongHandler: Start processing request")
+
+		id, err := parseSongID(r)
+		if err != nil {
+			logger.Log.Error("PatchSongHandler: Invalid song ID")
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidID, "Invalid song ID")
+			return
+		}
+
+		enc, ok := negotiate(w, r, "PatchSongHandler", (*models.Song)(nil))
+		if !ok {
+			return
+		}
+
+		var applyPatch func(doc, patch []byte) ([]byte, error)
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		switch mediaType {
+		case jsonpatch.MergePatchContentType, "application/json", "":
+			applyPatch = jsonpatch.MergePatch
+		case jsonpatch.JSONPatchContentType:
+			applyPatch = jsonpatch.ApplyPatch
+		default:
+			logger.Log.Errorf("PatchSongHandler: Unsupported content type %q", mediaType)
+			w.Header().Set("Accept-Patch", jsonpatch.MergePatchContentType+", "+jsonpatch.JSONPatchContentType)
+			writeError(w, r, http.StatusUnsupportedMediaType, models.ErrCodeUnsupportedMedia, "Unsupported patch content type")
+			return
+		}
+
+		logger.Log.Debugf("PatchSongHandler: Song ID received: %d, patch type: %s", id, mediaType)
+
+		song, err := repo.Get(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				logger.Log.Error("PatchSongHandler: Song not found")
+				writeError(w, r, http.StatusNotFound, models.ErrCodeSongNotFound, "Song not found")
+			} else {
+				logger.Log.Errorf("PatchSongHandler: Failed to find song: %v", err)
+				writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to find song")
+			}
+			return
+		}
+		if !ifMatch(r, songETag(song)) {
+			logger.Log.Error("PatchSongHandler: If-Match precondition failed")
+			writeError(w, r, http.StatusPreconditionFailed, models.ErrCodePreconditionFailed, "Song has been modified")
+			return
+		}
+
+		patch, err := io.ReadAll(r.Body)
+		if err != nil {
+			logger.Log.Errorf("PatchSongHandler: Failed to read body: %v", err)
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidPatch, "Failed to read request body")
+			return
+		}
+
+		current := models.NewUpdateSongRequest(song)
+		doc, err := json.Marshal(current)
+		if err != nil {
+			logger.Log.Errorf("PatchSongHandler: Failed to encode song: %v", err)
+			writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to encode song")
+			return
+		}
+
+		patched, err := applyPatch(doc, patch)
+		if err != nil {
+			logger.Log.Errorf("PatchSongHandler: Failed to apply patch: %v", err)
+			switch {
+			case errors.Is(err, jsonpatch.ErrTestFailed):
+				writeError(w, r, http.StatusConflict, models.ErrCodePatchTestFailed, err.Error())
+			case errors.Is(err, jsonpatch.ErrPathNotFound):
+				writeError(w, r, http.StatusUnprocessableEntity, models.ErrCodeInvalidPatch, err.Error())
+			default:
+				writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidPatch, err.Error())
+			}
+			return
+		}
+
+		input, err := decodePatchedSong(patched)
+		if err != nil {
+			logger.Log.Errorf("PatchSongHandler: Patched document is invalid: %v", err)
+			writeError(w, r, http.StatusUnprocessableEntity, models.ErrCodeInvalidPatch, err.Error())
+			return
+		}
+		// Проверяются только измененные патчем поля: сохраненные значения, которые
+		// не проходят текущие правила, не мешают исправить другие поля
+		dropUnchanged(&input, current)
+		if fieldErrors := validation.Validate(&input); len(fieldErrors) > 0 {
+			logger.Log.Errorf("PatchSongHandler: Validation failed: %v", fieldErrors)
+			writeValidationError(w, r, fieldErrors)
+			return
+		}
+
+		input.Apply(song)
+
+		if err := repo.Update(r.Context(), song); err != nil {
+			if errors.Is(err, repository.ErrConflict) {
+				logger.Log.Error("PatchSongHandler: Song has been modified concurrently")
+				writeError(w, r, http.StatusPreconditionFailed, models.ErrCodePreconditionFailed, "Song has been modified")
+			} else if errors.Is(err, repository.ErrDuplicate) {
+				logger.Log.Error("PatchSongHandler: Song with the same group and title already exists")
+				writeDuplicate(w, r, repo, song.Group, song.Title)
+			} else {
+				logger.Log.Errorf("PatchSongHandler: Failed to update song: %v", err)
+				writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to update song")
+			}
+			return
+		}
+		w.Header().Set("ETag", representationETag(songETag(song), enc))
+
+		if !writeEncoded(w, r, "PatchSongHandler", enc, song) {
+			return
+		}
+
+		logger.Log.Info("PatchSongHandler: Song patched successfully")
+	}
+}
+
+// decodePatchedSong разбирает документ после применения патча. Поля, удаленные
+// патчем или установленные в null, очищаются; неизвестные поля запрещены.
+func decodePatchedSong(doc []byte) (models.UpdateSongRequest, error) {
+	var input models.UpdateSongRequest
+	decoder := json.NewDecoder(bytes.NewReader(doc))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&input); err != nil {
+		return input, err
+	}
+
+	empty := ""
+	for _, field := range []**string{&input.Group, &input.Title, &input.Artist, &input.ReleaseDate, &input.Text, &input.Link} {
+		if *field == nil {
+			*field = &empty
+		}
+	}
+	return input, nil
+}
+
+// dropUnchanged сбрасывает в nil поля input, значения которых совпадают с before
+func dropUnchanged(input *models.UpdateSongRequest, before models.UpdateSongRequest) {
+	fields := []struct{ after, before **string }{
+		{&input.Group, &before.Group},
+		{&input.Title, &before.Title},
+		{&input.Artist, &before.Artist},
+		{&input.ReleaseDate, &before.ReleaseDate},
+		{&input.Text, &before.Text},
+		{&input.Link, &before.Link},
+	}
+	for _, field := range fields {
+		if *field.after != nil && *field.before != nil && **field.after == **field.before {
+			*field.after = nil
+		}
+	}
+}
+
 // AddSongHandler godoc
 // @Summary Добавить песню
-// @Description Добавляет песню в базу данных, получая информацию о песне из внешнего API
+// @Description Добавляет песню в базу данных, получая информацию о песне из внешнего API.
+// @Description С параметром async=true или заголовком "Prefer: respond-async" песня сохраняется в статусе pending,
+// @Description а данные внешнего API заполняются в фоне; статус доступен по GET /api/jobs/{id}.
+// @Description С заголовком Idempotency-Key первый ответ сохраняется и возвращается на повторы с тем же ключом.
+// @Description Если песня с той же группой и названием (без учета регистра, пробелов и ё/е) уже есть,
+// @Description возвращается 409 со ссылкой на нее в заголовке Location, а с on_conflict=update она обновляется.
+// @Description Песня, которую не удалось добавить в фоне (статус failed), добавляется заново без on_conflict, как новая.
+// @Description С album_id песня добавляется в конец альбома
 // @Tags songs
 // @Accept json
 // @Produce json
 // @Param song body models.AddSongRequest true "Данные для добавления песни"
+// @Param async query bool false "Асинхронное добавление"
+// @Param Idempotency-Key header string false "Ключ идемпотентности запроса"
+// @Param on_conflict query string false "Поведение, если песня уже существует" Enums(error, update) default(error)
+// @Success 200 {string} string "Существующая песня обновлена (on_conflict=update)"
 // @Success 201 {string} string "Песня успешно добавлена"
+// @Success 202 {object} models.JobAcceptedResponse "Задача на добавление принята"
 // @Failure 400 {object} models.ErrorResponse "Неверный формат данных"
+// @Failure 404 {object} models.ErrorResponse "Песня не найдена во внешнем API"
+// @Failure 409 {object} models.ErrorResponse "Песня уже существует или запрос с этим ключом идемпотентности еще выполняется"
+// @Failure 422 {object} models.ErrorResponse "Ошибки валидации полей или ключ идемпотентности использован с другим запросом"
 // @Failure 500 {object} models.ErrorResponse "Ошибка при обработке запроса"
+// @Failure 502 {object} models.ErrorResponse "Некорректный ответ внешнего API"
+// @Failure 503 {object} models.ErrorResponse "Внешний API недоступен или очередь фоновых задач заполнена"
 // @Router /songs [post]
-func AddSongHandler(db *gorm.DB) http.HandlerFunc {
+func AddSongHandler(repo repository.SongRepository, albums repository.AlbumRepository, songInfo enricher.Enricher, ingestor *ingest.Ingestor) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		logger.Log.Infof("Received request to add song from %s", r.RemoteAddr)
 
-		type Input struct {
-			Group string `json:"group"`
-			Song  string `json:"song"`
-		}
-
-		var input Input
+		var input models.AddSongRequest
 		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
 			logger.Log.Errorf("Invalid input: %v", err)
-			http.Error(w, "Invalid input", http.StatusBadRequest)
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidJSON, "Invalid input")
+			return
+		}
+		if fieldErrors := validation.Validate(&input); len(fieldErrors) > 0 {
+			logger.Log.Errorf("Validation failed: %v", fieldErrors)
+			writeValidationError(w, r, fieldErrors)
 			return
 		}
 		logger.Log.Infof("Parsed input: group=%s, song=%s", input.Group, input.Song)
 
-		baseURL := os.Getenv("EXTERNAL_API_BASE_URL")
-		if baseURL == "" {
-			logger.Log.Error("External API base URL not configured")
-			http.Error(w, "External API base URL not configured", http.StatusInternalServerError)
+		onConflict := r.URL.Query().Get("on_conflict")
+		if onConflict != "" && onConflict != onConflictError && onConflict != onConflictUpdate {
+			logger.Log.Errorf("Invalid on_conflict: %s", onConflict)
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidParameter, "Invalid on_conflict",
+				models.FieldError{Field: "on_conflict", Code: "invalid", Message: "must be error or update"})
 			return
 		}
-		url := fmt.Sprintf("%s/info?group=%s&song=%s", baseURL, input.Group, input.Song)
-		logger.Log.Infof("Making request to external API: %s", url)
 
-		resp, err := http.Get(url)
-		if err != nil {
-			logger.Log.Errorf("Failed to fetch song info: %v", err)
-			http.Error(w, "Failed to fetch song info", http.StatusInternalServerError)
+		existing, err := repo.FindByNaturalKey(r.Context(), input.Group, input.Song)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			logger.Log.Errorf("Failed to look up existing song: %v", err)
+			writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to save song")
 			return
 		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			logger.Log.Errorf("External API returned an error: %s", resp.Status)
-			http.Error(w, "External API returned an error", http.StatusInternalServerError)
+		// Песня, которую не удалось добавить в фоне, не считается конфликтом:
+		// повторный запрос добавляет ее заново
+		if existing != nil && onConflict != onConflictUpdate && existing.Status != models.SongStatusFailed {
+			logger.Log.Infof("Song already exists with ID %d", existing.ID)
+			writeSongExists(w, r, existing.ID)
 			return
 		}
 
-		var apiResponse struct {
-			Artist      string `json:"artist"`
-			ReleaseDate string `json:"releaseDate"`
-			Text        string `json:"text"`
-			Link        string `json:"link"`
+		if input.AlbumID != 0 {
+			if _, err := albums.GetAlbum(r.Context(), input.AlbumID); err != nil {
+				logger.Log.Errorf("Failed to find album %d: %v", input.AlbumID, err)
+				if errors.Is(err, repository.ErrNotFound) {
+					writeValidationError(w, r, []models.FieldError{{Field: "album_id", Code: "not_found", Message: "album does not exist"}})
+				} else {
+					writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to find album")
+				}
+				return
+			}
+		}
+
+		if ingestor != nil && wantsAsync(r) {
+			song := existing
+			if song == nil {
+				song = &models.Song{Group: input.Group, Title: input.Song}
+			}
+			job, err := ingestor.Submit(r.Context(), song)
+			if err != nil {
+				logger.Log.Errorf("Failed to submit song: %v", err)
+				if errors.Is(err, repository.ErrDuplicate) {
+					writeDuplicate(w, r, repo, input.Group, input.Song)
+					return
+				}
+				if errors.Is(err, ingest.ErrQueueFull) {
+					w.Header().Set("Retry-After", "5")
+					writeError(w, r, http.StatusServiceUnavailable, models.ErrCodeQueueFull, "Ingest queue is full, try again later")
+					return
+				}
+				writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to save song")
+				return
+			}
+
+			logger.Log.Infof("Song %d queued for enrichment, job %s", job.SongID, job.ID)
+			attachToAlbum(r, albums, input.AlbumID, job.SongID)
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Location", "/api/jobs/"+job.ID)
+			w.WriteHeader(http.StatusAccepted)
+			json.NewEncoder(w).Encode(models.JobAcceptedResponse{
+				JobID:  job.ID,
+				SongID: job.SongID,
+				Status: job.Status,
+			})
+			return
 		}
-		body, err := io.ReadAll(resp.Body)
+
+		info, err := songInfo.Enrich(r.Context(), input.Group, input.Song)
 		if err != nil {
-			logger.Log.Errorf("Failed to read API response: %v", err)
-			http.Error(w, "Failed to read API response", http.StatusInternalServerError)
+			logger.Log.Errorf("Failed to fetch song info: %v", err)
+			switch {
+			case errors.Is(err, enricher.ErrNotFound):
+				writeError(w, r, http.StatusNotFound, models.ErrCodeSongInfoNotFound, "Song info not found")
+			case errors.Is(err, enricher.ErrBadPayload):
+				writeError(w, r, http.StatusBadGateway, models.ErrCodeUpstreamBadPayload, "External API returned an invalid response")
+			case errors.Is(err, enricher.ErrUnavailable):
+				writeError(w, r, http.StatusServiceUnavailable, models.ErrCodeUpstreamUnavailable, "External API is unavailable")
+			default:
+				writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to fetch song info")
+			}
 			return
 		}
 
-		if err := json.Unmarshal(body, &apiResponse); err != nil {
-			logger.Log.Errorf("Invalid API response format: %v", err)
-			http.Error(w, "Invalid API response format", http.StatusInternalServerError)
+		if existing != nil {
+			// Песня, которую не удалось добавить в фоне, для клиента еще не создана:
+			// ее повторное добавление отвечает так же, как создание новой
+			readded := existing.Status == models.SongStatusFailed
+			info.Apply(existing)
+			existing.Status = models.SongStatusReady
+			if err := repo.Update(r.Context(), existing); err != nil {
+				logger.Log.Errorf("Failed to update existing song: %v", err)
+				if errors.Is(err, repository.ErrConflict) {
+					writeError(w, r, http.StatusPreconditionFailed, models.ErrCodePreconditionFailed, "Song has been modified")
+				} else {
+					writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to update song")
+				}
+				return
+			}
+
+			attachToAlbum(r, albums, input.AlbumID, existing.ID)
+
+			if readded {
+				logger.Log.Infof("Song added successfully: %s by %s", input.Song, input.Group)
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte("Song added successfully"))
+				return
+			}
+
+			logger.Log.Infof("Existing song %d updated: %s by %s", existing.ID, input.Song, input.Group)
+			w.Header().Set("Location", fmt.Sprintf("/api/songs/%d", existing.ID))
+			setChangedSongETag(w, r, existing)
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("Song updated successfully"))
 			return
 		}
 
 		newSong := models.Song{
-			Group:       input.Group,
-			Title:       input.Song,
-			Artist:      apiResponse.Artist,
-			ReleaseDate: apiResponse.ReleaseDate,
-			Text:        apiResponse.Text,
-			Link:        apiResponse.Link,
+			Group:  input.Group,
+			Title:  input.Song,
+			Status: models.SongStatusReady,
 		}
+		info.Apply(&newSong)
 
-		if err := db.Create(&newSong).Error; err != nil {
+		if err := repo.Create(r.Context(), &newSong); err != nil {
 			logger.Log.Errorf("Failed to save song to database: %v", err)
-			http.Error(w, "Failed to save song", http.StatusInternalServerError)
+			if errors.Is(err, repository.ErrDuplicate) {
+				writeDuplicate(w, r, repo, input.Group, input.Song)
+				return
+			}
+			writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to save song")
 			return
 		}
 
 		logger.Log.Infof("Song added successfully: %s by %s", input.Song, input.Group)
+		attachToAlbum(r, albums, input.AlbumID, newSong.ID)
 
 		w.WriteHeader(http.StatusCreated)
 		w.Write([]byte("Song added successfully"))
 	}
 }
+
+// attachToAlbum добавляет сохраненную песню в альбом. Песня уже сохранена,
+// поэтому ошибка только записывается в лог.
+func attachToAlbum(r *http.Request, albums repository.AlbumRepository, albumID, songID uint) {
+	if albumID == 0 {
+		return
+	}
+	if err := albums.AddTrack(r.Context(), albumID, songID); err != nil {
+		logger.Log.Errorf("Failed to add song %d to album %d: %v", songID, albumID, err)
+	}
+}
@@ -0,0 +1,747 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/w212w/GoProjectEM/internal/enricher"
+	"github.com/w212w/GoProjectEM/internal/jsonpatch"
+	"github.com/w212w/GoProjectEM/internal/logger"
+	"github.com/w212w/GoProjectEM/internal/models"
+	"github.com/w212w/GoProjectEM/internal/repository"
+)
+
+func TestMain(m *testing.M) {
+	logger.Log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// stubEnricher возвращает одни и те же метаданные для любой песни
+type stubEnricher struct {
+	info enricher.SongInfo
+	err  error
+}
+
+func (e stubEnricher) Enrich(ctx context.Context, group, song string) (*enricher.SongInfo, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	info := e.info
+	return &info, nil
+}
+
+// testSongs песни, с которыми запускается каждый тест, ID с 1 по порядку
+var testSongs = []models.Song{
+	{Group: "Muse", Title: "Supermassive Black Hole", Artist: "Matthew Bellamy", ReleaseDate: mustReleaseDate("16.07.2006"),
+		Text: "Ooh baby, don't you know I suffer?\n\nOoh you set my soul alight", Link: "https://example.com/muse/smbh"},
+	{Group: "Muse", Title: "Uprising", Artist: "Matthew Bellamy", ReleaseDate: mustReleaseDate("2009")},
+	{Group: "Queen", Title: "Bohemian Rhapsody", Artist: "Freddie Mercury", ReleaseDate: mustReleaseDate("1975-10-31")},
+}
+
+func mustReleaseDate(s string) models.ReleaseDate {
+	d, err := models.ParseReleaseDate(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// newTestServer собирает роутер песен, как в cmd/app, поверх хранилища в памяти
+func newTestServer(t *testing.T, songInfo enricher.Enricher, maxLimit int) (*httptest.Server, *repository.MemorySongRepository) {
+	t.Helper()
+
+	songs := repository.NewMemorySongRepository()
+	for _, s := range testSongs {
+		song := s
+		if err := songs.Create(context.Background(), &song); err != nil {
+			t.Fatalf("seed song %q: %v", s.Title, err)
+		}
+	}
+
+	idempotency := repository.NewMemoryIdempotencyRepository()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/songs", GetSongsHandler(songs, songs, maxLimit)).Methods("GET")
+	router.HandleFunc("/api/songs/{id}", GetSongHandler(songs)).Methods("GET")
+	router.HandleFunc("/api/songs/{id}/text", GetSongTextHandler(songs)).Methods("GET")
+	router.HandleFunc("/api/songs/{id}", DeleteSongHandler(songs)).Methods("DELETE")
+	router.HandleFunc("/api/songs/{id}", UpdateSongHandler(songs)).Methods("PUT")
+	router.HandleFunc("/api/songs/{id}", PatchSongHandler(songs)).Methods("PATCH")
+	router.HandleFunc("/api/songs", Idempotent(idempotency, time.Hour, AddSongHandler(songs, songs, songInfo, nil))).Methods("POST")
+	router.HandleFunc("/api/songs/{id}/revisions/{rev}/restore", RestoreSongRevisionHandler(songs, songs)).Methods("POST")
+	router.HandleFunc("/api/songs/{id}/restore", RestoreSongHandler(songs)).Methods("POST")
+	router.HandleFunc("/api/admin/duplicates/merge", MergeDuplicatesHandler(songs)).Methods("POST")
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server, songs
+}
+
+func doRequest(t *testing.T, server *httptest.Server, method, path, body string, header map[string]string) (*http.Response, []byte) {
+	t.Helper()
+
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(method, server.URL+path, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, data
+}
+
+func decodeProblem(t *testing.T, data []byte) models.ErrorResponse {
+	t.Helper()
+
+	var problem models.ErrorResponse
+	if err := json.Unmarshal(data, &problem); err != nil {
+		t.Fatalf("decode problem %s: %v", data, err)
+	}
+	return problem
+}
+
+func songTitles(songs []models.Song) []string {
+	titles := make([]string, len(songs))
+	for i, s := range songs {
+		titles[i] = s.Title
+	}
+	return titles
+}
+
+func TestGetSongsHandlerFilters(t *testing.T) {
+	server, _ := newTestServer(t, stubEnricher{}, 100)
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		titles []string
+		code   string
+	}{
+		{"all", "", http.StatusOK, []string{"Supermassive Black Hole", "Uprising", "Bohemian Rhapsody"}, ""},
+		{"group", "?group=muse", http.StatusOK, []string{"Supermassive Black Hole", "Uprising"}, ""},
+		{"title", "?title=RHAPSODY", http.StatusOK, []string{"Bohemian Rhapsody"}, ""},
+		{"artist", "?artist=bellamy", http.StatusOK, []string{"Supermassive Black Hole", "Uprising"}, ""},
+		{"artist and title", "?artist=bellamy&title=rising", http.StatusOK, []string{"Uprising"}, ""},
+		{"has link", "?has_link=true", http.StatusOK, []string{"Supermassive Black Hole"}, ""},
+		{"released range", "?released_from=2000&released_to=2006", http.StatusOK, []string{"Supermassive Black Hole"}, ""},
+		{"filter expression", "?filter=" + "release_date%3E%3D2006+and+not+title~hole", http.StatusOK, []string{"Uprising"}, ""},
+		{"sort", "?sort=-release_date", http.StatusOK, []string{"Uprising", "Supermassive Black Hole", "Bohemian Rhapsody"}, ""},
+		{"no match", "?artist=abba", http.StatusOK, []string{}, ""},
+		{"invalid released_from", "?released_from=someday", http.StatusBadRequest, nil, models.ErrCodeInvalidParameter},
+		{"invalid filter", "?filter=title%3D", http.StatusBadRequest, nil, models.ErrCodeInvalidParameter},
+		{"invalid has_link", "?has_link=maybe", http.StatusBadRequest, nil, models.ErrCodeInvalidParameter},
+		{"invalid sort", "?sort=text", http.StatusBadRequest, nil, models.ErrCodeInvalidParameter},
+		{"invalid cursor", "?cursor=%21%21", http.StatusBadRequest, nil, models.ErrCodeInvalidParameter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, data := doRequest(t, server, "GET", "/api/songs"+tt.query, "", nil)
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tt.status, data)
+			}
+			if tt.code != "" {
+				if problem := decodeProblem(t, data); problem.Code != tt.code {
+					t.Errorf("code = %q, want %q", problem.Code, tt.code)
+				}
+				return
+			}
+
+			var page models.SongPage
+			if err := json.Unmarshal(data, &page); err != nil {
+				t.Fatal(err)
+			}
+			if got := songTitles(page.Songs); strings.Join(got, "|") != strings.Join(tt.titles, "|") {
+				t.Errorf("titles = %q, want %q", got, tt.titles)
+			}
+			if page.Total != int64(len(tt.titles)) {
+				t.Errorf("total = %d, want %d", page.Total, len(tt.titles))
+			}
+			for _, s := range page.Songs {
+				if s.Text != "" {
+					t.Errorf("song %q has text in list without fields=text", s.Title)
+				}
+			}
+		})
+	}
+}
+
+func TestGetSongsHandlerFields(t *testing.T) {
+	server, _ := newTestServer(t, stubEnricher{}, 100)
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		keys   []string
+	}{
+		{"selected", "?fields=title,id&limit=1", http.StatusOK, []string{"id", "title"}},
+		{"text", "?fields=id,text&limit=1", http.StatusOK, []string{"id", "text"}},
+		{"unknown field", "?fields=id,lyrics", http.StatusBadRequest, nil},
+		{"empty", "?fields=,", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, data := doRequest(t, server, "GET", "/api/songs"+tt.query, "", nil)
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tt.status, data)
+			}
+			if tt.keys == nil {
+				return
+			}
+
+			var page struct {
+				Songs []map[string]json.RawMessage `json:"songs"`
+			}
+			if err := json.Unmarshal(data, &page); err != nil {
+				t.Fatal(err)
+			}
+			if len(page.Songs) != 1 {
+				t.Fatalf("songs = %d, want 1", len(page.Songs))
+			}
+			var keys []string
+			for key := range page.Songs[0] {
+				keys = append(keys, key)
+			}
+			slices.Sort(keys)
+			if !slices.Equal(keys, tt.keys) {
+				t.Errorf("keys = %q, want %q", keys, tt.keys)
+			}
+		})
+	}
+}
+
+func TestGetSongsHandlerPagination(t *testing.T) {
+	server, _ := newTestServer(t, stubEnricher{}, 2)
+
+	getPage := func(query string) models.SongPage {
+		t.Helper()
+		resp, data := doRequest(t, server, "GET", "/api/songs"+query, "", nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET %s: status = %d: %s", query, resp.StatusCode, data)
+		}
+		var page models.SongPage
+		if err := json.Unmarshal(data, &page); err != nil {
+			t.Fatal(err)
+		}
+		return page
+	}
+
+	// limit больше SONGS_MAX_LIMIT ограничивается им
+	first := getPage("?limit=50")
+	if first.Limit != 2 || len(first.Songs) != 2 {
+		t.Fatalf("first page: limit = %d, songs = %d, want 2 and 2", first.Limit, len(first.Songs))
+	}
+	if first.NextCursor == "" || first.PrevCursor != "" {
+		t.Fatalf("first page cursors: next = %q, prev = %q", first.NextCursor, first.PrevCursor)
+	}
+
+	second := getPage("?cursor=" + first.NextCursor)
+	if got := songTitles(second.Songs); len(got) != 1 || got[0] != "Bohemian Rhapsody" {
+		t.Fatalf("second page = %q, want [Bohemian Rhapsody]", got)
+	}
+	if second.NextCursor != "" || second.PrevCursor == "" {
+		t.Fatalf("second page cursors: next = %q, prev = %q", second.NextCursor, second.PrevCursor)
+	}
+
+	back := getPage("?cursor=" + second.PrevCursor)
+	if got, want := songTitles(back.Songs), songTitles(first.Songs); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("page before second = %q, want %q", got, want)
+	}
+
+	byOffset := getPage("?page=2&limit=2")
+	if got := songTitles(byOffset.Songs); len(got) != 1 || got[0] != "Bohemian Rhapsody" {
+		t.Errorf("page=2 = %q, want [Bohemian Rhapsody]", got)
+	}
+	// Неверные значения заменяются значениями по умолчанию
+	if got := getPage("?page=0&limit=abc"); len(got.Songs) != 2 || got.Total != 3 {
+		t.Errorf("defaults: songs = %d, total = %d, want 2 and 3", len(got.Songs), got.Total)
+	}
+
+	resp, data := doRequest(t, server, "GET", "/api/songs?sort=title&cursor="+first.NextCursor, "", nil)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("cursor of another sort: status = %d, want 400: %s", resp.StatusCode, data)
+	}
+	if link := resp.Header.Get("Link"); link != "" {
+		t.Errorf("Link on error = %q", link)
+	}
+	resp, _ = doRequest(t, server, "GET", "/api/songs?limit=1", "", nil)
+	if link := resp.Header.Get("Link"); !strings.Contains(link, `rel="first"`) || !strings.Contains(link, `rel="next"`) {
+		t.Errorf("Link = %q, want first and next", link)
+	}
+}
+
+func TestGetSongHandler(t *testing.T) {
+	server, _ := newTestServer(t, stubEnricher{}, 100)
+
+	tests := []struct {
+		name   string
+		path   string
+		header map[string]string
+		status int
+		etag   string
+		code   string
+	}{
+		{"json", "/api/songs/1", nil, http.StatusOK, `"1-json"`, ""},
+		{"csv", "/api/songs/1", map[string]string{"Accept": "text/csv"}, http.StatusOK, `"1-csv"`, ""},
+		{"not modified", "/api/songs/1", map[string]string{"If-None-Match": `"1-json"`}, http.StatusNotModified, `"1-json"`, ""},
+		{"weak etag", "/api/songs/1", map[string]string{"If-None-Match": `W/"1-json"`}, http.StatusNotModified, `"1-json"`, ""},
+		{"stale etag", "/api/songs/1", map[string]string{"If-None-Match": `"0-json"`}, http.StatusOK, `"1-json"`, ""},
+		{"etag of another format", "/api/songs/1", map[string]string{"If-None-Match": `"1-csv"`}, http.StatusOK, `"1-json"`, ""},
+		{"not acceptable", "/api/songs/1", map[string]string{"Accept": "image/png"}, http.StatusNotAcceptable, "", models.ErrCodeNotAcceptable},
+		{"not found", "/api/songs/42", nil, http.StatusNotFound, "", models.ErrCodeSongNotFound},
+		{"invalid id", "/api/songs/abc", nil, http.StatusBadRequest, "", models.ErrCodeInvalidID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, data := doRequest(t, server, "GET", tt.path, "", tt.header)
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tt.status, data)
+			}
+			if got := resp.Header.Get("ETag"); got != tt.etag {
+				t.Errorf("ETag = %s, want %s", got, tt.etag)
+			}
+			if tt.code != "" {
+				if problem := decodeProblem(t, data); problem.Code != tt.code {
+					t.Errorf("code = %q, want %q", problem.Code, tt.code)
+				}
+			}
+		})
+	}
+
+	resp, data := doRequest(t, server, "GET", "/api/songs/1", "", nil)
+	var song models.Song
+	if err := json.Unmarshal(data, &song); err != nil {
+		t.Fatal(err)
+	}
+	if song.Title != "Supermassive Black Hole" || song.ReleaseDate.String() != "2006-07-16" || song.Text == "" {
+		t.Errorf("song = %+v", song)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+}
+
+func TestGetSongTextHandler(t *testing.T) {
+	server, _ := newTestServer(t, stubEnricher{}, 100)
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		verses []string
+	}{
+		{"first page", "/api/songs/1/text?limit=1", http.StatusOK, []string{"Ooh baby, don't you know I suffer?"}},
+		{"second page", "/api/songs/1/text?limit=1&page=2", http.StatusOK, []string{"Ooh you set my soul alight"}},
+		{"page out of range", "/api/songs/1/text?limit=1&page=4", http.StatusBadRequest, nil},
+		{"unknown section", "/api/songs/1/text?section=solo", http.StatusBadRequest, nil},
+		{"not found", "/api/songs/42/text", http.StatusNotFound, nil},
+		{"invalid id", "/api/songs/abc/text", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, data := doRequest(t, server, "GET", tt.path, "", nil)
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tt.status, data)
+			}
+			if tt.verses == nil {
+				return
+			}
+
+			var text models.SongTextResponse
+			if err := json.Unmarshal(data, &text); err != nil {
+				t.Fatal(err)
+			}
+			if text.TotalVerses != 2 || strings.Join(text.Verses, "|") != strings.Join(tt.verses, "|") {
+				t.Errorf("text = %+v, want verses %q of 2", text, tt.verses)
+			}
+		})
+	}
+}
+
+func TestGetSongTextHandlerETag(t *testing.T) {
+	server, _ := newTestServer(t, stubEnricher{}, 100)
+
+	resp, data := doRequest(t, server, "GET", "/api/songs/1/text?limit=1", "", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d: %s", resp.StatusCode, data)
+	}
+	first := resp.Header.Get("ETag")
+
+	resp, _ = doRequest(t, server, "GET", "/api/songs/1/text?limit=1&page=2", "", map[string]string{"If-None-Match": first})
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("other page with ETag of the first: status = %d, want 200", resp.StatusCode)
+	}
+	if second := resp.Header.Get("ETag"); second == first {
+		t.Errorf("pages have the same ETag %s", first)
+	}
+
+	resp, _ = doRequest(t, server, "GET", "/api/songs/1/text?limit=1", "", map[string]string{"If-None-Match": first})
+	if resp.StatusCode != http.StatusNotModified {
+		t.Errorf("same page: status = %d, want 304", resp.StatusCode)
+	}
+
+	resp, data = doRequest(t, server, "GET", "/api/songs/1/text?section=solo", "", nil)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("unknown section: status = %d, want 400: %s", resp.StatusCode, data)
+	}
+}
+
+func TestAddSongHandler(t *testing.T) {
+	info := enricher.SongInfo{Artist: "Thom Yorke", ReleaseDate: "1997", Text: "Karma police", Link: "https://example.com/karma"}
+
+	tests := []struct {
+		name     string
+		songInfo enricher.Enricher
+		body     string
+		status   int
+		code     string
+		location string
+	}{
+		{"created", stubEnricher{info: info}, `{"group":"Radiohead","song":"Karma Police"}`, http.StatusCreated, "", ""},
+		{"duplicate", stubEnricher{info: info}, `{"group":"muse","song":"  Uprising "}`, http.StatusConflict, models.ErrCodeSongExists, "/api/songs/2"},
+		{"missing song", stubEnricher{info: info}, `{"group":"Radiohead"}`, http.StatusUnprocessableEntity, models.ErrCodeValidationFailed, ""},
+		{"invalid json", stubEnricher{info: info}, `{"group":`, http.StatusBadRequest, models.ErrCodeInvalidJSON, ""},
+		{"unknown album", stubEnricher{info: info}, `{"group":"Radiohead","song":"Karma Police","album_id":7}`, http.StatusUnprocessableEntity, models.ErrCodeValidationFailed, ""},
+		{"info not found", stubEnricher{err: enricher.ErrNotFound}, `{"group":"Radiohead","song":"Karma Police"}`, http.StatusNotFound, models.ErrCodeSongInfoNotFound, ""},
+		{"bad payload", stubEnricher{err: enricher.ErrBadPayload}, `{"group":"Radiohead","song":"Karma Police"}`, http.StatusBadGateway, models.ErrCodeUpstreamBadPayload, ""},
+		{"upstream unavailable", stubEnricher{err: enricher.ErrUnavailable}, `{"group":"Radiohead","song":"Karma Police"}`, http.StatusServiceUnavailable, models.ErrCodeUpstreamUnavailable, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, songs := newTestServer(t, tt.songInfo, 100)
+
+			resp, data := doRequest(t, server, "POST", "/api/songs", tt.body, map[string]string{"Content-Type": "application/json"})
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tt.status, data)
+			}
+			if got := resp.Header.Get("Location"); got != tt.location {
+				t.Errorf("Location = %q, want %q", got, tt.location)
+			}
+			if tt.code != "" {
+				if problem := decodeProblem(t, data); problem.Code != tt.code {
+					t.Errorf("code = %q, want %q", problem.Code, tt.code)
+				}
+				return
+			}
+
+			song, err := songs.FindByNaturalKey(context.Background(), "Radiohead", "Karma Police")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if song.Artist != info.Artist || song.ReleaseDate.String() != "1997" || song.Status != models.SongStatusReady {
+				t.Errorf("saved song = %+v", song)
+			}
+		})
+	}
+}
+
+func TestAddSongHandlerExisting(t *testing.T) {
+	info := enricher.SongInfo{Text: "Mama, just killed a man"}
+
+	tests := []struct {
+		name     string
+		status   string
+		query    string
+		want     int
+		body     string
+		location string
+		etag     string
+	}{
+		{"failed is added again", models.SongStatusFailed, "", http.StatusCreated, "Song added successfully", "", ""},
+		{"failed with on_conflict=update", models.SongStatusFailed, "?on_conflict=update", http.StatusCreated, "Song added successfully", "", ""},
+		// Смена статуса в тесте создает версию 2, ответ - версию 3
+		{"ready with on_conflict=update", models.SongStatusReady, "?on_conflict=update", http.StatusOK, "Song updated successfully", "/api/songs/3", `"3-json"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, songs := newTestServer(t, stubEnricher{info: info}, 100)
+
+			song, err := songs.Get(context.Background(), 3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			song.Status = tt.status
+			if err := songs.Update(context.Background(), song); err != nil {
+				t.Fatal(err)
+			}
+
+			resp, data := doRequest(t, server, "POST", "/api/songs"+tt.query, `{"group":"Queen","song":"Bohemian Rhapsody"}`, map[string]string{"Content-Type": "application/json"})
+			if resp.StatusCode != tt.want || string(data) != tt.body {
+				t.Fatalf("response = %d %q, want %d %q", resp.StatusCode, data, tt.want, tt.body)
+			}
+			if got := resp.Header.Get("Location"); got != tt.location {
+				t.Errorf("Location = %q, want %q", got, tt.location)
+			}
+			if got := resp.Header.Get("ETag"); got != tt.etag {
+				t.Errorf("ETag = %q, want %q", got, tt.etag)
+			}
+
+			song, err = songs.Get(context.Background(), 3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if song.Status != models.SongStatusReady || song.Text != info.Text {
+				t.Errorf("saved song = %+v", song)
+			}
+		})
+	}
+}
+
+func TestAddSongHandlerIdempotency(t *testing.T) {
+	server, songs := newTestServer(t, stubEnricher{info: enricher.SongInfo{Text: "Karma police"}}, 100)
+	header := map[string]string{"Content-Type": "application/json", idempotencyKeyHeader: "add-karma-police"}
+	body := `{"group":"Radiohead","song":"Karma Police"}`
+
+	resp, _ := doRequest(t, server, "POST", "/api/songs", body, header)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("first request: status = %d, want 201", resp.StatusCode)
+	}
+
+	resp, data := doRequest(t, server, "POST", "/api/songs", body, header)
+	if resp.StatusCode != http.StatusCreated || resp.Header.Get(idempotentReplayedHeader) != "true" {
+		t.Errorf("replay: status = %d, replayed = %q: %s", resp.StatusCode, resp.Header.Get(idempotentReplayedHeader), data)
+	}
+
+	resp, _ = doRequest(t, server, "POST", "/api/songs?async=true", body, header)
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("same key with other query: status = %d, want 422", resp.StatusCode)
+	}
+
+	count, err := songs.Count(context.Background(), repository.SongFilter{Title: "Karma Police"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("songs created = %d, want 1", count)
+	}
+}
+
+func TestUpdateSongHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		header map[string]string
+		status int
+		code   string
+		etag   string
+	}{
+		{"updated", "/api/songs/2", `{"title":"Uprising (Live)","release_date":"07.09.2009"}`, nil, http.StatusOK, "", `"2-json"`},
+		{"if-match", "/api/songs/2", `{"title":"Uprising (Live)","release_date":"07.09.2009"}`, map[string]string{"If-Match": `"1-json"`}, http.StatusOK, "", `"2-json"`},
+		{"if-match of any format", "/api/songs/2", `{"title":"Uprising (Live)","release_date":"07.09.2009"}`, map[string]string{"If-Match": `"1-csv"`}, http.StatusOK, "", `"2-json"`},
+		{"etag of accepted format", "/api/songs/2", `{"title":"Uprising (Live)","release_date":"07.09.2009"}`, map[string]string{"Accept": "text/csv"}, http.StatusOK, "", `"2-csv"`},
+		{"stale if-match", "/api/songs/2", `{"artist":"Muse"}`, map[string]string{"If-Match": `"5"`}, http.StatusPreconditionFailed, models.ErrCodePreconditionFailed, ""},
+		{"weak if-match", "/api/songs/2", `{"artist":"Muse"}`, map[string]string{"If-Match": `W/"1"`}, http.StatusPreconditionFailed, models.ErrCodePreconditionFailed, ""},
+		{"duplicate", "/api/songs/2", `{"title":"supermassive black hole"}`, nil, http.StatusConflict, models.ErrCodeSongExists, ""},
+		{"invalid release date", "/api/songs/2", `{"release_date":"someday"}`, nil, http.StatusUnprocessableEntity, models.ErrCodeValidationFailed, ""},
+		{"invalid link", "/api/songs/2", `{"link":"ftp://example.com"}`, nil, http.StatusUnprocessableEntity, models.ErrCodeValidationFailed, ""},
+		{"blank title", "/api/songs/2", `{"title":"  "}`, nil, http.StatusUnprocessableEntity, models.ErrCodeValidationFailed, ""},
+		{"invalid json", "/api/songs/2", `{"title":`, nil, http.StatusBadRequest, models.ErrCodeInvalidJSON, ""},
+		{"not found", "/api/songs/42", `{"title":"Madness"}`, nil, http.StatusNotFound, models.ErrCodeSongNotFound, ""},
+		{"invalid id", "/api/songs/0", `{"title":"Madness"}`, nil, http.StatusBadRequest, models.ErrCodeInvalidID, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, songs := newTestServer(t, stubEnricher{}, 100)
+
+			resp, data := doRequest(t, server, "PUT", tt.path, tt.body, tt.header)
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tt.status, data)
+			}
+			if got := resp.Header.Get("ETag"); got != tt.etag {
+				t.Errorf("ETag = %s, want %s", got, tt.etag)
+			}
+			if tt.code != "" {
+				if problem := decodeProblem(t, data); problem.Code != tt.code {
+					t.Errorf("code = %q, want %q", problem.Code, tt.code)
+				}
+				return
+			}
+
+			song, err := songs.Get(context.Background(), 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if song.Version != 2 || song.Title != "Uprising (Live)" || song.ReleaseDate.String() != "2009-09-07" {
+				t.Errorf("updated song = %+v", song)
+			}
+		})
+	}
+}
+
+func TestChangedSongETagMatchesGet(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		id     string
+	}{
+		{"revision restore", "POST", "/api/songs/2/revisions/1/restore", "", "2"},
+		{"trash restore", "POST", "/api/songs/1/restore", "", "1"},
+		{"merge", "POST", "/api/admin/duplicates/merge", `{"target_id":2,"source_ids":[3]}`, "2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := newTestServer(t, stubEnricher{}, 100)
+			if resp, data := doRequest(t, server, "PUT", "/api/songs/2", `{"artist":"Muse"}`, nil); resp.StatusCode != http.StatusOK {
+				t.Fatalf("update: status = %d: %s", resp.StatusCode, data)
+			}
+			if resp, data := doRequest(t, server, "DELETE", "/api/songs/1", "", nil); resp.StatusCode != http.StatusOK {
+				t.Fatalf("delete: status = %d: %s", resp.StatusCode, data)
+			}
+
+			resp, data := doRequest(t, server, tt.method, tt.path, tt.body, map[string]string{"Content-Type": "application/json"})
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", resp.StatusCode, data)
+			}
+			etag := resp.Header.Get("ETag")
+
+			// Ответ в JSON, поэтому его ETag должен совпадать с ETag GET без Accept
+			resp, data = doRequest(t, server, "GET", "/api/songs/"+tt.id, "", map[string]string{"If-None-Match": etag})
+			if resp.StatusCode != http.StatusNotModified {
+				t.Errorf("GET with If-None-Match %s: status = %d, want 304: %s", etag, resp.StatusCode, data)
+			}
+		})
+	}
+}
+
+func TestPatchSongHandler(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		accept      string
+		body        string
+		status      int
+		code        string
+		etag        string
+		title       string
+	}{
+		{"merge patch", jsonpatch.MergePatchContentType, "", `{"title":"Uprising (Live)"}`, http.StatusOK, "", `"2-json"`, "Uprising (Live)"},
+		{"json patch", jsonpatch.JSONPatchContentType, "", `[{"op":"test","path":"/title","value":"Uprising"},{"op":"replace","path":"/title","value":"Resistance"}]`, http.StatusOK, "", `"2-json"`, "Resistance"},
+		{"json patch null", jsonpatch.JSONPatchContentType, "", `[{"op":"replace","path":"/artist","value":null}]`, http.StatusOK, "", `"2-json"`, ""},
+		{"yaml response", jsonpatch.MergePatchContentType, "application/yaml", `{"title":"Uprising (Live)"}`, http.StatusOK, "", `"2-yaml"`, ""},
+		{"test failed", jsonpatch.JSONPatchContentType, "", `[{"op":"test","path":"/title","value":"Resistance"}]`, http.StatusConflict, models.ErrCodePatchTestFailed, "", ""},
+		{"path not found", jsonpatch.JSONPatchContentType, "", `[{"op":"remove","path":"/album/name"}]`, http.StatusUnprocessableEntity, models.ErrCodeInvalidPatch, "", ""},
+		{"unknown field", jsonpatch.MergePatchContentType, "", `{"album":"The Resistance"}`, http.StatusUnprocessableEntity, models.ErrCodeInvalidPatch, "", ""},
+		{"invalid value", jsonpatch.MergePatchContentType, "", `{"link":"not a url"}`, http.StatusUnprocessableEntity, models.ErrCodeValidationFailed, "", ""},
+		{"duplicate", jsonpatch.MergePatchContentType, "", `{"title":"Supermassive Black Hole"}`, http.StatusConflict, models.ErrCodeSongExists, "", ""},
+		{"unsupported type", "text/plain", "", `title=Resistance`, http.StatusUnsupportedMediaType, models.ErrCodeUnsupportedMedia, "", ""},
+		{"not acceptable", jsonpatch.MergePatchContentType, "image/png", `{"title":"Resistance"}`, http.StatusNotAcceptable, models.ErrCodeNotAcceptable, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := newTestServer(t, stubEnricher{}, 100)
+
+			header := map[string]string{"Content-Type": tt.contentType}
+			if tt.accept != "" {
+				header["Accept"] = tt.accept
+			}
+			resp, data := doRequest(t, server, "PATCH", "/api/songs/2", tt.body, header)
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tt.status, data)
+			}
+			if got := resp.Header.Get("ETag"); got != tt.etag {
+				t.Errorf("ETag = %s, want %s", got, tt.etag)
+			}
+			if tt.code != "" {
+				if problem := decodeProblem(t, data); problem.Code != tt.code {
+					t.Errorf("code = %q, want %q", problem.Code, tt.code)
+				}
+				return
+			}
+			if tt.title == "" {
+				return
+			}
+
+			var song models.Song
+			if err := json.Unmarshal(data, &song); err != nil {
+				t.Fatal(err)
+			}
+			if song.Title != tt.title || song.Artist != "Matthew Bellamy" || song.ReleaseDate.String() != "2009" {
+				t.Errorf("patched song = %+v", song)
+			}
+		})
+	}
+}
+
+func TestPatchSongHandlerSkipsUnchangedInvalidFields(t *testing.T) {
+	server, songs := newTestServer(t, stubEnricher{}, 100)
+
+	// Ссылка сохранена до появления правила url и сейчас не проходит проверку
+	song, err := songs.Get(context.Background(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	song.Link = "queen.com/bohemian-rhapsody"
+	if err := songs.Update(context.Background(), song); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, data := doRequest(t, server, "PATCH", "/api/songs/3", `{"artist":"Queen"}`,
+		map[string]string{"Content-Type": jsonpatch.MergePatchContentType})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", resp.StatusCode, data)
+	}
+
+	resp, _ = doRequest(t, server, "PATCH", "/api/songs/3", `{"link":"queen.com/news"}`,
+		map[string]string{"Content-Type": jsonpatch.MergePatchContentType})
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("changed invalid link: status = %d, want 422", resp.StatusCode)
+	}
+}
+
+func TestDeleteSongHandler(t *testing.T) {
+	server, _ := newTestServer(t, stubEnricher{}, 100)
+
+	resp, data := doRequest(t, server, "DELETE", "/api/songs/1", "", map[string]string{"If-Match": `"3"`})
+	if resp.StatusCode != http.StatusPreconditionFailed {
+		t.Fatalf("stale If-Match: status = %d, want 412: %s", resp.StatusCode, data)
+	}
+
+	resp, data = doRequest(t, server, "DELETE", "/api/songs/1", "", map[string]string{"If-Match": `"1"`})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", resp.StatusCode, data)
+	}
+
+	resp, _ = doRequest(t, server, "GET", "/api/songs/1", "", nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET deleted song: status = %d, want 404", resp.StatusCode)
+	}
+
+	resp, data = doRequest(t, server, "DELETE", "/api/songs/1", "", nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("delete again: status = %d, want 404", resp.StatusCode)
+	}
+	if problem := decodeProblem(t, data); problem.Code != models.ErrCodeSongNotFound {
+		t.Errorf("code = %q, want %q", problem.Code, models.ErrCodeSongNotFound)
+	}
+
+	resp, _ = doRequest(t, server, "DELETE", "/api/songs/0", "", nil)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("invalid id: status = %d, want 400", resp.StatusCode)
+	}
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/w212w/GoProjectEM/internal/logger"
+	"github.com/w212w/GoProjectEM/internal/models"
+	"github.com/w212w/GoProjectEM/internal/repository"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+// replayedHeaders заголовки ответа, которые сохраняются и возвращаются при повторе
+var replayedHeaders = []string{"Content-Type", "Location", "ETag"}
+
+// Idempotent сохраняет ответ на запрос с заголовком Idempotency-Key и возвращает его
+// на повторы с тем же ключом в течение ttl. Повтор с тем же ключом, но другими
+// параметрами или телом отклоняется с 422, а пока первый запрос выполняется - с 409.
+// Ответы 5xx и паники не сохраняются, чтобы запрос можно было повторить.
+func Idempotent(store repository.IdempotencyRepository, ttl time.Duration, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" {
+			next(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidParameter, "Invalid Idempotency-Key",
+				models.FieldError{Field: idempotencyKeyHeader, Code: "max", Message: "must be at most 255 characters"})
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			logger.Log.Errorf("Idempotent: Failed to read request body: %v", err)
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidJSON, "Failed to read request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		rec := &models.IdempotencyRecord{
+			Key:         key,
+			RequestHash: requestHash(r, body),
+			ExpiresAt:   time.Now().Add(ttl),
+		}
+		if err := store.Create(r.Context(), rec); err != nil {
+			if errors.Is(err, repository.ErrConflict) {
+				replayIdempotent(w, r, store, rec)
+			} else {
+				logger.Log.Errorf("Idempotent: Failed to reserve key: %v", err)
+				writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to process Idempotency-Key")
+			}
+			return
+		}
+
+		// Клиент мог отключиться, но ответ все равно нужно сохранить для повтора
+		ctx := context.WithoutCancel(r.Context())
+		defer func() {
+			// После паники ключ иначе остался бы занятым до истечения ttl
+			if p := recover(); p != nil {
+				releaseIdempotencyKey(ctx, store, key)
+				panic(p)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: w}
+		next(recorder, r)
+
+		if recorder.status >= http.StatusInternalServerError {
+			releaseIdempotencyKey(ctx, store, key)
+			return
+		}
+
+		rec.Status = recorder.status
+		rec.Body = recorder.body.Bytes()
+		rec.Header = make(map[string]string)
+		for _, name := range replayedHeaders {
+			if v := recorder.Header().Get(name); v != "" {
+				rec.Header[name] = v
+			}
+		}
+		if err := store.Complete(ctx, rec); err != nil {
+			logger.Log.Errorf("Idempotent: Failed to store response: %v", err)
+		}
+	}
+}
+
+func releaseIdempotencyKey(ctx context.Context, store repository.IdempotencyRepository, key string) {
+	if err := store.Delete(ctx, key); err != nil {
+		logger.Log.Errorf("Idempotent: Failed to release key: %v", err)
+	}
+}
+
+func replayIdempotent(w http.ResponseWriter, r *http.Request, store repository.IdempotencyRepository, rec *models.IdempotencyRecord) {
+	stored, err := store.Get(r.Context(), rec.Key)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			// Ключ истек или был освобожден между Create и Get
+			writeError(w, r, http.StatusConflict, models.ErrCodeIdempotencyInProgress, "Request with this Idempotency-Key is being processed, retry later")
+		} else {
+			logger.Log.Errorf("Idempotent: Failed to load stored response: %v", err)
+			writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to process Idempotency-Key")
+		}
+		return
+	}
+
+	if stored.RequestHash != rec.RequestHash {
+		logger.Log.Errorf("Idempotent: Key %s reused with a different request", rec.Key)
+		writeError(w, r, http.StatusUnprocessableEntity, models.ErrCodeIdempotencyKeyReused, "Idempotency-Key was already used with a different request")
+		return
+	}
+	if !stored.Completed {
+		writeError(w, r, http.StatusConflict, models.ErrCodeIdempotencyInProgress, "Request with this Idempotency-Key is being processed, retry later")
+		return
+	}
+
+	logger.Log.Infof("Idempotent: Replaying stored response for key %s", rec.Key)
+	for name, v := range stored.Header {
+		w.Header().Set(name, v)
+	}
+	w.Header().Set(idempotentReplayedHeader, "true")
+	w.WriteHeader(stored.Status)
+	w.Write(stored.Body)
+}
+
+// requestHash хеш всего, что влияет на результат запроса: метода, пути,
+// параметров запроса (без учета их порядка), заголовка Prefer и тела
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.Path+"\n")
+	io.WriteString(h, r.URL.Query().Encode()+"\n")
+	io.WriteString(h, r.Header.Get("Prefer")+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder передает ответ клиенту и одновременно запоминает его
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	if rr.status == 0 {
+		rr.status = status
+	}
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	if rr.status == 0 {
+		rr.status = http.StatusOK
+	}
+	rr.body.Write(b)
+	return rr.ResponseWriter.Write(b)
+}
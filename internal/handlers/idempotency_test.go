@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/w212w/GoProjectEM/internal/models"
+	"github.com/w212w/GoProjectEM/internal/repository"
+)
+
+// countingHandler отвечает статусом status и считает вызовы
+func countingHandler(calls *atomic.Int32, status int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		w.Header().Set("Location", "/api/songs/7")
+		w.Header().Set("X-Not-Replayed", "1")
+		w.WriteHeader(status)
+		w.Write([]byte(strings.Repeat("created ", int(n))))
+	}
+}
+
+func serveIdempotent(handler http.HandlerFunc, target, body, key string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	if key != "" {
+		r.Header.Set(idempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
+
+func TestIdempotent(t *testing.T) {
+	var calls atomic.Int32
+	handler := Idempotent(repository.NewMemoryIdempotencyRepository(), time.Hour, countingHandler(&calls, http.StatusCreated))
+
+	first := serveIdempotent(handler, "/api/songs?a=1&b=2", `{"song":"Uprising"}`, "key-1")
+	if first.Code != http.StatusCreated || first.Header().Get(idempotentReplayedHeader) != "" {
+		t.Fatalf("first: status = %d, replayed = %q", first.Code, first.Header().Get(idempotentReplayedHeader))
+	}
+
+	// Порядок параметров запроса не важен
+	replay := serveIdempotent(handler, "/api/songs?b=2&a=1", `{"song":"Uprising"}`, "key-1")
+	if replay.Code != http.StatusCreated || replay.Header().Get(idempotentReplayedHeader) != "true" {
+		t.Errorf("replay: status = %d, replayed = %q", replay.Code, replay.Header().Get(idempotentReplayedHeader))
+	}
+	if replay.Body.String() != first.Body.String() || replay.Header().Get("Location") != "/api/songs/7" {
+		t.Errorf("replay: body = %q, Location = %q", replay.Body, replay.Header().Get("Location"))
+	}
+	if replay.Header().Get("X-Not-Replayed") != "" {
+		t.Error("replay: header outside the list is replayed")
+	}
+
+	for name, tt := range map[string]struct{ target, body string }{
+		"other body":  {"/api/songs?a=1&b=2", `{"song":"Starlight"}`},
+		"other query": {"/api/songs?a=1", `{"song":"Uprising"}`},
+	} {
+		w := serveIdempotent(handler, tt.target, tt.body, "key-1")
+		if problem := decodeProblem(t, w.Body.Bytes()); w.Code != http.StatusUnprocessableEntity || problem.Code != models.ErrCodeIdempotencyKeyReused {
+			t.Errorf("%s: status = %d, code = %q, want 422 %s", name, w.Code, problem.Code, models.ErrCodeIdempotencyKeyReused)
+		}
+	}
+
+	serveIdempotent(handler, "/api/songs", `{}`, "")
+	serveIdempotent(handler, "/api/songs", `{}`, "")
+	if n := calls.Load(); n != 3 {
+		t.Errorf("handler called %d times, want 3: requests without key are not deduplicated", n)
+	}
+
+	w := serveIdempotent(handler, "/api/songs", `{}`, strings.Repeat("k", maxIdempotencyKeyLength+1))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("long key: status = %d, want 400", w.Code)
+	}
+}
+
+func TestIdempotentInProgress(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	handler := Idempotent(repository.NewMemoryIdempotencyRepository(), time.Hour, func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		serveIdempotent(handler, "/api/songs", `{}`, "key-1")
+	}()
+	<-started
+
+	w := serveIdempotent(handler, "/api/songs", `{}`, "key-1")
+	if problem := decodeProblem(t, w.Body.Bytes()); w.Code != http.StatusConflict || problem.Code != models.ErrCodeIdempotencyInProgress {
+		t.Errorf("status = %d, code = %q, want 409 %s", w.Code, problem.Code, models.ErrCodeIdempotencyInProgress)
+	}
+	close(release)
+	<-done
+}
+
+func TestIdempotentFailuresAreNotStored(t *testing.T) {
+	var calls atomic.Int32
+	store := repository.NewMemoryIdempotencyRepository()
+
+	failing := Idempotent(store, time.Hour, countingHandler(&calls, http.StatusServiceUnavailable))
+	if w := serveIdempotent(failing, "/api/songs", `{}`, "key-1"); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", w.Code)
+	}
+
+	panicking := Idempotent(store, time.Hour, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		panic("boom")
+	})
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("panic is not propagated")
+			}
+		}()
+		serveIdempotent(panicking, "/api/songs", `{}`, "key-1")
+	}()
+
+	ok := Idempotent(store, time.Hour, countingHandler(&calls, http.StatusCreated))
+	if w := serveIdempotent(ok, "/api/songs", `{}`, "key-1"); w.Code != http.StatusCreated || w.Header().Get(idempotentReplayedHeader) != "" {
+		t.Errorf("retry: status = %d, replayed = %q, want a new 201", w.Code, w.Header().Get(idempotentReplayedHeader))
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("handler called %d times, want 3", n)
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/w212w/GoProjectEM/internal/importer"
+	"github.com/w212w/GoProjectEM/internal/logger"
+	"github.com/w212w/GoProjectEM/internal/models"
+)
+
+// ImportSongsHandler godoc
+// @Summary Импортировать песни
+// @Description Массово добавляет песни из CSV (с заголовком: group, title, artist, release_date, text, link)
+// @Description или NDJSON (по объекту models.ImportSongRequest в строке). Формат задается параметром format
+// @Description или заголовком Content-Type: text/csv, application/x-ndjson. Данные читаются потоком
+// @Description и сохраняются пачками в транзакциях. Песни, которые уже есть в каталоге, пропускаются.
+// @Description С enrich=true пустые поля заполняются из внешнего API.
+// @Description Ответ содержит результат каждой строки: created, skipped или failed
+// @Tags songs
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Produce json
+// @Param format query string false "Формат входных данных" Enums(csv, ndjson)
+// @Param enrich query bool false "Заполнить пустые поля из внешнего API"
+// @Success 200 {object} models.ImportReport "Отчет об импорте"
+// @Failure 400 {object} models.ErrorResponse "Неверный заголовок CSV или входные данные не удалось дочитать"
+// @Failure 415 {object} models.ErrorResponse "Неподдерживаемый формат"
+// @Router /api/songs/import [post]
+func ImportSongsHandler(im *importer.Importer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Log.Debug("ImportSongsHandler: Start processing request")
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = importer.FormatFromContentType(r.Header.Get("Content-Type"))
+		}
+		if format != importer.FormatCSV && format != importer.FormatNDJSON {
+			logger.Log.Errorf("ImportSongsHandler: Unsupported format %q", format)
+			writeError(w, r, http.StatusUnsupportedMediaType, models.ErrCodeUnsupportedMedia,
+				"Unsupported import format, use text/csv or application/x-ndjson")
+			return
+		}
+
+		var opts importer.Options
+		if v := r.URL.Query().Get("enrich"); v != "" {
+			enrich, err := strconv.ParseBool(v)
+			if err != nil {
+				logger.Log.Errorf("ImportSongsHandler: Invalid enrich: %s", v)
+				writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidParameter, "Invalid enrich",
+					models.FieldError{Field: "enrich", Code: "invalid", Message: "must be a boolean"})
+				return
+			}
+			opts.Enrich = enrich
+		}
+
+		dec, err := importer.NewDecoder(r.Body, format)
+		if err != nil {
+			logger.Log.Errorf("ImportSongsHandler: Invalid input: %v", err)
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidParameter, err.Error())
+			return
+		}
+
+		report, err := im.Run(r.Context(), dec, opts)
+		if err != nil {
+			if r.Context().Err() != nil {
+				logger.Log.Warnf("ImportSongsHandler: Import cancelled after %d rows", len(report.Rows))
+				return
+			}
+			logger.Log.Errorf("ImportSongsHandler: Import stopped after %d rows: %v", len(report.Rows), err)
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidParameter,
+				fmt.Sprintf("Import stopped after %d rows: %v", len(report.Rows), err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			logger.Log.Error("ImportSongsHandler: Failed to encode response")
+			writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to encode response")
+			return
+		}
+
+		logger.Log.Infof("ImportSongsHandler: %d created, %d skipped, %d failed", report.Created, report.Skipped, report.Failed)
+	}
+}
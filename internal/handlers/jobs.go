@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/w212w/GoProjectEM/internal/logger"
+	"github.com/w212w/GoProjectEM/internal/models"
+	"github.com/w212w/GoProjectEM/internal/repository"
+)
+
+// GetJobHandler godoc
+// @Summary Получить статус задачи
+// @Description Получить статус асинхронного добавления песни: число попыток и последнюю ошибку
+// @Tags jobs
+// @Produce json
+// @Param id path string true "ID задачи"
+// @Success 200 {object} models.Job "Статус задачи"
+// @Failure 404 {object} models.ErrorResponse "Задача не найдена"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Router /api/jobs/{id} [get]
+func GetJobHandler(jobs repository.JobRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Log.Debug("GetJobHandler: Start processing request")
+
+		id := mux.Vars(r)["id"]
+		logger.Log.Debugf("GetJobHandler: Job ID received: %s", id)
+
+		job, err := jobs.Get(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				logger.Log.Error("GetJobHandler: Job not found")
+				writeError(w, r, http.StatusNotFound, models.ErrCodeJobNotFound, "Job not found")
+			} else {
+				logger.Log.Errorf("GetJobHandler: Failed to retrieve job: %v", err)
+				writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to retrieve job")
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(job); err != nil {
+			logger.Log.Error("GetJobHandler: Failed to encode response")
+			writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to encode response")
+			return
+		}
+
+		logger.Log.Info("GetJobHandler: Successfully responded with job status")
+	}
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/w212w/GoProjectEM/internal/logger"
+	"github.com/w212w/GoProjectEM/internal/models"
+	"gopkg.in/yaml.v2"
+)
+
+// tabular ответ, который можно представить таблицей CSV
+type tabular interface {
+	Table() (header []string, rows [][]string)
+}
+
+// plainTexter ответ, у которого есть представление простым текстом
+type plainTexter interface {
+	PlainText() string
+}
+
+// responseEncoder формат ответа. name - короткое имя формата для ETag, mediaTypes -
+// типы из Accept, которые ему соответствуют, первый из них используется в Content-Type.
+type responseEncoder struct {
+	name       string
+	mediaTypes []string
+	charset    bool
+	// supports сообщает, может ли формат представить ответ такого типа
+	supports func(v any) bool
+	encode   func(w io.Writer, v any) error
+}
+
+func (enc responseEncoder) contentType() string {
+	if enc.charset {
+		return enc.mediaTypes[0] + "; charset=utf-8"
+	}
+	return enc.mediaTypes[0]
+}
+
+// responseEncoders форматы ответов песенных эндпоинтов в порядке предпочтения
+// при равном качестве в Accept. Первый используется без заголовка Accept.
+var responseEncoders = []responseEncoder{
+	{"json", []string{"application/json"}, false, supportsAll, encodeJSON},
+	{"xml", []string{"application/xml", "text/xml"}, true, supportsAll, encodeXML},
+	{"csv", []string{"text/csv"}, true, isTabular, encodeCSV},
+	{"yaml", []string{"application/yaml", "application/x-yaml", "text/yaml"}, true, supportsAll, encodeYAML},
+	{"text", []string{"text/plain"}, true, isPlainText, encodePlainText},
+}
+
+func supportsAll(v any) bool { return true }
+
+func isTabular(v any) bool {
+	_, ok := v.(tabular)
+	return ok
+}
+
+func isPlainText(v any) bool {
+	_, ok := v.(plainTexter)
+	return ok
+}
+
+func encodeJSON(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+func encodeXML(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	if err := xml.NewEncoder(w).Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func encodeCSV(w io.Writer, v any) error {
+	header, rows := v.(tabular).Table()
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// encodeYAML переводит ответ в YAML через JSON, чтобы имена и порядок полей,
+// пропуск пустых полей и формат дат совпадали с JSON
+func encodeYAML(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var doc yaml.MapSlice
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	out, err := yaml.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+func encodePlainText(w io.Writer, v any) error {
+	text := v.(plainTexter).PlainText()
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	_, err := io.WriteString(w, text)
+	return err
+}
+
+// mediaRange диапазон типов из заголовка Accept
+type mediaRange struct {
+	mediaType string
+	q         float64
+}
+
+func parseAccept(header string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{mediaType: mediaType, q: q})
+	}
+	return ranges
+}
+
+// quality возвращает качество формата по самому точному подходящему диапазону
+// (RFC 9110, 12.5.1) или -1, если формат не подходит ни под один
+func (enc responseEncoder) quality(ranges []mediaRange) float64 {
+	q, specificity := -1.0, -1
+	for _, rng := range ranges {
+		for _, mediaType := range enc.mediaTypes {
+			s := -1
+			switch {
+			case rng.mediaType == mediaType:
+				s = 2
+			case rng.mediaType == "*/*":
+				s = 0
+			case strings.HasSuffix(rng.mediaType, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(rng.mediaType, "*")):
+				s = 1
+			}
+			if s > specificity {
+				q, specificity = rng.q, s
+			}
+		}
+	}
+	return q
+}
+
+// supportedEncoders форматы, которыми можно представить ответ v
+func supportedEncoders(v any) []responseEncoder {
+	var supported []responseEncoder
+	for _, enc := range responseEncoders {
+		if enc.supports(v) {
+			supported = append(supported, enc)
+		}
+	}
+	return supported
+}
+
+// preferredEncoder выбирает из supported формат по заголовку Accept, без заголовка
+// выбирается первый. Если ни один формат не подходит, возвращает false.
+func preferredEncoder(r *http.Request, supported []responseEncoder) (responseEncoder, bool) {
+	accept := r.Header.Get("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return supported[0], true
+	}
+	ranges := parseAccept(accept)
+	best, bestQ := -1, 0.0
+	for i, enc := range supported {
+		if q := enc.quality(ranges); q > bestQ {
+			best, bestQ = i, q
+		}
+	}
+	if best < 0 {
+		return responseEncoder{}, false
+	}
+	return supported[best], true
+}
+
+// negotiate выбирает формат ответа v по заголовку Accept. Если ни один формат
+// не подходит, отвечает 406 и возвращает false.
+func negotiate(w http.ResponseWriter, r *http.Request, handler string, v any) (responseEncoder, bool) {
+	w.Header().Add("Vary", "Accept")
+
+	supported := supportedEncoders(v)
+	if enc, ok := preferredEncoder(r, supported); ok {
+		return enc, true
+	}
+
+	available := make([]string, len(supported))
+	for i, enc := range supported {
+		available[i] = enc.mediaTypes[0]
+	}
+	logger.Log.Errorf("%s: Not acceptable: %s", handler, r.Header.Get("Accept"))
+	writeError(w, r, http.StatusNotAcceptable, models.ErrCodeNotAcceptable,
+		"None of the accepted media types is available, use one of: "+strings.Join(available, ", "))
+	return responseEncoder{}, false
+}
+
+// writeEncoded пишет ответ v в формате enc
+func writeEncoded(w http.ResponseWriter, r *http.Request, handler string, enc responseEncoder, v any) bool {
+	w.Header().Set("Content-Type", enc.contentType())
+	if err := enc.encode(w, v); err != nil {
+		logger.Log.Errorf("%s: Failed to encode response: %v", handler, err)
+		writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to encode response")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// pageURL возвращает адрес текущего запроса с курсором cursor вместо номера страницы.
+// Пустой cursor дает первую страницу.
+func pageURL(r *http.Request, cursor string) string {
+	query := r.URL.Query()
+	query.Del("page")
+	query.Del("cursor")
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+	u := *r.URL
+	u.RawQuery = query.Encode()
+	return u.RequestURI()
+}
+
+// setPageLinks записывает заголовок Link (RFC 8288) со ссылками на первую,
+// предыдущую и следующую страницы
+func setPageLinks(w http.ResponseWriter, r *http.Request, next, prev string) {
+	links := []string{fmt.Sprintf(`<%s>; rel="first"`, pageURL(r, ""))}
+	if prev != "" {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, pageURL(r, prev)))
+	}
+	if next != "" {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, pageURL(r, next)))
+	}
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
@@ -0,0 +1,545 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/w212w/GoProjectEM/internal/logger"
+	"github.com/w212w/GoProjectEM/internal/models"
+	"github.com/w212w/GoProjectEM/internal/playlists"
+	"github.com/w212w/GoProjectEM/internal/repository"
+	"github.com/w212w/GoProjectEM/internal/validation"
+)
+
+func parsePlaylistID(r *http.Request, name string) (uint, error) {
+	id, err := strconv.ParseUint(mux.Vars(r)[name], 10, 64)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, mux.Vars(r)[name])
+	}
+	return uint(id), nil
+}
+
+// loadPlaylist возвращает плейлист, видимый текущему пользователю. Если forWrite,
+// пользователь должен быть указан и быть владельцем. При ошибке ответ уже записан.
+func loadPlaylist(w http.ResponseWriter, r *http.Request, store repository.PlaylistRepository, handler string, forWrite bool) (*models.Playlist, bool) {
+	if forWrite {
+		if _, ok := requireActor(w, r, handler); !ok {
+			return nil, false
+		}
+	}
+
+	id, err := parsePlaylistID(r, "id")
+	if err != nil {
+		logger.Log.Errorf("%s: Invalid playlist ID", handler)
+		writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidID, "Invalid playlist ID")
+		return nil, false
+	}
+
+	playlist, err := store.GetPlaylist(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			logger.Log.Errorf("%s: Playlist not found", handler)
+			writeError(w, r, http.StatusNotFound, models.ErrCodePlaylistNotFound, "Playlist not found")
+		} else {
+			logger.Log.Errorf("%s: Failed to retrieve playlist: %v", handler, err)
+			writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to retrieve playlist")
+		}
+		return nil, false
+	}
+
+	// Чужой приватный плейлист не раскрывается даже фактом существования
+	viewer := repository.ChangeInfoFromContext(r.Context()).Actor
+	if !playlist.VisibleTo(viewer) {
+		logger.Log.Errorf("%s: Playlist %d is private", handler, id)
+		writeError(w, r, http.StatusNotFound, models.ErrCodePlaylistNotFound, "Playlist not found")
+		return nil, false
+	}
+	if forWrite && !playlist.OwnedBy(viewer) {
+		logger.Log.Errorf("%s: %q is not the owner of playlist %d", handler, viewer, id)
+		writeError(w, r, http.StatusForbidden, models.ErrCodeForbidden, "Only the owner can modify the playlist")
+		return nil, false
+	}
+	return playlist, true
+}
+
+// hasEntry сообщает, есть ли в плейлисте запись с ID id
+func hasEntry(playlist *models.Playlist, id uint) bool {
+	return slices.ContainsFunc(playlist.Entries, func(e models.PlaylistEntry) bool { return e.ID == id })
+}
+
+// checkEntryPosition проверяет соседние записи: задана только одна из них,
+// она есть в плейлисте и не совпадает с перемещаемой записью entryID
+func checkEntryPosition(playlist *models.Playlist, entryID uint, pos repository.EntryPosition) []models.FieldError {
+	if pos.BeforeID != 0 && pos.AfterID != 0 {
+		return []models.FieldError{{Field: "before_id", Code: "conflict", Message: "before_id and after_id are mutually exclusive"}}
+	}
+	field, anchor := "after_id", pos.AfterID
+	if pos.BeforeID != 0 {
+		field, anchor = "before_id", pos.BeforeID
+	}
+	switch {
+	case anchor == 0:
+		return nil
+	case anchor == entryID:
+		return []models.FieldError{{Field: field, Code: "invalid", Message: "entry cannot be placed relative to itself"}}
+	case !hasEntry(playlist, anchor):
+		return []models.FieldError{{Field: field, Code: "not_found", Message: fmt.Sprintf("entry %d is not in the playlist", anchor)}}
+	}
+	return nil
+}
+
+// writePlaylistAfterChange отвечает плейлистом, перечитанным после изменения
+func writePlaylistAfterChange(w http.ResponseWriter, r *http.Request, store repository.PlaylistRepository, handler string, status int, id uint) {
+	playlist, err := store.GetPlaylist(r.Context(), id)
+	if err != nil {
+		logger.Log.Errorf("%s: Failed to retrieve playlist: %v", handler, err)
+		writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to retrieve playlist")
+		return
+	}
+	writePlaylist(w, status, playlist)
+}
+
+func writePlaylist(w http.ResponseWriter, status int, playlist *models.Playlist) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(playlist); err != nil {
+		logger.Log.Errorf("Failed to encode playlist: %v", err)
+	}
+}
+
+// ListPlaylistsHandler godoc
+// @Summary Получить список плейлистов
+// @Description Получить публичные плейлисты и плейлисты текущего пользователя (заголовок X-User) без записей
+// @Tags playlists
+// @Produce json
+// @Param X-User header string false "Пользователь"
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество результатов на странице" default(10)
+// @Success 200 {array} models.Playlist "Список плейлистов"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Router /api/playlists [get]
+func ListPlaylistsHandler(store repository.PlaylistRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Log.Debug("ListPlaylistsHandler: Start processing request")
+
+		offset, limit := parsePage(r, 10)
+		viewer := repository.ChangeInfoFromContext(r.Context()).Actor
+		list, err := store.ListPlaylists(r.Context(), viewer, offset, limit)
+		if err != nil {
+			logger.Log.Errorf("ListPlaylistsHandler: Failed to retrieve playlists: %v", err)
+			writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to retrieve playlists")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(list); err != nil {
+			logger.Log.Error("ListPlaylistsHandler: Failed to encode response")
+			writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to encode response")
+			return
+		}
+
+		logger.Log.Info("ListPlaylistsHandler: Successfully responded with playlists")
+	}
+}
+
+// GetPlaylistHandler godoc
+// @Summary Получить плейлист
+// @Description Получить плейлист по его ID вместе с записями по порядку. Чужой приватный плейлист не найден
+// @Tags playlists
+// @Produce json
+// @Param X-User header string false "Пользователь"
+// @Param id path string true "ID плейлиста"
+// @Success 200 {object} models.Playlist "Плейлист"
+// @Failure 400 {object} models.ErrorResponse "Неверный ID плейлиста"
+// @Failure 404 {object} models.ErrorResponse "Плейлист не найден"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Router /api/playlists/{id} [get]
+func GetPlaylistHandler(store repository.PlaylistRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Log.Debug("GetPlaylistHandler: Start processing request")
+
+		playlist, ok := loadPlaylist(w, r, store, "GetPlaylistHandler", false)
+		if !ok {
+			return
+		}
+
+		writePlaylist(w, http.StatusOK, playlist)
+		logger.Log.Info("GetPlaylistHandler: Successfully responded with playlist")
+	}
+}
+
+// CreatePlaylistHandler godoc
+// @Summary Создать плейлист
+// @Description Создать пустой плейлист. Владельцем становится текущий пользователь (заголовок X-User)
+// @Tags playlists
+// @Accept json
+// @Produce json
+// @Param X-User header string true "Пользователь"
+// @Param playlist body models.PlaylistRequest true "Данные плейлиста"
+// @Success 201 {object} models.Playlist "Созданный плейлист"
+// @Failure 400 {object} models.ErrorResponse "Неверный формат JSON"
+// @Failure 401 {object} models.ErrorResponse "Не указан пользователь"
+// @Failure 422 {object} models.ErrorResponse "Ошибки валидации полей"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Router /api/playlists [post]
+func CreatePlaylistHandler(store repository.PlaylistRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Log.Debug("CreatePlaylistHandler: Start processing request")
+
+		owner, ok := requireActor(w, r, "CreatePlaylistHandler")
+		if !ok {
+			return
+		}
+
+		var input models.PlaylistRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			logger.Log.Error("CreatePlaylistHandler: Invalid JSON format")
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidJSON, "Invalid JSON format")
+			return
+		}
+		if fieldErrors := validation.Validate(&input); len(fieldErrors) > 0 {
+			logger.Log.Errorf("CreatePlaylistHandler: Validation failed: %v", fieldErrors)
+			writeValidationError(w, r, fieldErrors)
+			return
+		}
+
+		playlist := models.Playlist{
+			Name:       input.Name,
+			Owner:      owner,
+			Visibility: input.Visibility,
+			Entries:    []models.PlaylistEntry{},
+		}
+		if playlist.Visibility == "" {
+			playlist.Visibility = models.PlaylistPrivate
+		}
+
+		if err := store.CreatePlaylist(r.Context(), &playlist); err != nil {
+			logger.Log.Errorf("CreatePlaylistHandler: Failed to create playlist: %v", err)
+			writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to create playlist")
+			return
+		}
+
+		w.Header().Set("Location", fmt.Sprintf("/api/playlists/%d", playlist.ID))
+		writePlaylist(w, http.StatusCreated, &playlist)
+		logger.Log.Infof("CreatePlaylistHandler: Playlist %d created by %q", playlist.ID, playlist.Owner)
+	}
+}
+
+// UpdatePlaylistHandler godoc
+// @Summary Изменить плейлист
+// @Description Изменить название и видимость плейлиста. Доступно только владельцу
+// @Tags playlists
+// @Accept json
+// @Produce json
+// @Param X-User header string true "Пользователь"
+// @Param id path string true "ID плейлиста"
+// @Param playlist body models.PlaylistRequest true "Данные плейлиста"
+// @Success 200 {object} models.Playlist "Обновленный плейлист"
+// @Failure 400 {object} models.ErrorResponse "Неверный формат JSON"
+// @Failure 401 {object} models.ErrorResponse "Не указан пользователь"
+// @Failure 403 {object} models.ErrorResponse "Плейлист принадлежит другому пользователю"
+// @Failure 404 {object} models.ErrorResponse "Плейлист не найден"
+// @Failure 422 {object} models.ErrorResponse "Ошибки валидации полей"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Router /api/playlists/{id} [put]
+func UpdatePlaylistHandler(store repository.PlaylistRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Log.Debug("UpdatePlaylistHandler: Start processing request")
+
+		playlist, ok := loadPlaylist(w, r, store, "UpdatePlaylistHandler", true)
+		if !ok {
+			return
+		}
+
+		var input models.PlaylistRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			logger.Log.Error("UpdatePlaylistHandler: Invalid JSON format")
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidJSON, "Invalid JSON format")
+			return
+		}
+		if fieldErrors := validation.Validate(&input); len(fieldErrors) > 0 {
+			logger.Log.Errorf("UpdatePlaylistHandler: Validation failed: %v", fieldErrors)
+			writeValidationError(w, r, fieldErrors)
+			return
+		}
+
+		playlist.Name = input.Name
+		if input.Visibility != "" {
+			playlist.Visibility = input.Visibility
+		}
+
+		if err := store.UpdatePlaylist(r.Context(), playlist); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				logger.Log.Error("UpdatePlaylistHandler: Playlist not found")
+				writeError(w, r, http.StatusNotFound, models.ErrCodePlaylistNotFound, "Playlist not found")
+			} else {
+				logger.Log.Errorf("UpdatePlaylistHandler: Failed to update playlist: %v", err)
+				writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to update playlist")
+			}
+			return
+		}
+
+		writePlaylist(w, http.StatusOK, playlist)
+		logger.Log.Infof("UpdatePlaylistHandler: Playlist %d updated", playlist.ID)
+	}
+}
+
+// DeletePlaylistHandler godoc
+// @Summary Удалить плейлист
+// @Description Удалить плейлист вместе с записями. Песни не удаляются. Доступно только владельцу
+// @Tags playlists
+// @Param X-User header string true "Пользователь"
+// @Param id path string true "ID плейлиста"
+// @Success 204 "Плейлист удален"
+// @Failure 400 {object} models.ErrorResponse "Неверный ID плейлиста"
+// @Failure 401 {object} models.ErrorResponse "Не указан пользователь"
+// @Failure 403 {object} models.ErrorResponse "Плейлист принадлежит другому пользователю"
+// @Failure 404 {object} models.ErrorResponse "Плейлист не найден"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Router /api/playlists/{id} [delete]
+func DeletePlaylistHandler(store repository.PlaylistRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Log.Debug("DeletePlaylistHandler: Start processing request")
+
+		playlist, ok := loadPlaylist(w, r, store, "DeletePlaylistHandler", true)
+		if !ok {
+			return
+		}
+
+		if err := store.DeletePlaylist(r.Context(), playlist.ID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+			logger.Log.Errorf("DeletePlaylistHandler: Failed to delete playlist: %v", err)
+			writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to delete playlist")
+			return
+		}
+
+		logger.Log.Infof("DeletePlaylistHandler: Playlist %d deleted", playlist.ID)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// AddPlaylistEntryHandler godoc
+// @Summary Добавить песню в плейлист
+// @Description Вставить песню перед before_id или после after_id, без них - в конец. Остальные записи не переписываются. Доступно только владельцу
+// @Tags playlists
+// @Accept json
+// @Produce json
+// @Param X-User header string true "Пользователь"
+// @Param id path string true "ID плейлиста"
+// @Param entry body models.PlaylistEntryRequest true "Песня и место вставки"
+// @Success 201 {object} models.Playlist "Плейлист с новой записью"
+// @Failure 400 {object} models.ErrorResponse "Неверный формат JSON"
+// @Failure 401 {object} models.ErrorResponse "Не указан пользователь"
+// @Failure 403 {object} models.ErrorResponse "Плейлист принадлежит другому пользователю"
+// @Failure 404 {object} models.ErrorResponse "Плейлист не найден"
+// @Failure 422 {object} models.ErrorResponse "Песня или соседняя запись не найдена"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Router /api/playlists/{id}/entries [post]
+func AddPlaylistEntryHandler(store repository.PlaylistRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Log.Debug("AddPlaylistEntryHandler: Start processing request")
+
+		playlist, ok := loadPlaylist(w, r, store, "AddPlaylistEntryHandler", true)
+		if !ok {
+			return
+		}
+
+		var input models.PlaylistEntryRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			logger.Log.Error("AddPlaylistEntryHandler: Invalid JSON format")
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidJSON, "Invalid JSON format")
+			return
+		}
+		pos := repository.EntryPosition{BeforeID: input.BeforeID, AfterID: input.AfterID}
+		fieldErrors := checkEntryPosition(playlist, 0, pos)
+		if input.SongID == 0 {
+			fieldErrors = append(fieldErrors, models.FieldError{Field: "song_id", Code: "required", Message: "field is required"})
+		}
+		if len(fieldErrors) > 0 {
+			logger.Log.Errorf("AddPlaylistEntryHandler: Validation failed: %v", fieldErrors)
+			writeValidationError(w, r, fieldErrors)
+			return
+		}
+
+		entry := models.PlaylistEntry{PlaylistID: playlist.ID, SongID: input.SongID}
+		if err := store.InsertEntry(r.Context(), &entry, pos); err != nil {
+			switch {
+			case errors.Is(err, repository.ErrInvalidReference):
+				logger.Log.Errorf("AddPlaylistEntryHandler: Song %d not found", input.SongID)
+				writeValidationError(w, r, []models.FieldError{{Field: "song_id", Code: "not_found", Message: "song does not exist or is deleted"}})
+			case errors.Is(err, repository.ErrNotFound):
+				logger.Log.Error("AddPlaylistEntryHandler: Playlist or neighbour entry not found")
+				writeError(w, r, http.StatusNotFound, models.ErrCodeEntryNotFound, "Playlist or neighbour entry no longer exists")
+			default:
+				logger.Log.Errorf("AddPlaylistEntryHandler: Failed to add entry: %v", err)
+				writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to add entry")
+			}
+			return
+		}
+
+		writePlaylistAfterChange(w, r, store, "AddPlaylistEntryHandler", http.StatusCreated, playlist.ID)
+		logger.Log.Infof("AddPlaylistEntryHandler: Song %d added to playlist %d as entry %d", entry.SongID, playlist.ID, entry.ID)
+	}
+}
+
+// MovePlaylistEntryHandler godoc
+// @Summary Переместить запись плейлиста
+// @Description Поставить запись перед before_id или после after_id, без них - в конец. Остальные записи не переписываются. Доступно только владельцу
+// @Tags playlists
+// @Accept json
+// @Produce json
+// @Param X-User header string true "Пользователь"
+// @Param id path string true "ID плейлиста"
+// @Param entry_id path string true "ID записи"
+// @Param position body models.MoveEntryRequest true "Новое место записи"
+// @Success 200 {object} models.Playlist "Плейлист с новым порядком"
+// @Failure 400 {object} models.ErrorResponse "Неверный формат JSON"
+// @Failure 401 {object} models.ErrorResponse "Не указан пользователь"
+// @Failure 403 {object} models.ErrorResponse "Плейлист принадлежит другому пользователю"
+// @Failure 404 {object} models.ErrorResponse "Плейлист или запись не найдены"
+// @Failure 422 {object} models.ErrorResponse "Соседняя запись не найдена"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Router /api/playlists/{id}/entries/{entry_id}/move [post]
+func MovePlaylistEntryHandler(store repository.PlaylistRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Log.Debug("MovePlaylistEntryHandler: Start processing request")
+
+		playlist, ok := loadPlaylist(w, r, store, "MovePlaylistEntryHandler", true)
+		if !ok {
+			return
+		}
+		entryID, err := parsePlaylistID(r, "entry_id")
+		if err != nil {
+			logger.Log.Error("MovePlaylistEntryHandler: Invalid entry ID")
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidID, "Invalid entry ID")
+			return
+		}
+		if !hasEntry(playlist, entryID) {
+			logger.Log.Errorf("MovePlaylistEntryHandler: Entry %d not found", entryID)
+			writeError(w, r, http.StatusNotFound, models.ErrCodeEntryNotFound, "Entry not found")
+			return
+		}
+
+		var input models.MoveEntryRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			logger.Log.Error("MovePlaylistEntryHandler: Invalid JSON format")
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidJSON, "Invalid JSON format")
+			return
+		}
+		pos := repository.EntryPosition{BeforeID: input.BeforeID, AfterID: input.AfterID}
+		if fieldErrors := checkEntryPosition(playlist, entryID, pos); len(fieldErrors) > 0 {
+			logger.Log.Errorf("MovePlaylistEntryHandler: Validation failed: %v", fieldErrors)
+			writeValidationError(w, r, fieldErrors)
+			return
+		}
+
+		if err := store.MoveEntry(r.Context(), playlist.ID, entryID, pos); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				logger.Log.Error("MovePlaylistEntryHandler: Entry or neighbour entry not found")
+				writeError(w, r, http.StatusNotFound, models.ErrCodeEntryNotFound, "Entry or neighbour entry no longer exists")
+			} else {
+				logger.Log.Errorf("MovePlaylistEntryHandler: Failed to move entry: %v", err)
+				writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to move entry")
+			}
+			return
+		}
+
+		writePlaylistAfterChange(w, r, store, "MovePlaylistEntryHandler", http.StatusOK, playlist.ID)
+		logger.Log.Infof("MovePlaylistEntryHandler: Entry %d of playlist %d moved", entryID, playlist.ID)
+	}
+}
+
+// DeletePlaylistEntryHandler godoc
+// @Summary Удалить запись плейлиста
+// @Description Удалить запись из плейлиста. Песня не удаляется. Доступно только владельцу
+// @Tags playlists
+// @Param X-User header string true "Пользователь"
+// @Param id path string true "ID плейлиста"
+// @Param entry_id path string true "ID записи"
+// @Success 204 "Запись удалена"
+// @Failure 400 {object} models.ErrorResponse "Неверный ID"
+// @Failure 401 {object} models.ErrorResponse "Не указан пользователь"
+// @Failure 403 {object} models.ErrorResponse "Плейлист принадлежит другому пользователю"
+// @Failure 404 {object} models.ErrorResponse "Плейлист или запись не найдены"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Router /api/playlists/{id}/entries/{entry_id} [delete]
+func DeletePlaylistEntryHandler(store repository.PlaylistRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Log.Debug("DeletePlaylistEntryHandler: Start processing request")
+
+		playlist, ok := loadPlaylist(w, r, store, "DeletePlaylistEntryHandler", true)
+		if !ok {
+			return
+		}
+		entryID, err := parsePlaylistID(r, "entry_id")
+		if err != nil {
+			logger.Log.Error("DeletePlaylistEntryHandler: Invalid entry ID")
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidID, "Invalid entry ID")
+			return
+		}
+
+		if err := store.DeleteEntry(r.Context(), playlist.ID, entryID); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				logger.Log.Errorf("DeletePlaylistEntryHandler: Entry %d not found", entryID)
+				writeError(w, r, http.StatusNotFound, models.ErrCodeEntryNotFound, "Entry not found")
+			} else {
+				logger.Log.Errorf("DeletePlaylistEntryHandler: Failed to delete entry: %v", err)
+				writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to delete entry")
+			}
+			return
+		}
+
+		logger.Log.Infof("DeletePlaylistEntryHandler: Entry %d of playlist %d deleted", entryID, playlist.ID)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// ExportPlaylistHandler godoc
+// @Summary Выгрузить плейлист
+// @Description Выгрузить плейлист в M3U или XSPF со ссылками песен. Записи без ссылки пропускаются
+// @Tags playlists
+// @Produce audio/x-mpegurl
+// @Produce application/xspf+xml
+// @Param X-User header string false "Пользователь"
+// @Param id path string true "ID плейлиста"
+// @Param format query string false "Формат: m3u или xspf" default(m3u)
+// @Success 200 {string} string "Файл плейлиста"
+// @Failure 400 {object} models.ErrorResponse "Неверный ID или формат"
+// @Failure 404 {object} models.ErrorResponse "Плейлист не найден"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Router /api/playlists/{id}/export [get]
+func ExportPlaylistHandler(store repository.PlaylistRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Log.Debug("ExportPlaylistHandler: Start processing request")
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = playlists.FormatM3U
+		}
+		contentType, ext, ok := playlists.ContentType(format)
+		if !ok {
+			logger.Log.Errorf("ExportPlaylistHandler: Unknown format %q", format)
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidParameter, "Invalid format, expected m3u or xspf",
+				models.FieldError{Field: "format", Code: "invalid", Message: "must be one of: m3u, xspf"})
+			return
+		}
+
+		playlist, ok := loadPlaylist(w, r, store, "ExportPlaylistHandler", false)
+		if !ok {
+			return
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="playlist-%d.%s"`, playlist.ID, ext))
+		if err := playlists.Write(w, format, playlist); err != nil {
+			logger.Log.Errorf("ExportPlaylistHandler: Failed to write playlist: %v", err)
+			return
+		}
+
+		logger.Log.Infof("ExportPlaylistHandler: Playlist %d exported as %s", playlist.ID, format)
+	}
+}
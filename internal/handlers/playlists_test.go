@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/w212w/GoProjectEM/internal/models"
+	"github.com/w212w/GoProjectEM/internal/repository"
+)
+
+func newPlaylistServer(t *testing.T) (*httptest.Server, *repository.MemorySongRepository) {
+	t.Helper()
+
+	songs := repository.NewMemorySongRepository()
+	for _, s := range testSongs {
+		song := s
+		if err := songs.Create(context.Background(), &song); err != nil {
+			t.Fatalf("seed song %q: %v", s.Title, err)
+		}
+	}
+
+	router := mux.NewRouter()
+	router.Use(ActorMiddleware)
+	router.HandleFunc("/api/playlists", ListPlaylistsHandler(songs)).Methods("GET")
+	router.HandleFunc("/api/playlists", CreatePlaylistHandler(songs)).Methods("POST")
+	router.HandleFunc("/api/playlists/{id}", GetPlaylistHandler(songs)).Methods("GET")
+	router.HandleFunc("/api/playlists/{id}", UpdatePlaylistHandler(songs)).Methods("PUT")
+	router.HandleFunc("/api/playlists/{id}", DeletePlaylistHandler(songs)).Methods("DELETE")
+	router.HandleFunc("/api/playlists/{id}/entries", AddPlaylistEntryHandler(songs)).Methods("POST")
+	router.HandleFunc("/api/playlists/{id}/entries/{entry_id}/move", MovePlaylistEntryHandler(songs)).Methods("POST")
+	router.HandleFunc("/api/playlists/{id}/export", ExportPlaylistHandler(songs)).Methods("GET")
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server, songs
+}
+
+func asUser(user string) map[string]string {
+	header := map[string]string{"Content-Type": "application/json"}
+	if user != "" {
+		header[actorHeader] = user
+	}
+	return header
+}
+
+func decodePlaylist(t *testing.T, data []byte) models.Playlist {
+	t.Helper()
+
+	var playlist models.Playlist
+	if err := json.Unmarshal(data, &playlist); err != nil {
+		t.Fatalf("decode playlist %s: %v", data, err)
+	}
+	return playlist
+}
+
+func TestPlaylistAccess(t *testing.T) {
+	server, songs := newPlaylistServer(t)
+	ctx := context.Background()
+	for _, p := range []models.Playlist{
+		{Name: "Private", Owner: "alice", Visibility: models.PlaylistPrivate},
+		{Name: "Public", Owner: "alice", Visibility: models.PlaylistPublic},
+		{Name: "Ownerless", Visibility: models.PlaylistPrivate},
+	} {
+		if err := songs.CreatePlaylist(ctx, &p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		user   string
+		method string
+		path   string
+		body   string
+		status int
+		code   string
+	}{
+		{"owner reads private", "alice", "GET", "/api/playlists/1", "", http.StatusOK, ""},
+		{"other user reads private", "bob", "GET", "/api/playlists/1", "", http.StatusNotFound, models.ErrCodePlaylistNotFound},
+		{"anonymous reads private", "", "GET", "/api/playlists/1", "", http.StatusNotFound, models.ErrCodePlaylistNotFound},
+		{"anonymous reads public", "", "GET", "/api/playlists/2", "", http.StatusOK, ""},
+		{"anonymous reads ownerless", "", "GET", "/api/playlists/3", "", http.StatusNotFound, models.ErrCodePlaylistNotFound},
+		{"other user renames public", "bob", "PUT", "/api/playlists/2", `{"name":"Mine"}`, http.StatusForbidden, models.ErrCodeForbidden},
+		{"anonymous renames public", "", "PUT", "/api/playlists/2", `{"name":"Mine"}`, http.StatusUnauthorized, models.ErrCodeUnauthorized},
+		{"anonymous renames ownerless", "", "PUT", "/api/playlists/3", `{"name":"Mine"}`, http.StatusUnauthorized, models.ErrCodeUnauthorized},
+		{"anonymous adds entry", "", "POST", "/api/playlists/2/entries", `{"song_id":1}`, http.StatusUnauthorized, models.ErrCodeUnauthorized},
+		{"anonymous deletes", "", "DELETE", "/api/playlists/2", "", http.StatusUnauthorized, models.ErrCodeUnauthorized},
+		{"anonymous creates", "", "POST", "/api/playlists", `{"name":"Mine"}`, http.StatusUnauthorized, models.ErrCodeUnauthorized},
+		{"invalid visibility", "bob", "POST", "/api/playlists", `{"name":"Mine","visibility":"friends"}`, http.StatusUnprocessableEntity, models.ErrCodeValidationFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, data := doRequest(t, server, tt.method, tt.path, tt.body, asUser(tt.user))
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tt.status, data)
+			}
+			if tt.code != "" {
+				if problem := decodeProblem(t, data); problem.Code != tt.code {
+					t.Errorf("code = %q, want %q", problem.Code, tt.code)
+				}
+			}
+		})
+	}
+
+	resp, data := doRequest(t, server, "GET", "/api/playlists", "", asUser(""))
+	var list []models.Playlist
+	if err := json.Unmarshal(data, &list); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("list: status = %d: %s", resp.StatusCode, data)
+	}
+	if len(list) != 1 || list[0].Name != "Public" {
+		t.Errorf("anonymous list = %+v, want only the public playlist", list)
+	}
+}
+
+func TestPlaylistEntriesAndExport(t *testing.T) {
+	server, _ := newPlaylistServer(t)
+	alice := asUser("alice")
+
+	resp, data := doRequest(t, server, "POST", "/api/playlists", `{"name":"Road trip"}`, alice)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create: status = %d: %s", resp.StatusCode, data)
+	}
+	if playlist := decodePlaylist(t, data); playlist.Owner != "alice" || playlist.Visibility != models.PlaylistPrivate {
+		t.Errorf("created playlist = %+v, want private playlist of alice", playlist)
+	}
+
+	var playlist models.Playlist
+	for _, body := range []string{`{"song_id":1}`, `{"song_id":3}`, `{"song_id":2,"before_id":1}`} {
+		resp, data := doRequest(t, server, "POST", "/api/playlists/1/entries", body, alice)
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("add %s: status = %d: %s", body, resp.StatusCode, data)
+		}
+		playlist = decodePlaylist(t, data)
+	}
+	// Записи 1, 2, 3 - песни 1, 3, 2; запись 3 вставлена перед записью 1
+	resp, data = doRequest(t, server, "POST", "/api/playlists/1/entries/2/move", `{"after_id":3}`, alice)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("move: status = %d: %s", resp.StatusCode, data)
+	}
+	playlist = decodePlaylist(t, data)
+
+	var songIDs []uint
+	for _, entry := range playlist.Entries {
+		songIDs = append(songIDs, entry.SongID)
+	}
+	if want := []uint{2, 3, 1}; !reflect.DeepEqual(songIDs, want) {
+		t.Errorf("songs = %v, want %v", songIDs, want)
+	}
+
+	for _, tt := range []struct{ body, field string }{
+		{`{"song_id":42}`, "song_id"},
+		{`{"song_id":1,"before_id":1,"after_id":2}`, "before_id"},
+		{`{"song_id":1,"after_id":42}`, "after_id"},
+	} {
+		resp, data := doRequest(t, server, "POST", "/api/playlists/1/entries", tt.body, alice)
+		if resp.StatusCode != http.StatusUnprocessableEntity {
+			t.Errorf("add %s: status = %d, want 422: %s", tt.body, resp.StatusCode, data)
+			continue
+		}
+		if problem := decodeProblem(t, data); len(problem.Errors) == 0 || problem.Errors[0].Field != tt.field {
+			t.Errorf("add %s: errors = %+v, want %s", tt.body, problem.Errors, tt.field)
+		}
+	}
+
+	resp, data = doRequest(t, server, "GET", "/api/playlists/1/export?format=m3u", "", alice)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "audio/x-mpegurl" {
+		t.Fatalf("export: status = %d, Content-Type = %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	// Ссылка есть только у первой песни
+	if !strings.HasSuffix(string(data), "#EXTINF:-1,Muse - Supermassive Black Hole\nhttps://example.com/muse/smbh\n") {
+		t.Errorf("export =\n%s", data)
+	}
+
+	resp, _ = doRequest(t, server, "GET", "/api/playlists/1/export?format=pls", "", alice)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("unknown format: status = %d, want 400", resp.StatusCode)
+	}
+}
@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/w212w/GoProjectEM/internal/logger"
+	"github.com/w212w/GoProjectEM/internal/lyrics"
+	"github.com/w212w/GoProjectEM/internal/models"
+	"github.com/w212w/GoProjectEM/internal/repository"
+)
+
+// ListSongRevisionsHandler godoc
+// @Summary Получить историю изменений песни
+// @Description Получить все ревизии песни: кто, когда и какие поля изменил, и снимок песни после изменения
+// @Tags revisions
+// @Produce json
+// @Param id path string true "ID песни"
+// @Success 200 {array} models.SongRevision "Ревизии песни по возрастанию номера"
+// @Failure 400 {object} models.ErrorResponse "Неверный ID песни"
+// @Failure 404 {object} models.ErrorResponse "Песня не найдена"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Router /api/songs/{id}/revisions [get]
+func ListSongRevisionsHandler(revisions repository.RevisionRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Log.Debug("ListSongRevisionsHandler: Start processing request")
+
+		id, err := parseSongID(r)
+		if err != nil {
+			logger.Log.Error("ListSongRevisionsHandler: Invalid song ID")
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidID, "Invalid song ID")
+			return
+		}
+
+		list, err := revisions.ListRevisions(r.Context(), id)
+		if err != nil {
+			logger.Log.Errorf("ListSongRevisionsHandler: Failed to retrieve revisions: %v", err)
+			writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to retrieve revisions")
+			return
+		}
+		if len(list) == 0 {
+			logger.Log.Error("ListSongRevisionsHandler: Song not found")
+			writeError(w, r, http.StatusNotFound, models.ErrCodeSongNotFound, "Song not found")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(list); err != nil {
+			logger.Log.Error("ListSongRevisionsHandler: Failed to encode response")
+			writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to encode response")
+			return
+		}
+
+		logger.Log.Info("ListSongRevisionsHandler: Successfully responded with revisions")
+	}
+}
+
+// DiffSongRevisionsHandler godoc
+// @Summary Сравнить две ревизии песни
+// @Description Получить изменения полей и секций текста (куплетов, припевов) между двумя ревизиями.
+// @Description По умолчанию to - последняя ревизия, from - предыдущая перед to
+// @Tags revisions
+// @Produce json
+// @Param id path string true "ID песни"
+// @Param from query int false "Номер исходной ревизии"
+// @Param to query int false "Номер конечной ревизии"
+// @Success 200 {object} models.RevisionDiff "Разница между ревизиями"
+// @Failure 400 {object} models.ErrorResponse "Неверные параметры"
+// @Failure 404 {object} models.ErrorResponse "Песня или ревизия не найдена"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Router /api/songs/{id}/revisions/diff [get]
+func DiffSongRevisionsHandler(revisions repository.RevisionRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Log.Debug("DiffSongRevisionsHandler: Start processing request")
+
+		id, err := parseSongID(r)
+		if err != nil {
+			logger.Log.Error("DiffSongRevisionsHandler: Invalid song ID")
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidID, "Invalid song ID")
+			return
+		}
+
+		list, err := revisions.ListRevisions(r.Context(), id)
+		if err != nil {
+			logger.Log.Errorf("DiffSongRevisionsHandler: Failed to retrieve revisions: %v", err)
+			writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to retrieve revisions")
+			return
+		}
+		if len(list) == 0 {
+			logger.Log.Error("DiffSongRevisionsHandler: Song not found")
+			writeError(w, r, http.StatusNotFound, models.ErrCodeSongNotFound, "Song not found")
+			return
+		}
+
+		byNumber := make(map[int]*models.SongRevision, len(list))
+		for i := range list {
+			byNumber[list[i].Revision] = &list[i]
+		}
+
+		to := list[len(list)-1].Revision
+		if v := r.URL.Query().Get("to"); v != "" {
+			if to, err = strconv.Atoi(v); err != nil {
+				writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidParameter, "Invalid to",
+					models.FieldError{Field: "to", Code: "invalid", Message: "must be a revision number"})
+				return
+			}
+		}
+		from := to - 1
+		if v := r.URL.Query().Get("from"); v != "" {
+			if from, err = strconv.Atoi(v); err != nil {
+				writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidParameter, "Invalid from",
+					models.FieldError{Field: "from", Code: "invalid", Message: "must be a revision number"})
+				return
+			}
+		}
+
+		fromRev, toRev := byNumber[from], byNumber[to]
+		if fromRev == nil || toRev == nil {
+			logger.Log.Errorf("DiffSongRevisionsHandler: Revision %d or %d not found", from, to)
+			writeError(w, r, http.StatusNotFound, models.ErrCodeRevisionNotFound,
+				fmt.Sprintf("Revision %d or %d not found", from, to))
+			return
+		}
+
+		diff := models.RevisionDiff{
+			SongID:   id,
+			From:     from,
+			To:       to,
+			Fields:   fromRev.FieldChanges(toRev),
+			Sections: lyrics.Diff(fromRev.Text, toRev.Text),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(diff); err != nil {
+			logger.Log.Error("DiffSongRevisionsHandler: Failed to encode response")
+			writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to encode response")
+			return
+		}
+
+		logger.Log.Info("DiffSongRevisionsHandler: Successfully responded with diff")
+	}
+}
+
+// RestoreSongRevisionHandler godoc
+// @Summary Восстановить ревизию песни
+// @Description Вернуть песне состояние из указанной ревизии. Восстановление записывается как новая ревизия
+// @Tags revisions
+// @Produce json
+// @Param id path string true "ID песни"
+// @Param rev path int true "Номер ревизии"
+// @Param If-Match header string false "ETag песни"
+// @Success 200 {object} models.Song "Восстановленная песня"
+// @Failure 400 {object} models.ErrorResponse "Неверные параметры"
+// @Failure 404 {object} models.ErrorResponse "Песня или ревизия не найдена"
+// @Failure 409 {object} models.ErrorResponse "Песня с такой группой и названием уже существует"
+// @Failure 412 {object} models.ErrorResponse "Песня была изменена"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Router /api/songs/{id}/revisions/{rev}/restore [post]
+func RestoreSongRevisionHandler(songs repository.SongRepository, revisions repository.RevisionRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Log.Debug("RestoreSongRevisionHandler: Start processing request")
+
+		id, err := parseSongID(r)
+		if err != nil {
+			logger.Log.Error("RestoreSongRevisionHandler: Invalid song ID")
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidID, "Invalid song ID")
+			return
+		}
+		revNumber, err := strconv.Atoi(mux.Vars(r)["rev"])
+		if err != nil {
+			logger.Log.Error("RestoreSongRevisionHandler: Invalid revision")
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidParameter, "Invalid revision number")
+			return
+		}
+
+		logger.Log.Debugf("RestoreSongRevisionHandler: Song ID received: %d, revision: %d", id, revNumber)
+
+		song, err := songs.Get(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				logger.Log.Error("RestoreSongRevisionHandler: Song not found")
+				writeError(w, r, http.StatusNotFound, models.ErrCodeSongNotFound, "Song not found")
+			} else {
+				logger.Log.Errorf("RestoreSongRevisionHandler: Failed to find song: %v", err)
+				writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to find song")
+			}
+			return
+		}
+		if !ifMatch(r, songETag(song)) {
+			logger.Log.Error("RestoreSongRevisionHandler: If-Match precondition failed")
+			writeError(w, r, http.StatusPreconditionFailed, models.ErrCodePreconditionFailed, "Song has been modified")
+			return
+		}
+
+		rev, err := revisions.GetRevision(r.Context(), id, revNumber)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				logger.Log.Error("RestoreSongRevisionHandler: Revision not found")
+				writeError(w, r, http.StatusNotFound, models.ErrCodeRevisionNotFound, "Revision not found")
+			} else {
+				logger.Log.Errorf("RestoreSongRevisionHandler: Failed to find revision: %v", err)
+				writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to find revision")
+			}
+			return
+		}
+
+		rev.ApplyTo(song)
+
+		info := repository.ChangeInfoFromContext(r.Context())
+		info.Note = fmt.Sprintf("restored from revision %d", revNumber)
+		ctx := repository.WithChangeInfo(r.Context(), info)
+
+		if err := songs.Update(ctx, song); err != nil {
+			if errors.Is(err, repository.ErrConflict) {
+				logger.Log.Error("RestoreSongRevisionHandler: Song has been modified concurrently")
+				writeError(w, r, http.StatusPreconditionFailed, models.ErrCodePreconditionFailed, "Song has been modified")
+			} else if errors.Is(err, repository.ErrDuplicate) {
+				logger.Log.Error("RestoreSongRevisionHandler: Song with the same group and title already exists")
+				writeDuplicate(w, r, songs, song.Group, song.Title)
+			} else {
+				logger.Log.Errorf("RestoreSongRevisionHandler: Failed to update song: %v", err)
+				writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to update song")
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", jsonSongETag(song))
+		if err := json.NewEncoder(w).Encode(song); err != nil {
+			logger.Log.Error("RestoreSongRevisionHandler: Failed to encode response")
+			writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to encode response")
+			return
+		}
+
+		logger.Log.Infof("RestoreSongRevisionHandler: Song %d restored to revision %d", id, revNumber)
+	}
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/w212w/GoProjectEM/internal/filterexpr"
+	"github.com/w212w/GoProjectEM/internal/models"
+	"github.com/w212w/GoProjectEM/internal/repository"
+)
+
+// songQuery фильтры, сортировка и поля выборки песен из параметров запроса
+type songQuery struct {
+	Filter repository.SongFilter
+	Sort   []repository.SortField
+	Fields []string
+}
+
+// parseSongQuery разбирает общие параметры списка и выгрузки песен. Без fields
+// выбираются поля defaultFields. Ошибка относится к одному параметру.
+func parseSongQuery(r *http.Request, defaultFields []string) (songQuery, *models.FieldError) {
+	params := r.URL.Query()
+	query := songQuery{
+		Filter: repository.SongFilter{
+			Artist: params.Get("artist"),
+			Title:  params.Get("title"),
+			Group:  params.Get("group"),
+			Query:  params.Get("q"),
+		},
+		Fields: defaultFields,
+	}
+	filter := &query.Filter
+
+	if hasLink := params.Get("has_link"); hasLink != "" {
+		v, err := strconv.ParseBool(hasLink)
+		if err != nil {
+			return query, &models.FieldError{Field: "has_link", Code: "invalid", Message: "must be true or false"}
+		}
+		filter.HasLink = &v
+	}
+	ranges := []struct {
+		name   string
+		bound  *time.Time
+		useEnd bool
+	}{
+		{"released_from", &filter.ReleasedFrom, false},
+		{"released_to", &filter.ReleasedTo, true},
+		{"created_from", &filter.CreatedFrom, false},
+		{"created_to", &filter.CreatedTo, true},
+		{"updated_from", &filter.UpdatedFrom, false},
+		{"updated_to", &filter.UpdatedTo, true},
+	}
+	for _, rng := range ranges {
+		value := params.Get(rng.name)
+		if value == "" {
+			continue
+		}
+		start, end, err := filterexpr.ParseTimeRange(value)
+		if err != nil {
+			return query, &models.FieldError{Field: rng.name, Code: "invalid_date", Message: err.Error()}
+		}
+		*rng.bound = start
+		if rng.useEnd {
+			*rng.bound = end
+		}
+	}
+	if raw := params.Get("filter"); raw != "" {
+		expr, err := filterexpr.Parse(raw)
+		if err != nil {
+			return query, &models.FieldError{Field: "filter", Code: "invalid", Message: err.Error()}
+		}
+		filter.Expr = expr
+	}
+	if album := params.Get("album"); album != "" {
+		id, err := strconv.ParseUint(album, 10, 64)
+		if err != nil || id == 0 {
+			return query, &models.FieldError{Field: "album", Code: "invalid", Message: "must be an album ID"}
+		}
+		filter.AlbumID = uint(id)
+	}
+	tags, err := models.NormalizeTags(params["tag"])
+	if err != nil {
+		return query, &models.FieldError{Field: "tag", Code: "invalid", Message: err.Error()}
+	}
+	filter.Tags = tags
+	for _, genre := range params["genre"] {
+		filter.Genres = append(filter.Genres, strings.ToLower(strings.TrimSpace(genre)))
+	}
+
+	if query.Sort, err = repository.ParseSort(params.Get("sort")); err != nil {
+		return query, &models.FieldError{Field: "sort", Code: "invalid", Message: err.Error()}
+	}
+	if fields := params.Get("fields"); fields != "" {
+		if query.Fields, err = models.ParseSongFields(fields); err != nil {
+			return query, &models.FieldError{Field: "fields", Code: "invalid", Message: err.Error()}
+		}
+	}
+	return query, nil
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/w212w/GoProjectEM/internal/logger"
+	"github.com/w212w/GoProjectEM/internal/models"
+	"github.com/w212w/GoProjectEM/internal/repository"
+)
+
+// maxBulkSongs максимальное количество песен в одном запросе массового изменения тегов
+const maxBulkSongs = 1000
+
+// ListGenresHandler godoc
+// @Summary Получить словарь жанров
+// @Description Получить жанры, которые можно назначать песням
+// @Tags tags
+// @Produce json
+// @Success 200 {array} models.Genre "Жанры"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Router /api/genres [get]
+func ListGenresHandler(tags repository.TagRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Log.Debug("ListGenresHandler: Start processing request")
+
+		genres, err := tags.ListGenres(r.Context())
+		if err != nil {
+			logger.Log.Errorf("ListGenresHandler: Failed to retrieve genres: %v", err)
+			writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to retrieve genres")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(genres); err != nil {
+			logger.Log.Error("ListGenresHandler: Failed to encode response")
+			writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to encode response")
+			return
+		}
+
+		logger.Log.Info("ListGenresHandler: Successfully responded with genres")
+	}
+}
+
+// TagSongsHandler godoc
+// @Summary Добавить теги и жанры песням
+// @Description Добавить теги и жанры из словаря сразу нескольким песням. Каждая измененная песня получает новую версию
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param request body models.BulkTagRequest true "Песни, теги и жанры"
+// @Success 200 {object} models.BulkTagResult "Количество измененных песен"
+// @Failure 400 {object} models.ErrorResponse "Неверный формат JSON"
+// @Failure 422 {object} models.ErrorResponse "Ошибки валидации полей"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Router /api/songs/tag [post]
+func TagSongsHandler(tags repository.TagRepository) http.HandlerFunc {
+	return bulkTagHandler("TagSongsHandler", "added", tags, tags.TagSongs)
+}
+
+// UntagSongsHandler godoc
+// @Summary Удалить теги и жанры у песен
+// @Description Удалить теги и жанры сразу у нескольких песен. Каждая измененная песня получает новую версию
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param request body models.BulkTagRequest true "Песни, теги и жанры"
+// @Success 200 {object} models.BulkTagResult "Количество измененных песен"
+// @Failure 400 {object} models.ErrorResponse "Неверный формат JSON"
+// @Failure 422 {object} models.ErrorResponse "Ошибки валидации полей"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Router /api/songs/untag [post]
+func UntagSongsHandler(tags repository.TagRepository) http.HandlerFunc {
+	return bulkTagHandler("UntagSongsHandler", "removed", tags, tags.UntagSongs)
+}
+
+type retagFunc func(ctx context.Context, songIDs []uint, tags, genres []string) (int, error)
+
+func bulkTagHandler(name, action string, tags repository.TagRepository, retag retagFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Log.Debugf("%s: Start processing request", name)
+
+		var input models.BulkTagRequest
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			logger.Log.Errorf("%s: Invalid JSON format", name)
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidJSON, "Invalid JSON format")
+			return
+		}
+		fieldErrors, err := validateBulkTag(r, tags, &input)
+		if err != nil {
+			logger.Log.Errorf("%s: Failed to retrieve genres: %v", name, err)
+			writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to retrieve genres")
+			return
+		}
+		if len(fieldErrors) > 0 {
+			logger.Log.Errorf("%s: Validation failed: %v", name, fieldErrors)
+			writeValidationError(w, r, fieldErrors)
+			return
+		}
+
+		info := repository.ChangeInfoFromContext(r.Context())
+		info.Note = fmt.Sprintf("tags %s: %s", action, strings.Join(append(slices.Clone(input.Tags), input.Genres...), ", "))
+		ctx := repository.WithChangeInfo(r.Context(), info)
+
+		updated, err := retag(ctx, input.SongIDs, input.Tags, input.Genres)
+		if err != nil {
+			switch {
+			case errors.Is(err, repository.ErrInvalidReference):
+				logger.Log.Errorf("%s: Unknown songs", name)
+				writeValidationError(w, r, []models.FieldError{{Field: "song_ids", Code: "not_found", Message: "some songs do not exist or are deleted"}})
+			case errors.Is(err, repository.ErrUnknownGenre):
+				logger.Log.Errorf("%s: Unknown genres", name)
+				writeValidationError(w, r, []models.FieldError{{Field: "genres", Code: "not_found", Message: "some genres are not in the vocabulary, see /api/genres"}})
+			default:
+				logger.Log.Errorf("%s: Failed to change tags: %v", name, err)
+				writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to change tags")
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(models.BulkTagResult{Updated: updated}); err != nil {
+			logger.Log.Errorf("%s: Failed to encode response", name)
+			return
+		}
+
+		logger.Log.Infof("%s: Tags %s for %d songs", name, action, updated)
+	}
+}
+
+// validateBulkTag нормализует теги, проверяет жанры по словарю и убирает повторы песен
+func validateBulkTag(r *http.Request, tags repository.TagRepository, input *models.BulkTagRequest) ([]models.FieldError, error) {
+	var errs []models.FieldError
+
+	slices.Sort(input.SongIDs)
+	input.SongIDs = slices.Compact(input.SongIDs)
+	switch {
+	case len(input.SongIDs) == 0:
+		errs = append(errs, models.FieldError{Field: "song_ids", Code: "required", Message: "field is required"})
+	case len(input.SongIDs) > maxBulkSongs:
+		errs = append(errs, models.FieldError{Field: "song_ids", Code: "too_long", Message: fmt.Sprintf("must contain at most %d songs", maxBulkSongs)})
+	case input.SongIDs[0] == 0:
+		errs = append(errs, models.FieldError{Field: "song_ids", Code: "invalid", Message: "must contain song IDs"})
+	}
+
+	normalized, err := models.NormalizeTags(input.Tags)
+	if err != nil {
+		errs = append(errs, models.FieldError{Field: "tags", Code: "invalid", Message: err.Error()})
+	}
+	input.Tags = normalized
+
+	if len(input.Genres) > 0 {
+		vocabulary, err := tags.ListGenres(r.Context())
+		if err != nil {
+			return nil, err
+		}
+		for i, genre := range input.Genres {
+			genre = strings.ToLower(strings.TrimSpace(genre))
+			if !slices.ContainsFunc(vocabulary, func(g models.Genre) bool { return g.Slug == genre }) {
+				errs = append(errs, models.FieldError{Field: "genres", Code: "not_found", Message: fmt.Sprintf("unknown genre %q, see /api/genres", genre)})
+				break
+			}
+			input.Genres[i] = genre
+		}
+		slices.Sort(input.Genres)
+		input.Genres = slices.Compact(input.Genres)
+	}
+
+	if len(input.Tags) == 0 && len(input.Genres) == 0 {
+		errs = append(errs, models.FieldError{Field: "tags", Code: "required", Message: "tags or genres are required"})
+	}
+	return errs, nil
+}
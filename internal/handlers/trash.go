@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/w212w/GoProjectEM/internal/logger"
+	"github.com/w212w/GoProjectEM/internal/models"
+	"github.com/w212w/GoProjectEM/internal/repository"
+)
+
+// ListTrashHandler godoc
+// @Summary Получить песни из корзины
+// @Description Получить удаленные песни, начиная с удаленных последними. Песни хранятся в корзине ограниченное время, после чего удаляются окончательно
+// @Tags trash
+// @Produce json
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество результатов на странице" default(10)
+// @Success 200 {array} models.Song "Список удаленных песен"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Router /api/trash [get]
+func ListTrashHandler(trash repository.TrashRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Log.Debug("ListTrashHandler: Start processing request")
+
+		offset, limit := parsePage(r, 10)
+
+		songs, err := trash.ListDeleted(r.Context(), offset, limit)
+		if err != nil {
+			logger.Log.Errorf("ListTrashHandler: Failed to retrieve deleted songs: %v", err)
+			writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to retrieve deleted songs")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(songs); err != nil {
+			logger.Log.Error("ListTrashHandler: Failed to encode response")
+			writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to encode response")
+			return
+		}
+
+		logger.Log.Info("ListTrashHandler: Successfully responded with deleted songs")
+	}
+}
+
+// RestoreSongHandler godoc
+// @Summary Восстановить песню из корзины
+// @Description Вернуть удаленную песню по ее ID
+// @Tags trash
+// @Produce json
+// @Param id path string true "ID песни"
+// @Success 200 {object} models.Song "Восстановленная песня"
+// @Failure 400 {object} models.ErrorResponse "Неверный ID песни"
+// @Failure 404 {object} models.ErrorResponse "Песня не найдена в корзине"
+// @Failure 409 {object} models.ErrorResponse "Песня с такой группой и названием уже существует"
+// @Failure 500 {object} models.ErrorResponse "Ошибка сервера"
+// @Router /api/songs/{id}/restore [post]
+func RestoreSongHandler(trash repository.TrashRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Log.Debug("RestoreSongHandler: Start processing request")
+
+		id, err := parseSongID(r)
+		if err != nil {
+			logger.Log.Error("RestoreSongHandler: Invalid song ID")
+			writeError(w, r, http.StatusBadRequest, models.ErrCodeInvalidID, "Invalid song ID")
+			return
+		}
+
+		song, err := trash.Restore(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				logger.Log.Error("RestoreSongHandler: Song not found in trash")
+				writeError(w, r, http.StatusNotFound, models.ErrCodeSongNotFound, "Song not found in trash")
+			} else if errors.Is(err, repository.ErrDuplicate) {
+				logger.Log.Error("RestoreSongHandler: Song with the same group and title already exists")
+				writeError(w, r, http.StatusConflict, models.ErrCodeSongExists, "Song with the same group and title already exists, merge them instead")
+			} else {
+				logger.Log.Errorf("RestoreSongHandler: Failed to restore song: %v", err)
+				writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to restore song")
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", jsonSongETag(song))
+		if err := json.NewEncoder(w).Encode(song); err != nil {
+			logger.Log.Error("RestoreSongHandler: Failed to encode response")
+			writeError(w, r, http.StatusInternalServerError, models.ErrCodeInternal, "Failed to encode response")
+			return
+		}
+
+		logger.Log.Infof("RestoreSongHandler: Song %d restored from trash", id)
+	}
+}
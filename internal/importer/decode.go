@@ -0,0 +1,164 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/w212w/GoProjectEM/internal/models"
+)
+
+// Форматы входных данных
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// maxLineSize наибольшая длина строки NDJSON: текст песни до 50000 символов
+// может занимать в UTF-8 и с экранированием в несколько раз больше
+const maxLineSize = 1 << 20
+
+// Row строка входных данных. Err - ошибка разбора этой строки, остальные строки
+// при этом читаются дальше.
+type Row struct {
+	Line  int
+	Input models.ImportSongRequest
+	Err   error
+}
+
+// Decoder читает строки импорта по одной. Next возвращает io.EOF после последней строки.
+type Decoder interface {
+	Next() (Row, error)
+}
+
+// NewDecoder возвращает потоковый декодер формата FormatCSV или FormatNDJSON
+func NewDecoder(r io.Reader, format string) (Decoder, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVDecoder(r)
+	case FormatNDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+		return &ndjsonDecoder{scanner: scanner}, nil
+	default:
+		return nil, fmt.Errorf("unsupported import format %q, expected csv or ndjson", format)
+	}
+}
+
+// FormatFromContentType определяет формат по типу содержимого, например text/csv
+func FormatFromContentType(contentType string) string {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	switch strings.ToLower(strings.TrimSpace(mediaType)) {
+	case "text/csv", "application/csv":
+		return FormatCSV
+	case "application/x-ndjson", "application/ndjson", "application/jsonl":
+		return FormatNDJSON
+	}
+	return ""
+}
+
+type ndjsonDecoder struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (d *ndjsonDecoder) Next() (Row, error) {
+	for d.scanner.Scan() {
+		d.line++
+		data := bytes.TrimSpace(d.scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		row := Row{Line: d.line}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&row.Input); err != nil {
+			row.Err = fmt.Errorf("invalid JSON: %w", err)
+		} else if decoder.More() {
+			row.Err = errors.New("invalid JSON: unexpected data after object")
+		}
+		return row, nil
+	}
+	if err := d.scanner.Err(); err != nil {
+		return Row{}, fmt.Errorf("line %d: %w", d.line+1, err)
+	}
+	return Row{}, io.EOF
+}
+
+// csvColumns колонки CSV и поля строки импорта
+var csvColumns = map[string]func(in *models.ImportSongRequest) *string{
+	"group":        func(in *models.ImportSongRequest) *string { return &in.Group },
+	"title":        func(in *models.ImportSongRequest) *string { return &in.Title },
+	"artist":       func(in *models.ImportSongRequest) *string { return &in.Artist },
+	"release_date": func(in *models.ImportSongRequest) *string { return &in.ReleaseDate },
+	"text":         func(in *models.ImportSongRequest) *string { return &in.Text },
+	"link":         func(in *models.ImportSongRequest) *string { return &in.Link },
+}
+
+type csvDecoder struct {
+	reader *csv.Reader
+	header []string
+}
+
+// newCSVDecoder читает заголовок: имена колонок из csvColumns в любом порядке,
+// обязательны group и title
+func newCSVDecoder(r io.Reader) (*csvDecoder, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("CSV header is missing")
+		}
+		return nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, ok := csvColumns[name]; !ok {
+			return nil, fmt.Errorf("unknown CSV column %q", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate CSV column %q", name)
+		}
+		seen[name] = true
+		header[i] = name
+	}
+	for _, required := range []string{"group", "title"} {
+		if !seen[required] {
+			return nil, fmt.Errorf("CSV column %q is required", required)
+		}
+	}
+	return &csvDecoder{reader: reader, header: header}, nil
+}
+
+func (d *csvDecoder) Next() (Row, error) {
+	record, err := d.reader.Read()
+	if errors.Is(err, io.EOF) {
+		return Row{}, io.EOF
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return Row{Line: parseErr.StartLine, Err: fmt.Errorf("invalid CSV: %w", parseErr.Err)}, nil
+	}
+	if err != nil {
+		return Row{}, err
+	}
+
+	line, _ := d.reader.FieldPos(0)
+	row := Row{Line: line}
+	if len(record) != len(d.header) {
+		row.Err = fmt.Errorf("expected %d fields, got %d", len(d.header), len(record))
+		return row, nil
+	}
+	for i, value := range record {
+		*csvColumns[d.header[i]](&row.Input) = value
+	}
+	return row, nil
+}
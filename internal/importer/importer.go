@@ -0,0 +1,220 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/w212w/GoProjectEM/internal/config"
+	"github.com/w212w/GoProjectEM/internal/enricher"
+	"github.com/w212w/GoProjectEM/internal/logger"
+	"github.com/w212w/GoProjectEM/internal/models"
+	"github.com/w212w/GoProjectEM/internal/repository"
+	"github.com/w212w/GoProjectEM/internal/validation"
+)
+
+// Config настройки импорта
+type Config struct {
+	// BatchSize количество строк, сохраняемых в одной транзакции
+	BatchSize int
+	// Concurrency наибольшее число одновременных запросов к внешнему API
+	Concurrency int
+}
+
+// ConfigFromEnv читает настройки из переменных окружения IMPORT_*
+func ConfigFromEnv() Config {
+	return Config{
+		BatchSize:   config.Int("IMPORT_BATCH_SIZE", 100),
+		Concurrency: config.Int("IMPORT_ENRICH_CONCURRENCY", 4),
+	}
+}
+
+// Options параметры одного импорта
+type Options struct {
+	// Enrich заполняет пустые поля песен данными внешнего API
+	Enrich bool
+}
+
+// Importer массово добавляет песни из CSV или NDJSON. Строки читаются потоком
+// и обрабатываются пачками: проверка, необязательное обогащение и сохранение
+// пачки в одной транзакции. Песни, которые уже есть в каталоге, пропускаются.
+type Importer struct {
+	songs    repository.SongRepository
+	batches  repository.ImportRepository
+	enricher enricher.Enricher
+	cfg      Config
+}
+
+func New(songs repository.SongRepository, batches repository.ImportRepository, e enricher.Enricher, cfg Config) *Importer {
+	if cfg.BatchSize < 1 {
+		cfg.BatchSize = 1
+	}
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = 1
+	}
+	return &Importer{songs: songs, batches: batches, enricher: e, cfg: cfg}
+}
+
+// item строка пачки: песня для сохранения или уже известный результат
+type item struct {
+	result models.ImportRowResult
+	song   *models.Song
+}
+
+// Run импортирует все строки dec. Ошибка сохранения пачки отмечает ее строки
+// как failed, и импорт продолжается. Ошибка возвращается, если входные данные
+// не удалось дочитать или ctx отменен; отчет при этом содержит обработанные строки.
+func (im *Importer) Run(ctx context.Context, dec Decoder, opts Options) (*models.ImportReport, error) {
+	report := &models.ImportReport{Rows: []models.ImportRowResult{}}
+	batch := make([]Row, 0, im.cfg.BatchSize)
+	for {
+		row, err := dec.Next()
+		if err != nil && !errors.Is(err, io.EOF) {
+			im.processBatch(ctx, batch, opts, report)
+			return report, err
+		}
+		if err == nil {
+			batch = append(batch, row)
+		}
+		if len(batch) == im.cfg.BatchSize || (errors.Is(err, io.EOF) && len(batch) > 0) {
+			im.processBatch(ctx, batch, opts, report)
+			batch = batch[:0]
+		}
+		if ctx.Err() != nil {
+			return report, ctx.Err()
+		}
+		if errors.Is(err, io.EOF) {
+			logger.Log.Infof("Importer: %d created, %d skipped, %d failed", report.Created, report.Skipped, report.Failed)
+			return report, nil
+		}
+	}
+}
+
+func (im *Importer) processBatch(ctx context.Context, rows []Row, opts Options, report *models.ImportReport) {
+	if len(rows) == 0 || ctx.Err() != nil {
+		return
+	}
+	items := make([]item, len(rows))
+	for i, row := range rows {
+		items[i] = im.prepare(ctx, row)
+	}
+	if opts.Enrich {
+		im.enrich(ctx, items)
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	im.save(ctx, items)
+	for _, it := range items {
+		report.Add(it.result)
+	}
+}
+
+// prepare проверяет строку и ищет существующую песню с той же группой и названием
+func (im *Importer) prepare(ctx context.Context, row Row) item {
+	it := item{result: models.ImportRowResult{Line: row.Line}}
+	if row.Err != nil {
+		it.result.Status, it.result.Error = models.ImportFailed, row.Err.Error()
+		return it
+	}
+	if fieldErrors := validation.Validate(&row.Input); len(fieldErrors) > 0 {
+		it.result.Status, it.result.Error, it.result.Errors = models.ImportFailed, "validation failed", fieldErrors
+		return it
+	}
+
+	existing, err := im.songs.FindByNaturalKey(ctx, row.Input.Group, row.Input.Title)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		logger.Log.Errorf("Importer: line %d: failed to look up existing song: %v", row.Line, err)
+		it.result.Status, it.result.Error = models.ImportFailed, "failed to look up existing song"
+		return it
+	}
+	if existing != nil {
+		it.result.Status, it.result.SongID, it.result.Error = models.ImportSkipped, existing.ID, "song already exists"
+		return it
+	}
+
+	releaseDate, _ := models.ParseReleaseDate(row.Input.ReleaseDate)
+	it.song = &models.Song{
+		Group:       row.Input.Group,
+		Title:       row.Input.Title,
+		Artist:      row.Input.Artist,
+		ReleaseDate: releaseDate,
+		Text:        row.Input.Text,
+		Link:        row.Input.Link,
+		Status:      models.SongStatusReady,
+	}
+	return it
+}
+
+// enrich дополняет пустые поля песен данными внешнего API, выполняя не больше
+// Concurrency запросов одновременно. Строки с ошибкой обогащения не сохраняются.
+func (im *Importer) enrich(ctx context.Context, items []item) {
+	sem := make(chan struct{}, im.cfg.Concurrency)
+	var wg sync.WaitGroup
+	for i := range items {
+		song := items[i].song
+		if song == nil || (song.Artist != "" && !song.ReleaseDate.IsZero() && song.Text != "" && song.Link != "") {
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		}
+		wg.Add(1)
+		go func(it *item) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			info, err := im.enricher.Enrich(ctx, it.song.Group, it.song.Title)
+			if err != nil {
+				logger.Log.Warnf("Importer: line %d: failed to fetch song info: %v", it.result.Line, err)
+				it.result.Status, it.result.Error = models.ImportFailed, fmt.Sprintf("failed to fetch song info: %v", err)
+				it.song = nil
+				return
+			}
+			var fetched models.Song
+			info.Apply(&fetched)
+			it.song.FillMissing(&fetched)
+		}(&items[i])
+	}
+	wg.Wait()
+}
+
+// save сохраняет подготовленные песни пачки в одной транзакции
+func (im *Importer) save(ctx context.Context, items []item) {
+	var songs []*models.Song
+	var pending []*item
+	for i := range items {
+		if items[i].song != nil {
+			songs = append(songs, items[i].song)
+			pending = append(pending, &items[i])
+		}
+	}
+	if len(songs) == 0 {
+		return
+	}
+
+	errs, err := im.batches.CreateBatch(ctx, songs)
+	if err != nil {
+		logger.Log.Errorf("Importer: failed to save batch of %d songs: %v", len(songs), err)
+		for _, it := range pending {
+			it.result.Status, it.result.Error = models.ImportFailed, "failed to save batch"
+		}
+		return
+	}
+	for i, it := range pending {
+		switch {
+		case errs[i] == nil:
+			it.result.Status, it.result.SongID = models.ImportCreated, it.song.ID
+		case errors.Is(errs[i], repository.ErrDuplicate):
+			it.result.Status, it.result.Error = models.ImportSkipped, "song already exists"
+		default:
+			it.result.Status, it.result.Error = models.ImportFailed, errs[i].Error()
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/w212w/GoProjectEM/internal/enricher"
+	"github.com/w212w/GoProjectEM/internal/logger"
+	"github.com/w212w/GoProjectEM/internal/models"
+	"github.com/w212w/GoProjectEM/internal/repository"
+)
+
+func TestMain(m *testing.M) {
+	logger.Log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// readRows читает все строки декодера
+func readRows(t *testing.T, dec Decoder) []Row {
+	t.Helper()
+
+	var rows []Row
+	for {
+		row, err := dec.Next()
+		if errors.Is(err, io.EOF) {
+			return rows
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		rows = append(rows, row)
+	}
+}
+
+func TestFormatFromContentType(t *testing.T) {
+	tests := map[string]string{
+		"text/csv":                          FormatCSV,
+		"Text/CSV; charset=utf-8":           FormatCSV,
+		"application/csv":                   FormatCSV,
+		"application/x-ndjson":              FormatNDJSON,
+		"application/jsonl; charset=utf-8 ": FormatNDJSON,
+		"application/json":                  "",
+		"":                                  "",
+	}
+	for contentType, want := range tests {
+		if got := FormatFromContentType(contentType); got != want {
+			t.Errorf("FormatFromContentType(%q) = %q, want %q", contentType, got, want)
+		}
+	}
+}
+
+func TestNDJSONDecoder(t *testing.T) {
+	input := `{"group":"Muse","title":"Uprising"}
+
+{"group":"Muse","title":"Starlight","artist":"Matthew Bellamy"}
+{"group":"Muse"
+{"group":"Muse","title":"Hysteria","album":"Absolution"}
+{"group":"Muse","title":"Madness"} {}
+`
+	dec, err := NewDecoder(strings.NewReader(input), FormatNDJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := readRows(t, dec)
+
+	if len(rows) != 5 {
+		t.Fatalf("rows = %+v, want 5 rows", rows)
+	}
+	lines := []int{1, 3, 4, 5, 6}
+	failed := []bool{false, false, true, true, true}
+	for i, row := range rows {
+		if row.Line != lines[i] || (row.Err != nil) != failed[i] {
+			t.Errorf("row %d = line %d, err %v; want line %d, failed %v", i, row.Line, row.Err, lines[i], failed[i])
+		}
+	}
+	want := models.ImportSongRequest{Group: "Muse", Title: "Starlight", Artist: "Matthew Bellamy"}
+	if rows[1].Input != want {
+		t.Errorf("Input = %+v, want %+v", rows[1].Input, want)
+	}
+}
+
+func TestCSVDecoder(t *testing.T) {
+	input := "\ufeffTitle, group ,link\n" +
+		"Uprising,Muse,https://example.com/uprising\n" +
+		"Starlight,Muse\n" +
+		"\"Knights of Cydonia\",Muse,\n" +
+		"\"broken,Muse,\n"
+	dec, err := NewDecoder(strings.NewReader(input), FormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := readRows(t, dec)
+
+	if len(rows) != 4 {
+		t.Fatalf("rows = %+v, want 4 rows", rows)
+	}
+	want := models.ImportSongRequest{Group: "Muse", Title: "Uprising", Link: "https://example.com/uprising"}
+	if rows[0].Line != 2 || rows[0].Err != nil || rows[0].Input != want {
+		t.Errorf("row 0 = %+v, want line 2 with %+v", rows[0], want)
+	}
+	if rows[1].Line != 3 || rows[1].Err == nil {
+		t.Errorf("row 1 = %+v, want field count error on line 3", rows[1])
+	}
+	if rows[2].Line != 4 || rows[2].Err != nil || rows[2].Input.Title != "Knights of Cydonia" {
+		t.Errorf("row 2 = %+v, want quoted title on line 4", rows[2])
+	}
+	if rows[3].Line != 5 || rows[3].Err == nil {
+		t.Errorf("row 3 = %+v, want parse error on line 5", rows[3])
+	}
+}
+
+func TestNewDecoderErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		format string
+	}{
+		{"unsupported format", "", "xml"},
+		{"empty csv", "", FormatCSV},
+		{"unknown column", "group,title,album\n", FormatCSV},
+		{"duplicate column", "group,title,Group\n", FormatCSV},
+		{"missing title", "group,artist\n", FormatCSV},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewDecoder(strings.NewReader(tt.input), tt.format); err == nil {
+				t.Error("NewDecoder succeeded, want error")
+			}
+		})
+	}
+}
+
+// stubEnricher возвращает ошибку для песен из failing и метаданные для остальных
+type stubEnricher struct {
+	failing map[string]bool
+}
+
+func (e stubEnricher) Enrich(ctx context.Context, group, song string) (*enricher.SongInfo, error) {
+	if e.failing[song] {
+		return nil, enricher.ErrNotFound
+	}
+	return &enricher.SongInfo{Artist: "Matthew Bellamy", ReleaseDate: "2009", Link: "https://example.com/" + song}, nil
+}
+
+// failingBatches хранилище, в котором не удается сохранить ни одну пачку
+type failingBatches struct{}
+
+func (failingBatches) CreateBatch(ctx context.Context, songs []*models.Song) ([]error, error) {
+	return nil, errors.New("connection reset")
+}
+
+// runImport импортирует NDJSON input в хранилище с песней Muse - Uprising
+func runImport(t *testing.T, input string, batches repository.ImportRepository, opts Options) (*models.ImportReport, *repository.MemorySongRepository) {
+	t.Helper()
+
+	songs := repository.NewMemorySongRepository()
+	if err := songs.Create(context.Background(), &models.Song{Group: "Muse", Title: "Uprising", Status: models.SongStatusReady}); err != nil {
+		t.Fatal(err)
+	}
+	if batches == nil {
+		batches = songs
+	}
+	dec, err := NewDecoder(strings.NewReader(input), FormatNDJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	im := New(songs, batches, stubEnricher{failing: map[string]bool{"Unknown": true}}, Config{BatchSize: 2, Concurrency: 2})
+	report, err := im.Run(context.Background(), dec, opts)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	return report, songs
+}
+
+// statuses возвращает статусы строк отчета по порядку
+func statuses(report *models.ImportReport) []string {
+	got := make([]string, len(report.Rows))
+	for i, row := range report.Rows {
+		got[i] = row.Status
+	}
+	return got
+}
+
+func TestImporterRun(t *testing.T) {
+	input := `{"group":"Muse","title":"Starlight"}
+{"group":"muse","title":"UPRISING"}
+{"group":"Muse","title":"Hysteria","release_date":"2003"}
+{"group":"Muse","title":"hysteria"}
+{"group":"Muse","title":""}
+not json
+`
+	report, songs := runImport(t, input, nil, Options{})
+
+	// Вторая строка пачки с тем же ключом пропускается при сохранении пачки
+	want := []string{models.ImportCreated, models.ImportSkipped, models.ImportCreated, models.ImportSkipped, models.ImportFailed, models.ImportFailed}
+	if got := statuses(report); !reflect.DeepEqual(got, want) {
+		t.Fatalf("statuses = %v, want %v", got, want)
+	}
+	if report.Created != 2 || report.Skipped != 2 || report.Failed != 2 {
+		t.Errorf("report = %d created, %d skipped, %d failed, want 2, 2, 2", report.Created, report.Skipped, report.Failed)
+	}
+	if report.Rows[1].SongID != 1 {
+		t.Errorf("skipped row song_id = %d, want the existing song 1", report.Rows[1].SongID)
+	}
+	if len(report.Rows[4].Errors) == 0 {
+		t.Error("validation errors are not reported")
+	}
+
+	song, err := songs.Get(context.Background(), report.Rows[2].SongID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if song.Title != "Hysteria" || song.ReleaseDate.String() != "2003" || song.Status != models.SongStatusReady {
+		t.Errorf("song = %+v, want ready Hysteria released in 2003", song)
+	}
+}
+
+func TestImporterEnrich(t *testing.T) {
+	input := `{"group":"Muse","title":"Starlight"}
+{"group":"Muse","title":"Unknown"}
+{"group":"Muse","title":"Hysteria","artist":"Muse","release_date":"2003"}
+`
+	report, songs := runImport(t, input, nil, Options{Enrich: true})
+
+	want := []string{models.ImportCreated, models.ImportFailed, models.ImportCreated}
+	if got := statuses(report); !reflect.DeepEqual(got, want) {
+		t.Fatalf("statuses = %v, want %v", got, want)
+	}
+
+	starlight, err := songs.Get(context.Background(), report.Rows[0].SongID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if starlight.Artist != "Matthew Bellamy" || starlight.Link != "https://example.com/Starlight" {
+		t.Errorf("enriched song = %+v", starlight)
+	}
+
+	// Заполненные поля не перезаписываются
+	hysteria, err := songs.Get(context.Background(), report.Rows[2].SongID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hysteria.Artist != "Muse" || hysteria.ReleaseDate.String() != "2003" || hysteria.Link != "https://example.com/Hysteria" {
+		t.Errorf("partially enriched song = %+v", hysteria)
+	}
+}
+
+func TestImporterBatchFailure(t *testing.T) {
+	input := `{"group":"Muse","title":"Starlight"}
+{"group":"Muse","title":"Hysteria"}
+{"group":"Muse","title":"Uprising"}
+`
+	report, _ := runImport(t, input, failingBatches{}, Options{})
+
+	want := []string{models.ImportFailed, models.ImportFailed, models.ImportSkipped}
+	if got := statuses(report); !reflect.DeepEqual(got, want) {
+		t.Errorf("statuses = %v, want %v", got, want)
+	}
+}
+
+func TestImporterCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	songs := repository.NewMemorySongRepository()
+	dec, err := NewDecoder(strings.NewReader(`{"group":"Muse","title":"Starlight"}`), FormatNDJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := New(songs, songs, stubEnricher{}, Config{}).Run(ctx, dec, Options{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if len(report.Rows) != 0 {
+		t.Errorf("rows = %+v, want none", report.Rows)
+	}
+}
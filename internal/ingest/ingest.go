@@ -0,0 +1,293 @@
+package ingest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	mathrand "math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/w212w/GoProjectEM/internal/config"
+	"github.com/w212w/GoProjectEM/internal/enricher"
+	"github.com/w212w/GoProjectEM/internal/logger"
+	"github.com/w212w/GoProjectEM/internal/models"
+	"github.com/w212w/GoProjectEM/internal/repository"
+)
+
+// ErrQueueFull очередь задач заполнена, задача не принята
+var ErrQueueFull = errors.New("ingest queue is full")
+
+// maxRetryDelay наибольшая задержка перед повтором задачи
+const maxRetryDelay = 10 * time.Minute
+
+// Config настройки пула фоновых обработчиков
+type Config struct {
+	Workers     int
+	QueueSize   int
+	MaxAttempts int
+	RetryDelay  time.Duration
+	Timeout     time.Duration
+}
+
+// ConfigFromEnv читает настройки из переменных окружения INGEST_*
+func ConfigFromEnv() Config {
+	return Config{
+		Workers:     config.Int("INGEST_WORKERS", 4),
+		QueueSize:   config.Int("INGEST_QUEUE_SIZE", 100),
+		MaxAttempts: config.Int("INGEST_MAX_ATTEMPTS", 5),
+		RetryDelay:  config.Duration("INGEST_RETRY_DELAY", 5*time.Second),
+		Timeout:     config.Duration("INGEST_TIMEOUT", 30*time.Second),
+	}
+}
+
+// Ingestor сохраняет песни в статусе pending и дополняет их данными
+// внешнего API в пуле фоновых обработчиков, повторяя неудачные попытки.
+type Ingestor struct {
+	songs    repository.SongRepository
+	jobs     repository.JobRepository
+	enricher enricher.Enricher
+	cfg      Config
+
+	queue chan string
+	// slots места в очереди: место занимается до сохранения песни и задачи,
+	// поэтому задача, для которой нет места, ничего не меняет
+	slots chan struct{}
+	done  chan struct{}
+	wg    sync.WaitGroup
+}
+
+func New(songs repository.SongRepository, jobs repository.JobRepository, e enricher.Enricher, cfg Config) *Ingestor {
+	if cfg.Workers < 1 {
+		cfg.Workers = 1
+	}
+	if cfg.MaxAttempts < 1 {
+		cfg.MaxAttempts = 1
+	}
+	if cfg.QueueSize < 1 {
+		cfg.QueueSize = 1
+	}
+	return &Ingestor{
+		songs:    songs,
+		jobs:     jobs,
+		enricher: e,
+		cfg:      cfg,
+		queue:    make(chan string, cfg.QueueSize),
+		slots:    make(chan struct{}, cfg.QueueSize),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start запускает обработчики и возобновляет незавершенные задачи
+func (i *Ingestor) Start(ctx context.Context) error {
+	for n := 0; n < i.cfg.Workers; n++ {
+		i.wg.Add(1)
+		go i.worker()
+	}
+
+	unfinished, err := i.jobs.ListUnfinished(ctx)
+	if err != nil {
+		return fmt.Errorf("list unfinished jobs: %w", err)
+	}
+	for _, job := range unfinished {
+		logger.Log.Debugf("Ingestor: resuming job %s", job.ID)
+		if !i.enqueue(job.ID) {
+			i.retryLater(job.ID, i.cfg.RetryDelay)
+		}
+	}
+
+	logger.Log.Infof("Ingestor: started %d workers", i.cfg.Workers)
+	return nil
+}
+
+// Stop останавливает обработчики и дожидается завершения текущих задач
+func (i *Ingestor) Stop() {
+	close(i.done)
+	i.wg.Wait()
+}
+
+// Submit сохраняет песню в статусе pending и ставит задачу на обогащение в очередь.
+// Песня с ненулевым ID уже существует: она обновляется и обогащается заново.
+// Если очередь заполнена, возвращается ErrQueueFull, а песня и задачи не меняются.
+func (i *Ingestor) Submit(ctx context.Context, song *models.Song) (*models.Job, error) {
+	if !i.reserve() {
+		return nil, ErrQueueFull
+	}
+
+	job, err := i.save(ctx, song)
+	if err != nil {
+		i.release()
+		return nil, err
+	}
+	i.queue <- job.ID
+	return job, nil
+}
+
+// save сохраняет песню в статусе pending и создает для нее задачу
+func (i *Ingestor) save(ctx context.Context, song *models.Song) (*models.Job, error) {
+	song.Status = models.SongStatusPending
+	if song.ID == 0 {
+		if err := i.songs.Create(ctx, song); err != nil {
+			return nil, fmt.Errorf("create song: %w", err)
+		}
+	} else if err := i.songs.Update(ctx, song); err != nil {
+		return nil, fmt.Errorf("update song: %w", err)
+	}
+
+	job := &models.Job{
+		ID:          newJobID(),
+		SongID:      song.ID,
+		Status:      models.JobStatusQueued,
+		MaxAttempts: i.cfg.MaxAttempts,
+	}
+	if err := i.jobs.Create(ctx, job); err != nil {
+		return nil, fmt.Errorf("create job: %w", err)
+	}
+	return job, nil
+}
+
+// reserve занимает место в очереди, не блокируя вызывающего, и возвращает
+// false, если очередь заполнена
+func (i *Ingestor) reserve() bool {
+	select {
+	case i.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// release освобождает место в очереди
+func (i *Ingestor) release() {
+	<-i.slots
+}
+
+// enqueue ставит задачу в очередь, не блокируя вызывающего, и возвращает
+// false, если очередь заполнена
+func (i *Ingestor) enqueue(id string) bool {
+	if !i.reserve() {
+		return false
+	}
+	i.queue <- id
+	return true
+}
+
+// retryLater ставит задачу в очередь через delay. Если очередь к этому
+// времени заполнена, попытка откладывается еще на RetryDelay.
+func (i *Ingestor) retryLater(id string, delay time.Duration) {
+	time.AfterFunc(delay, func() {
+		select {
+		case <-i.done:
+		default:
+			if !i.enqueue(id) {
+				logger.Log.Warnf("Ingestor: queue is full, postponing job %s", id)
+				i.retryLater(id, i.cfg.RetryDelay)
+			}
+		}
+	})
+}
+
+func (i *Ingestor) worker() {
+	defer i.wg.Done()
+	for {
+		select {
+		case <-i.done:
+			return
+		case id := <-i.queue:
+			i.release()
+			i.process(id)
+		}
+	}
+}
+
+func (i *Ingestor) process(id string) {
+	ctx, cancel := context.WithTimeout(context.Background(), i.cfg.Timeout)
+	defer cancel()
+	ctx = repository.WithChangeInfo(ctx, repository.ChangeInfo{Actor: "ingest", Note: "enriched from external API"})
+
+	job, err := i.jobs.Get(ctx, id)
+	if err != nil {
+		logger.Log.Errorf("Ingestor: failed to load job %s: %v", id, err)
+		return
+	}
+	if job.Status == models.JobStatusSucceeded || job.Status == models.JobStatusFailed {
+		return
+	}
+
+	song, err := i.songs.Get(ctx, job.SongID)
+	if err != nil {
+		logger.Log.Errorf("Ingestor: failed to load song %d for job %s: %v", job.SongID, id, err)
+		job.Status = models.JobStatusFailed
+		job.LastError = err.Error()
+		i.saveJob(ctx, job)
+		return
+	}
+
+	job.Status = models.JobStatusRunning
+	job.Attempts++
+	i.saveJob(ctx, job)
+
+	info, err := i.enricher.Enrich(ctx, song.Group, song.Title)
+	if err == nil {
+		info.Apply(song)
+		song.Status = models.SongStatusReady
+		if err = i.songs.Update(ctx, song); err == nil {
+			job.Status = models.JobStatusSucceeded
+			job.LastError = ""
+			i.saveJob(ctx, job)
+			logger.Log.Infof("Ingestor: job %s succeeded for song %d", id, song.ID)
+			return
+		}
+	}
+
+	job.LastError = err.Error()
+	// Повтор не поможет, если песни нет во внешнем API или ее данные
+	// нельзя сохранить из-за уникальности группы и названия
+	permanent := errors.Is(err, enricher.ErrNotFound) || errors.Is(err, repository.ErrDuplicate)
+	if permanent || job.Attempts >= job.MaxAttempts {
+		logger.Log.Errorf("Ingestor: job %s failed after %d attempts: %v", id, job.Attempts, err)
+		job.Status = models.JobStatusFailed
+		i.saveJob(ctx, job)
+
+		if err := i.songs.SetStatus(ctx, song.ID, models.SongStatusFailed); err != nil {
+			logger.Log.Errorf("Ingestor: failed to mark song %d as failed: %v", song.ID, err)
+		}
+		return
+	}
+
+	delay := i.retryDelay(job.Attempts)
+	logger.Log.Warnf("Ingestor: job %s attempt %d failed, retrying in %s: %v", id, job.Attempts, delay, err)
+	job.Status = models.JobStatusQueued
+	i.saveJob(ctx, job)
+	i.retryLater(id, delay)
+}
+
+// retryDelay экспоненциальная задержка перед повтором после attempt попыток,
+// не больше maxRetryDelay, с равномерным разбросом в пределах ее половины:
+// задачи, упавшие одновременно, не повторяются все разом
+func (i *Ingestor) retryDelay(attempt int) time.Duration {
+	d := maxRetryDelay
+	if i.cfg.RetryDelay < maxRetryDelay>>(attempt-1) {
+		d = i.cfg.RetryDelay << (attempt - 1)
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(mathrand.Int64N(int64(d/2)+1))
+}
+
+func (i *Ingestor) saveJob(ctx context.Context, job *models.Job) {
+	if err := i.jobs.Update(ctx, job); err != nil {
+		logger.Log.Errorf("Ingestor: failed to update job %s: %v", job.ID, err)
+	}
+}
+
+func newJobID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
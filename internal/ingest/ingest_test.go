@@ -0,0 +1,203 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/w212w/GoProjectEM/internal/enricher"
+	"github.com/w212w/GoProjectEM/internal/logger"
+	"github.com/w212w/GoProjectEM/internal/models"
+	"github.com/w212w/GoProjectEM/internal/repository"
+)
+
+func TestMain(m *testing.M) {
+	logger.Log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// stubEnricher возвращает ошибки errs по порядку, а после них - метаданные песни
+type stubEnricher struct {
+	errs  []error
+	calls atomic.Int32
+}
+
+func (e *stubEnricher) Enrich(ctx context.Context, group, song string) (*enricher.SongInfo, error) {
+	n := int(e.calls.Add(1))
+	if n <= len(e.errs) {
+		return nil, e.errs[n-1]
+	}
+	return &enricher.SongInfo{Artist: "Matthew Bellamy", ReleaseDate: "2009", Text: "Paranoia is in bloom"}, nil
+}
+
+// duplicateSongs хранилище, в котором любое изменение песни нарушает уникальность
+type duplicateSongs struct {
+	*repository.MemorySongRepository
+}
+
+func (r duplicateSongs) Update(ctx context.Context, song *models.Song) error {
+	if song.Status == models.SongStatusReady {
+		return repository.ErrDuplicate
+	}
+	return r.MemorySongRepository.Update(ctx, song)
+}
+
+func testConfig() Config {
+	return Config{Workers: 2, QueueSize: 10, MaxAttempts: 3, RetryDelay: time.Millisecond, Timeout: time.Second}
+}
+
+func startIngestor(t *testing.T, songs repository.SongRepository, e enricher.Enricher, cfg Config) (*Ingestor, *repository.MemoryJobRepository) {
+	t.Helper()
+
+	jobs := repository.NewMemoryJobRepository()
+	ingestor := New(songs, jobs, e, cfg)
+	if err := ingestor.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(ingestor.Stop)
+	return ingestor, jobs
+}
+
+// waitJob ждет, пока задача завершится
+func waitJob(t *testing.T, jobs repository.JobRepository, id string) *models.Job {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := jobs.Get(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Status == models.JobStatusSucceeded || job.Status == models.JobStatusFailed {
+			return job
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("job %s is not finished", id)
+	return nil
+}
+
+func TestIngestorProcess(t *testing.T) {
+	tests := []struct {
+		name     string
+		errs     []error
+		status   string
+		song     string
+		attempts int
+	}{
+		{"enriched", nil, models.JobStatusSucceeded, models.SongStatusReady, 1},
+		{"retried", []error{enricher.ErrUnavailable, enricher.ErrBadPayload}, models.JobStatusSucceeded, models.SongStatusReady, 3},
+		{"attempts exhausted", []error{enricher.ErrUnavailable, enricher.ErrUnavailable, enricher.ErrUnavailable}, models.JobStatusFailed, models.SongStatusFailed, 3},
+		{"not found is not retried", []error{enricher.ErrNotFound}, models.JobStatusFailed, models.SongStatusFailed, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			songs := repository.NewMemorySongRepository()
+			ingestor, jobs := startIngestor(t, songs, &stubEnricher{errs: tt.errs}, testConfig())
+
+			job, err := ingestor.Submit(context.Background(), &models.Song{Group: "Muse", Title: "Uprising"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			job = waitJob(t, jobs, job.ID)
+			if job.Status != tt.status || job.Attempts != tt.attempts {
+				t.Errorf("job = %s after %d attempts, want %s after %d", job.Status, job.Attempts, tt.status, tt.attempts)
+			}
+
+			song, err := songs.Get(context.Background(), job.SongID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if song.Status != tt.song {
+				t.Errorf("song status = %s, want %s", song.Status, tt.song)
+			}
+			if tt.song == models.SongStatusReady && song.Artist != "Matthew Bellamy" {
+				t.Errorf("song is not enriched: %+v", song)
+			}
+		})
+	}
+}
+
+func TestIngestorDuplicateIsNotRetried(t *testing.T) {
+	songs := duplicateSongs{repository.NewMemorySongRepository()}
+	ingestor, jobs := startIngestor(t, songs, &stubEnricher{}, testConfig())
+
+	job, err := ingestor.Submit(context.Background(), &models.Song{Group: "Muse", Title: "Uprising"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job = waitJob(t, jobs, job.ID)
+	if job.Status != models.JobStatusFailed || job.Attempts != 1 {
+		t.Errorf("job = %s after %d attempts, want failed after 1", job.Status, job.Attempts)
+	}
+
+	song, err := songs.Get(context.Background(), job.SongID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if song.Status != models.SongStatusFailed {
+		t.Errorf("song status = %s, want failed", song.Status)
+	}
+}
+
+func TestIngestorQueueFull(t *testing.T) {
+	ctx := context.Background()
+	songs := repository.NewMemorySongRepository()
+	cfg := testConfig()
+	cfg.QueueSize = 1
+	// Обработчики не запущены: первая задача остается в очереди
+	ingestor := New(songs, repository.NewMemoryJobRepository(), &stubEnricher{}, cfg)
+
+	if _, err := ingestor.Submit(ctx, &models.Song{Group: "Muse", Title: "Uprising"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ingestor.Submit(ctx, &models.Song{Group: "Queen", Title: "Bohemian Rhapsody"}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("new song: err = %v, want ErrQueueFull", err)
+	}
+	if _, err := songs.FindByNaturalKey(ctx, "Queen", "Bohemian Rhapsody"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("song was saved although the queue is full: %v", err)
+	}
+
+	existing := &models.Song{Group: "Muse", Title: "Starlight", Status: models.SongStatusReady}
+	if err := songs.Create(ctx, existing); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ingestor.Submit(ctx, existing); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("existing song: err = %v, want ErrQueueFull", err)
+	}
+	stored, err := songs.Get(ctx, existing.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	revisions, err := songs.ListRevisions(ctx, existing.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != models.SongStatusReady || stored.Version != 1 || len(revisions) != 1 {
+		t.Errorf("existing song changed: status %s, version %d, %d revisions", stored.Status, stored.Version, len(revisions))
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	ingestor := New(nil, nil, nil, Config{RetryDelay: time.Second})
+	for attempt := 1; attempt <= 100; attempt++ {
+		limit := maxRetryDelay
+		if attempt <= 10 {
+			limit = time.Second << (attempt - 1)
+		}
+		for range 20 {
+			if d := ingestor.retryDelay(attempt); d < limit/2 || d > limit {
+				t.Fatalf("retryDelay(%d) = %s, want within [%s, %s]", attempt, d, limit/2, limit)
+			}
+		}
+	}
+
+	if d := New(nil, nil, nil, Config{}).retryDelay(3); d != 0 {
+		t.Errorf("retryDelay without RetryDelay = %s, want 0", d)
+	}
+}
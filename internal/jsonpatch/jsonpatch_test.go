@@ -0,0 +1,135 @@
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// equalJSON сравнивает документы без учета порядка ключей и пробелов
+func equalJSON(t *testing.T, got []byte, want string) bool {
+	t.Helper()
+
+	var g, w any
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("result is not JSON: %s", got)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("want is not JSON: %s", want)
+	}
+	gb, _ := json.Marshal(g)
+	wb, _ := json.Marshal(w)
+	return bytes.Equal(gb, wb)
+}
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{"replace field", `{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"add field", `{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{"remove field", `{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{"remove missing field", `{"a":"b"}`, `{"c":null}`, `{"a":"b"}`},
+		{"replace array", `{"a":["b"]}`, `{"a":["c","d"]}`, `{"a":["c","d"]}`},
+		{"nested", `{"a":{"b":"c","d":"e"}}`, `{"a":{"b":"x","d":null}}`, `{"a":{"b":"x"}}`},
+		{"object over scalar", `{"a":"b"}`, `{"a":{"c":"d"}}`, `{"a":{"c":"d"}}`},
+		{"non-object patch", `{"a":"b"}`, `["c"]`, `["c"]`},
+		{"empty patch", `{"a":"b"}`, `{}`, `{"a":"b"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergePatch([]byte(tt.doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("MergePatch: %v", err)
+			}
+			if !equalJSON(t, got, tt.want) {
+				t.Errorf("MergePatch = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := MergePatch([]byte(`{}`), []byte(`{"a":`)); !errors.Is(err, ErrInvalidPatch) {
+		t.Errorf("malformed patch: err = %v, want ErrInvalidPatch", err)
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	const doc = `{"title":"Uprising","tags":["rock","live"],"meta":{"a/b":1,"m~n":2}}`
+
+	tests := []struct {
+		name  string
+		patch string
+		want  string
+	}{
+		{"add field", `[{"op":"add","path":"/artist","value":"Muse"}]`,
+			`{"title":"Uprising","artist":"Muse","tags":["rock","live"],"meta":{"a/b":1,"m~n":2}}`},
+		{"add replaces field", `[{"op":"add","path":"/title","value":"Resistance"}]`,
+			`{"title":"Resistance","tags":["rock","live"],"meta":{"a/b":1,"m~n":2}}`},
+		{"insert into array", `[{"op":"add","path":"/tags/1","value":"alt"}]`,
+			`{"title":"Uprising","tags":["rock","alt","live"],"meta":{"a/b":1,"m~n":2}}`},
+		{"append to array", `[{"op":"add","path":"/tags/-","value":"alt"}]`,
+			`{"title":"Uprising","tags":["rock","live","alt"],"meta":{"a/b":1,"m~n":2}}`},
+		{"remove field", `[{"op":"remove","path":"/meta"}]`,
+			`{"title":"Uprising","tags":["rock","live"]}`},
+		{"remove from array", `[{"op":"remove","path":"/tags/0"}]`,
+			`{"title":"Uprising","tags":["live"],"meta":{"a/b":1,"m~n":2}}`},
+		{"replace", `[{"op":"replace","path":"/title","value":null}]`,
+			`{"title":null,"tags":["rock","live"],"meta":{"a/b":1,"m~n":2}}`},
+		{"escaped pointer", `[{"op":"replace","path":"/meta/a~1b","value":3},{"op":"remove","path":"/meta/m~0n"}]`,
+			`{"title":"Uprising","tags":["rock","live"],"meta":{"a/b":3}}`},
+		{"move", `[{"op":"move","from":"/title","path":"/name"}]`,
+			`{"name":"Uprising","tags":["rock","live"],"meta":{"a/b":1,"m~n":2}}`},
+		{"copy", `[{"op":"copy","from":"/tags/0","path":"/genre"}]`,
+			`{"title":"Uprising","genre":"rock","tags":["rock","live"],"meta":{"a/b":1,"m~n":2}}`},
+		{"test then replace", `[{"op":"test","path":"/tags","value":["rock","live"]},{"op":"replace","path":"/tags/1","value":"studio"}]`,
+			`{"title":"Uprising","tags":["rock","studio"],"meta":{"a/b":1,"m~n":2}}`},
+		{"replace root", `[{"op":"replace","path":"","value":{"x":1}}]`, `{"x":1}`},
+		{"empty patch", `[]`, doc},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyPatch([]byte(doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("ApplyPatch: %v", err)
+			}
+			if !equalJSON(t, got, tt.want) {
+				t.Errorf("ApplyPatch = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyPatchErrors(t *testing.T) {
+	const doc = `{"title":"Uprising","tags":["rock"]}`
+
+	tests := []struct {
+		name  string
+		patch string
+		want  error
+	}{
+		{"not an array", `{"op":"remove","path":"/title"}`, ErrInvalidPatch},
+		{"unknown op", `[{"op":"rename","path":"/title"}]`, ErrInvalidPatch},
+		{"missing value", `[{"op":"add","path":"/artist"}]`, ErrInvalidPatch},
+		{"relative path", `[{"op":"remove","path":"title"}]`, ErrInvalidPatch},
+		{"test failed", `[{"op":"test","path":"/title","value":"Resistance"}]`, ErrTestFailed},
+		{"remove missing field", `[{"op":"remove","path":"/artist"}]`, ErrPathNotFound},
+		{"replace missing field", `[{"op":"replace","path":"/artist","value":"Muse"}]`, ErrPathNotFound},
+		{"add under missing parent", `[{"op":"add","path":"/album/name","value":"x"}]`, ErrPathNotFound},
+		{"array index out of range", `[{"op":"add","path":"/tags/2","value":"x"}]`, ErrPathNotFound},
+		{"array index with leading zero", `[{"op":"remove","path":"/tags/00"}]`, ErrPathNotFound},
+		{"move from missing field", `[{"op":"move","from":"/artist","path":"/name"}]`, ErrPathNotFound},
+		{"path into scalar", `[{"op":"add","path":"/title/x","value":1}]`, ErrPathNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ApplyPatch([]byte(doc), []byte(tt.patch))
+			if !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
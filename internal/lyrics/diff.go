@@ -0,0 +1,185 @@
+package lyrics
+
+import (
+	"strings"
+
+	"github.com/w212w/GoProjectEM/internal/models"
+)
+
+// Diff сравнивает два текста песни по секциям. Соседние удаленная и добавленная
+// секции одного типа считаются измененной секцией с построчной разницей.
+func Diff(oldText, newText string) []models.SectionDiff {
+	oldSections, newSections := Parse(oldText), Parse(newText)
+
+	oldKeys := make([]string, len(oldSections))
+	for i, s := range oldSections {
+		oldKeys[i] = normalize(s.Text)
+	}
+	newKeys := make([]string, len(newSections))
+	for i, s := range newSections {
+		newKeys[i] = normalize(s.Text)
+	}
+
+	var diffs []models.SectionDiff
+	for _, op := range diffOps(oldKeys, newKeys) {
+		switch op.kind {
+		case models.DiffEqual:
+			s := newSections[op.newIndex]
+			diffs = append(diffs, models.SectionDiff{Op: models.DiffEqual, Type: s.Type, Index: s.Index, To: s.Text})
+		case models.DiffRemoved:
+			s := oldSections[op.oldIndex]
+			diffs = append(diffs, models.SectionDiff{Op: models.DiffRemoved, Type: s.Type, Index: s.Index, From: s.Text})
+		case models.DiffAdded:
+			s := newSections[op.newIndex]
+			if n := len(diffs); n > 0 && diffs[n-1].Op == models.DiffRemoved && diffs[n-1].Type == s.Type {
+				diffs[n-1] = models.SectionDiff{
+					Op:    models.DiffModified,
+					Type:  s.Type,
+					Index: s.Index,
+					From:  diffs[n-1].From,
+					To:    s.Text,
+					Lines: diffLines(diffs[n-1].From, s.Text),
+				}
+				continue
+			}
+			diffs = append(diffs, models.SectionDiff{Op: models.DiffAdded, Type: s.Type, Index: s.Index, To: s.Text})
+		}
+	}
+	if diffs == nil {
+		diffs = []models.SectionDiff{}
+	}
+	return diffs
+}
+
+func diffLines(oldText, newText string) []models.LineDiff {
+	oldLines, newLines := strings.Split(oldText, "\n"), strings.Split(newText, "\n")
+
+	lines := make([]models.LineDiff, 0, len(newLines))
+	for _, op := range diffOps(oldLines, newLines) {
+		switch op.kind {
+		case models.DiffRemoved:
+			lines = append(lines, models.LineDiff{Op: op.kind, Text: oldLines[op.oldIndex]})
+		default:
+			lines = append(lines, models.LineDiff{Op: op.kind, Text: newLines[op.newIndex]})
+		}
+	}
+	return lines
+}
+
+type diffOp struct {
+	kind     string
+	oldIndex int
+	newIndex int
+}
+
+// maxDiffCells ограничивает произведение длин сравниваемых последовательностей
+// после отбрасывания общих начала и конца. Сравнение занимает время O(n*m),
+// поэтому более длинные последовательности целиком заменяются без поиска общих частей.
+const maxDiffCells = 1 << 24
+
+// diffOps строит последовательность правок по наибольшей общей подпоследовательности.
+// Удаления выдаются перед добавлениями, чтобы Diff мог склеить их в изменение.
+// Общая подпоследовательность ищется алгоритмом Хиршберга в памяти O(n+m).
+func diffOps(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	var matches []diffOp
+	if len(midA)*len(midB) <= maxDiffCells {
+		hirschberg(midA, midB, prefix, prefix, &matches)
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b)-prefix-suffix)
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, diffOp{kind: models.DiffEqual, oldIndex: i, newIndex: i})
+	}
+	i, j := prefix, prefix
+	emitGap := func(toA, toB int) {
+		for ; i < toA; i++ {
+			ops = append(ops, diffOp{kind: models.DiffRemoved, oldIndex: i})
+		}
+		for ; j < toB; j++ {
+			ops = append(ops, diffOp{kind: models.DiffAdded, newIndex: j})
+		}
+	}
+	for _, match := range matches {
+		emitGap(match.oldIndex, match.newIndex)
+		ops = append(ops, match)
+		i++
+		j++
+	}
+	emitGap(len(a)-suffix, len(b)-suffix)
+	for k := 0; k < suffix; k++ {
+		ops = append(ops, diffOp{kind: models.DiffEqual, oldIndex: i + k, newIndex: j + k})
+	}
+	return ops
+}
+
+// hirschberg добавляет в matches пары совпадающих элементов наибольшей общей
+// подпоследовательности a и b по возрастанию индексов. aOff и bOff - смещения
+// a и b в исходных последовательностях.
+func hirschberg(a, b []string, aOff, bOff int, matches *[]diffOp) {
+	if len(a) == 0 || len(b) == 0 {
+		return
+	}
+	if len(a) == 1 {
+		for j := range b {
+			if a[0] == b[j] {
+				*matches = append(*matches, diffOp{kind: models.DiffEqual, oldIndex: aOff, newIndex: bOff + j})
+				return
+			}
+		}
+		return
+	}
+
+	mid := len(a) / 2
+	head, tail := lcsPrefix(a[:mid], b), lcsSuffix(a[mid:], b)
+	split, best := 0, -1
+	for k := 0; k <= len(b); k++ {
+		if n := head[k] + tail[k]; n > best {
+			split, best = k, n
+		}
+	}
+
+	hirschberg(a[:mid], b[:split], aOff, bOff, matches)
+	hirschberg(a[mid:], b[split:], aOff+mid, bOff+split, matches)
+}
+
+// lcsPrefix возвращает длины наибольших общих подпоследовательностей a и b[:k] для всех k
+func lcsPrefix(a, b []string) []int {
+	prev, cur := make([]int, len(b)+1), make([]int, len(b)+1)
+	for i := range a {
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(cur[j], prev[j+1])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev
+}
+
+// lcsSuffix возвращает длины наибольших общих подпоследовательностей a и b[k:] для всех k
+func lcsSuffix(a, b []string) []int {
+	prev, cur := make([]int, len(b)+1), make([]int, len(b)+1)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				cur[j] = prev[j+1] + 1
+			} else {
+				cur[j] = max(cur[j+1], prev[j])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev
+}
@@ -0,0 +1,158 @@
+package lyrics
+
+import (
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/w212w/GoProjectEM/internal/models"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		old  string
+		new  string
+		want []models.SectionDiff
+	}{
+		{"both empty", "", "", []models.SectionDiff{}},
+		{
+			"equal ignoring case and spaces",
+			"[Verse]\nHello  world",
+			"[Verse]\nhello world",
+			[]models.SectionDiff{{Op: models.DiffEqual, Type: Verse, Index: 1, To: "hello world"}},
+		},
+		{
+			"added",
+			"[Verse]\none",
+			"[Verse]\none\n\n[Chorus]\nla la",
+			[]models.SectionDiff{
+				{Op: models.DiffEqual, Type: Verse, Index: 1, To: "one"},
+				{Op: models.DiffAdded, Type: Chorus, Index: 1, To: "la la"},
+			},
+		},
+		{
+			"removed",
+			"[Verse]\none\n\n[Chorus]\nla la",
+			"[Chorus]\nla la",
+			[]models.SectionDiff{
+				{Op: models.DiffRemoved, Type: Verse, Index: 1, From: "one"},
+				{Op: models.DiffEqual, Type: Chorus, Index: 1, To: "la la"},
+			},
+		},
+		{
+			"modified",
+			"[Verse]\nfirst\nsecond\nthird\n\n[Chorus]\nla la",
+			"[Verse]\nfirst\n2nd\nthird\nfourth\n\n[Chorus]\nla la",
+			[]models.SectionDiff{
+				{
+					Op: models.DiffModified, Type: Verse, Index: 1,
+					From: "first\nsecond\nthird", To: "first\n2nd\nthird\nfourth",
+					Lines: []models.LineDiff{
+						{Op: models.DiffEqual, Text: "first"},
+						{Op: models.DiffRemoved, Text: "second"},
+						{Op: models.DiffAdded, Text: "2nd"},
+						{Op: models.DiffEqual, Text: "third"},
+						{Op: models.DiffAdded, Text: "fourth"},
+					},
+				},
+				{Op: models.DiffEqual, Type: Chorus, Index: 1, To: "la la"},
+			},
+		},
+		{
+			"replaced with another type",
+			"[Verse]\none",
+			"[Bridge]\nup",
+			[]models.SectionDiff{
+				{Op: models.DiffRemoved, Type: Verse, Index: 1, From: "one"},
+				{Op: models.DiffAdded, Type: Bridge, Index: 1, To: "up"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff(tt.old, tt.new); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// applyOps восстанавливает обе последовательности по правкам, проверяя их корректность
+func applyOps(t *testing.T, a, b []string, ops []diffOp) (equal int) {
+	t.Helper()
+
+	var gotA, gotB []string
+	for _, op := range ops {
+		switch op.kind {
+		case models.DiffEqual:
+			if a[op.oldIndex] != b[op.newIndex] {
+				t.Fatalf("equal op pairs %q and %q", a[op.oldIndex], b[op.newIndex])
+			}
+			gotA, gotB = append(gotA, a[op.oldIndex]), append(gotB, b[op.newIndex])
+			equal++
+		case models.DiffRemoved:
+			gotA = append(gotA, a[op.oldIndex])
+		case models.DiffAdded:
+			gotB = append(gotB, b[op.newIndex])
+		}
+	}
+	if !slices.Equal(gotA, a) || !slices.Equal(gotB, b) {
+		t.Fatalf("ops do not rebuild inputs: %v -> %v, %v -> %v", a, gotA, b, gotB)
+	}
+	return equal
+}
+
+func TestDiffOps(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		equal int
+	}{
+		{"", "", 0},
+		{"a b c", "", 0},
+		{"", "a b c", 0},
+		{"a b c", "a b c", 3},
+		{"a b c d", "a x c d", 3},
+		{"a b c a b b a", "c b a b a c", 4},
+		{"x a b c y", "a b c", 3},
+		{"a a a b", "b a a a", 3},
+		{"a b", "c d", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"|"+tt.b, func(t *testing.T) {
+			a, b := strings.Fields(tt.a), strings.Fields(tt.b)
+			if got := applyOps(t, a, b, diffOps(a, b)); got != tt.equal {
+				t.Errorf("common elements = %d, want %d", got, tt.equal)
+			}
+		})
+	}
+}
+
+func TestDiffOpsRemovalsBeforeAdditions(t *testing.T) {
+	ops := diffOps(strings.Fields("a b c"), strings.Fields("a x c"))
+	kinds := make([]string, len(ops))
+	for i, op := range ops {
+		kinds[i] = op.kind
+	}
+	want := []string{models.DiffEqual, models.DiffRemoved, models.DiffAdded, models.DiffEqual}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Errorf("ops = %v, want %v", kinds, want)
+	}
+}
+
+func TestDiffOpsOverCap(t *testing.T) {
+	// Середины длиннее корня из maxDiffCells не сравниваются, хотя у них
+	// много общих строк: середина целиком заменяется
+	common := make([]string, 1<<12)
+	for i := range common {
+		common[i] = "line " + strconv.Itoa(i)
+	}
+	a := slices.Concat([]string{"start", "old"}, common, []string{"old", "end"})
+	b := slices.Concat([]string{"start", "new"}, common, []string{"new", "end"})
+
+	if got := applyOps(t, a, b, diffOps(a, b)); got != 2 {
+		t.Errorf("common elements = %d, want only the prefix and suffix", got)
+	}
+}
@@ -0,0 +1,166 @@
+package lyrics
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/w212w/GoProjectEM/internal/models"
+)
+
+// Типы секций текста песни
+const (
+	Verse  = "verse"
+	Chorus = "chorus"
+	Bridge = "bridge"
+	Intro  = "intro"
+	Outro  = "outro"
+)
+
+var (
+	markerRe    = regexp.MustCompile(`^\s*[\[(]\s*([^\])]+?)\s*[\])]\s*:?\s*$`)
+	blankLineRe = regexp.MustCompile(`\n\s*\n`)
+)
+
+// markerTypes сопоставляет первое слово маркера ("[Chorus 2]", "[Припев]") с типом секции
+var markerTypes = map[string]string{
+	"verse":       Verse,
+	"куплет":      Verse,
+	"chorus":      Chorus,
+	"refrain":     Chorus,
+	"hook":        Chorus,
+	"припев":      Chorus,
+	"bridge":      Bridge,
+	"бридж":       Bridge,
+	"intro":       Intro,
+	"вступление":  Intro,
+	"интро":       Intro,
+	"outro":       Outro,
+	"аутро":       Outro,
+	"концовка":    Outro,
+	"pre-chorus":  Bridge,
+	"предприпев":  Bridge,
+	"post-chorus": Chorus,
+}
+
+// IsType сообщает, является ли t известным типом секции
+func IsType(t string) bool {
+	switch t {
+	case Verse, Chorus, Bridge, Intro, Outro:
+		return true
+	}
+	return false
+}
+
+// Parse разбивает текст песни на секции по пустым строкам. Тип секции берется
+// из маркера в первой строке блока ("[Chorus]", "[Куплет 2]"); маркер без текста
+// повторяет последнюю секцию того же типа. Если маркеров в тексте нет, блоки,
+// встречающиеся несколько раз, считаются припевом, остальные - куплетами.
+func Parse(text string) []models.SongSection {
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+	if text == "" {
+		return []models.SongSection{}
+	}
+
+	blocks := blankLineRe.Split(text, -1)
+	types := make([]string, len(blocks))
+	hasMarkers := false
+
+	last := make(map[string]string)
+	for i, block := range blocks {
+		first, rest, _ := strings.Cut(block, "\n")
+		t, ok := markerType(first)
+		if !ok {
+			continue
+		}
+		hasMarkers = true
+		types[i] = t
+
+		rest = strings.TrimSpace(rest)
+		if rest == "" {
+			rest = last[t]
+		}
+		blocks[i] = rest
+		last[t] = rest
+	}
+
+	if !hasMarkers {
+		seen := make(map[string]int)
+		for _, block := range blocks {
+			seen[normalize(block)]++
+		}
+		for i, block := range blocks {
+			if seen[normalize(block)] > 1 {
+				types[i] = Chorus
+			}
+		}
+	}
+
+	sections := make([]models.SongSection, 0, len(blocks))
+	indexes := make(map[string]int)
+	for i, block := range blocks {
+		t := types[i]
+		if t == "" {
+			t = Verse
+		}
+		indexes[t]++
+		sections = append(sections, models.SongSection{
+			Type:     t,
+			Index:    indexes[t],
+			Position: i + 1,
+			Text:     strings.TrimSpace(block),
+		})
+	}
+	return sections
+}
+
+// FilterType оставляет только секции указанного типа
+func FilterType(sections []models.SongSection, t string) []models.SongSection {
+	filtered := make([]models.SongSection, 0, len(sections))
+	for _, s := range sections {
+		if s.Type == t {
+			filtered = append(filtered, s)
+		}
+	}
+	return filtered
+}
+
+// CollapseRepeats убирает повторы припева с тем же текстом, оставляя первое
+// вхождение с количеством повторов в Repeats.
+func CollapseRepeats(sections []models.SongSection) []models.SongSection {
+	collapsed := make([]models.SongSection, 0, len(sections))
+	first := make(map[string]int)
+	for _, s := range sections {
+		if s.Type != Chorus {
+			collapsed = append(collapsed, s)
+			continue
+		}
+		key := normalize(s.Text)
+		if i, ok := first[key]; ok {
+			collapsed[i].Repeats++
+			continue
+		}
+		s.Repeats = 1
+		first[key] = len(collapsed)
+		collapsed = append(collapsed, s)
+	}
+	return collapsed
+}
+
+func markerType(line string) (string, bool) {
+	m := markerRe.FindStringSubmatch(line)
+	if m == nil {
+		return "", false
+	}
+	fields := strings.Fields(m[1])
+	if len(fields) == 0 {
+		return "", false
+	}
+	label := strings.ToLower(fields[0])
+	label = strings.TrimRight(label, ":0123456789")
+	t, ok := markerTypes[label]
+	return t, ok
+}
+
+func normalize(block string) string {
+	return strings.Join(strings.Fields(strings.ToLower(block)), " ")
+}
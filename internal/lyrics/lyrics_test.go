@@ -0,0 +1,111 @@
+package lyrics
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/w212w/GoProjectEM/internal/models"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []models.SongSection
+	}{
+		{"empty", "  \n\n ", []models.SongSection{}},
+		{
+			"markers",
+			"[Verse 1]\nline one\nline two\n\n[Chorus]\nla la\n\n[Куплет 2]:\nстрока\n\n(Bridge)\nbridge line",
+			[]models.SongSection{
+				{Type: Verse, Index: 1, Position: 1, Text: "line one\nline two"},
+				{Type: Chorus, Index: 1, Position: 2, Text: "la la"},
+				{Type: Verse, Index: 2, Position: 3, Text: "строка"},
+				{Type: Bridge, Index: 1, Position: 4, Text: "bridge line"},
+			},
+		},
+		{
+			"empty marker repeats last section",
+			"[Intro]\nhey\n\n[Припев]\nla la\n\n[Verse]\nwords\n\n[Припев]\n\n[Outro]\nbye",
+			[]models.SongSection{
+				{Type: Intro, Index: 1, Position: 1, Text: "hey"},
+				{Type: Chorus, Index: 1, Position: 2, Text: "la la"},
+				{Type: Verse, Index: 1, Position: 3, Text: "words"},
+				{Type: Chorus, Index: 2, Position: 4, Text: "la la"},
+				{Type: Outro, Index: 1, Position: 5, Text: "bye"},
+			},
+		},
+		{
+			"unknown marker is text",
+			"[Guitar solo]\nda da\n\n[Pre-Chorus]\nup",
+			[]models.SongSection{
+				{Type: Verse, Index: 1, Position: 1, Text: "[Guitar solo]\nda da"},
+				{Type: Bridge, Index: 1, Position: 2, Text: "up"},
+			},
+		},
+		{
+			"blank marker is text",
+			"[ ]\nda da\n\n( )\nup",
+			[]models.SongSection{
+				{Type: Verse, Index: 1, Position: 1, Text: "[ ]\nda da"},
+				{Type: Verse, Index: 2, Position: 2, Text: "( )\nup"},
+			},
+		},
+		{
+			"repeated blocks without markers are chorus",
+			"first verse\r\n\r\nLa  la\n\nsecond verse\n \nla la",
+			[]models.SongSection{
+				{Type: Verse, Index: 1, Position: 1, Text: "first verse"},
+				{Type: Chorus, Index: 1, Position: 2, Text: "La  la"},
+				{Type: Verse, Index: 2, Position: 3, Text: "second verse"},
+				{Type: Chorus, Index: 2, Position: 4, Text: "la la"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollapseRepeats(t *testing.T) {
+	sections := Parse("[Verse]\none\n\n[Chorus]\nla la\n\n[Verse]\ntwo\n\n[Chorus]\nLa la\n\n[Chorus]\nna na")
+
+	want := []models.SongSection{
+		{Type: Verse, Index: 1, Position: 1, Text: "one"},
+		{Type: Chorus, Index: 1, Position: 2, Text: "la la", Repeats: 2},
+		{Type: Verse, Index: 2, Position: 3, Text: "two"},
+		{Type: Chorus, Index: 3, Position: 5, Text: "na na", Repeats: 1},
+	}
+	if got := CollapseRepeats(sections); !reflect.DeepEqual(got, want) {
+		t.Errorf("CollapseRepeats =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestFilterType(t *testing.T) {
+	sections := Parse("[Verse]\none\n\n[Chorus]\nla la\n\n[Verse]\ntwo")
+
+	tests := []struct {
+		t    string
+		want []string
+	}{
+		{Verse, []string{"one", "two"}},
+		{Chorus, []string{"la la"}},
+		{Bridge, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.t, func(t *testing.T) {
+			got := FilterType(sections, tt.t)
+			texts := make([]string, len(got))
+			for i, s := range got {
+				texts[i] = s.Text
+			}
+			if !reflect.DeepEqual(texts, tt.want) {
+				t.Errorf("FilterType = %q, want %q", texts, tt.want)
+			}
+		})
+	}
+}
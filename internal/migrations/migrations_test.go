@@ -0,0 +1,73 @@
+package migrations
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sql/0002_add_index.up.sql":      {Data: []byte("CREATE INDEX")},
+		"sql/0002_add_index.down.sql":    {Data: []byte("DROP INDEX")},
+		"sql/0001_create_songs.up.sql":   {Data: []byte("CREATE TABLE")},
+		"sql/0001_create_songs.down.sql": {Data: []byte("DROP TABLE")},
+	}
+
+	migrations, err := load(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Migration{
+		{Version: 1, Name: "create_songs", Up: "CREATE TABLE", Down: "DROP TABLE"},
+		{Version: 2, Name: "add_index", Up: "CREATE INDEX", Down: "DROP INDEX"},
+	}
+	if len(migrations) != len(want) {
+		t.Fatalf("loaded %d migrations, want %d", len(migrations), len(want))
+	}
+	for i := range want {
+		if migrations[i] != want[i] {
+			t.Errorf("migration %d = %+v, want %+v", i, migrations[i], want[i])
+		}
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		err   string
+	}{
+		{"unexpected file", []string{"0001_init.up.sql", "0001_init.down.sql", "README.md"}, "unexpected migration file"},
+		{"no name", []string{"0001.up.sql", "0001.down.sql"}, "must be named"},
+		{"invalid version", []string{"first_init.up.sql", "first_init.down.sql"}, "invalid version"},
+		{"conflicting names", []string{"0001_init.up.sql", "0001_create.down.sql"}, "conflicting names"},
+		{"missing down", []string{"0001_init.up.sql"}, "both up and down"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{}
+			for _, name := range tt.files {
+				fsys["sql/"+name] = &fstest.MapFile{Data: []byte("SELECT 1")}
+			}
+			if _, err := load(fsys); err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("err = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := load(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no embedded migrations")
+	}
+	for i, mig := range migrations {
+		if mig.Version != i+1 {
+			t.Errorf("migration %04d_%s, want version %d: versions must have no gaps", mig.Version, mig.Name, i+1)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS songs (
+    id           bigserial PRIMARY KEY,
+    created_at   text,
+    updated_at   text,
+    artist       text,
+    title        text,
+    release_date text,
+    text         text,
+    link         text,
+    "group"      text
+);
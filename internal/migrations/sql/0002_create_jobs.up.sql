@@ -0,0 +1,15 @@
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS status text NOT NULL DEFAULT 'ready';
+
+CREATE TABLE IF NOT EXISTS jobs (
+    id           text PRIMARY KEY,
+    song_id      bigint,
+    status       text,
+    attempts     bigint,
+    max_attempts bigint,
+    last_error   text,
+    created_at   timestamptz,
+    updated_at   timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_song_id ON jobs (song_id);
+CREATE INDEX IF NOT EXISTS idx_jobs_status ON jobs (status);
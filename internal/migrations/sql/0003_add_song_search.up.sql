@@ -0,0 +1,24 @@
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS language text NOT NULL DEFAULT 'english';
+
+-- То же, что models.DetectLanguage: russian, если кириллицы больше, чем латиницы.
+-- Выполняется до создания search_vector, чтобы не пересчитывать его дважды.
+UPDATE songs SET language = 'russian'
+WHERE length(regexp_replace(concat_ws(' ', "group", title, text), '[^А-Яа-яЁё]', '', 'g')) >
+      length(regexp_replace(concat_ws(' ', "group", title, text), '[^A-Za-z]', '', 'g'));
+
+-- Приведение language::regconfig не является IMMUTABLE и недопустимо в
+-- генерируемой колонке, поэтому конфигурация выбирается через CASE.
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        CASE WHEN language = 'russian' THEN
+            setweight(to_tsvector('russian', coalesce(title, '')), 'A') ||
+            setweight(to_tsvector('russian', coalesce("group", '')), 'B') ||
+            setweight(to_tsvector('russian', coalesce(text, '')), 'C')
+        ELSE
+            setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+            setweight(to_tsvector('english', coalesce("group", '')), 'B') ||
+            setweight(to_tsvector('english', coalesce(text, '')), 'C')
+        END
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_songs_search_vector ON songs USING GIN (search_vector);
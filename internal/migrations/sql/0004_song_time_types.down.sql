@@ -0,0 +1,19 @@
+DROP INDEX IF EXISTS idx_songs_release_date;
+
+ALTER TABLE songs ADD COLUMN release_date_raw text;
+
+UPDATE songs SET release_date_raw = CASE release_date_precision
+    WHEN 'year' THEN to_char(release_date, 'YYYY')
+    WHEN 'month' THEN to_char(release_date, 'MM.YYYY')
+    ELSE to_char(release_date, 'DD.MM.YYYY')
+END
+WHERE release_date IS NOT NULL;
+
+ALTER TABLE songs
+    DROP COLUMN release_date,
+    DROP COLUMN release_date_precision;
+ALTER TABLE songs RENAME COLUMN release_date_raw TO release_date;
+
+ALTER TABLE songs
+    ALTER COLUMN created_at TYPE text USING created_at::text,
+    ALTER COLUMN updated_at TYPE text USING updated_at::text;
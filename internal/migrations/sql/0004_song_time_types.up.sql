@@ -0,0 +1,46 @@
+ALTER TABLE songs
+    ALTER COLUMN created_at TYPE timestamptz
+        USING CASE WHEN created_at ~ '^\d{4}-\d{2}-\d{2}' THEN created_at::timestamptz END,
+    ALTER COLUMN updated_at TYPE timestamptz
+        USING CASE WHEN updated_at ~ '^\d{4}-\d{2}-\d{2}' THEN updated_at::timestamptz END;
+
+UPDATE songs SET created_at = now() WHERE created_at IS NULL;
+UPDATE songs SET updated_at = created_at WHERE updated_at IS NULL;
+
+-- Дата релиза из внешнего API приходит строкой вида "16.07.2006";
+-- переводим ее в дату начала периода с точностью до дня, месяца или года.
+-- Строки другого вида и несуществующие даты (например, "31.02.2006")
+-- остаются NULL: to_date на них прервал бы всю миграцию.
+CREATE FUNCTION pg_temp.try_to_date(value text, format text) RETURNS date AS $$
+BEGIN
+    RETURN to_date(value, format);
+EXCEPTION WHEN others THEN
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE songs RENAME COLUMN release_date TO release_date_raw;
+ALTER TABLE songs
+    ADD COLUMN release_date date,
+    ADD COLUMN release_date_precision text;
+
+UPDATE songs SET
+    release_date = CASE
+        WHEN release_date_raw ~ '^\d{1,2}\.\d{1,2}\.\d{4}$' THEN pg_temp.try_to_date(release_date_raw, 'DD.MM.YYYY')
+        WHEN release_date_raw ~ '^\d{4}-\d{1,2}-\d{1,2}$' THEN pg_temp.try_to_date(release_date_raw, 'YYYY-MM-DD')
+        WHEN release_date_raw ~ '^\d{1,2}\.\d{4}$' THEN pg_temp.try_to_date(release_date_raw, 'MM.YYYY')
+        WHEN release_date_raw ~ '^\d{4}-\d{1,2}$' THEN pg_temp.try_to_date(release_date_raw, 'YYYY-MM')
+        WHEN release_date_raw ~ '^\d{4}$' THEN pg_temp.try_to_date(release_date_raw, 'YYYY')
+    END;
+
+UPDATE songs SET
+    release_date_precision = CASE
+        WHEN release_date_raw ~ '^(\d{1,2}\.\d{1,2}\.\d{4}|\d{4}-\d{1,2}-\d{1,2})$' THEN 'day'
+        WHEN release_date_raw ~ '^(\d{1,2}\.\d{4}|\d{4}-\d{1,2})$' THEN 'month'
+        WHEN release_date_raw ~ '^\d{4}$' THEN 'year'
+    END
+WHERE release_date IS NOT NULL;
+
+ALTER TABLE songs DROP COLUMN release_date_raw;
+
+CREATE INDEX IF NOT EXISTS idx_songs_release_date ON songs (release_date);
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS song_revisions (
+    id                     bigserial PRIMARY KEY,
+    song_id                bigint NOT NULL,
+    revision               integer NOT NULL,
+    changed_by             text,
+    changed_fields         jsonb,
+    note                   text,
+    "group"                text,
+    title                  text,
+    artist                 text,
+    release_date           date,
+    release_date_precision text,
+    text                   text,
+    link                   text,
+    created_at             timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_song_revisions_song_revision ON song_revisions (song_id, revision);
+
+-- Текущее состояние существующих песен становится их первой известной ревизией
+INSERT INTO song_revisions (song_id, revision, changed_by, changed_fields, note,
+                            "group", title, artist, release_date, release_date_precision, text, link, created_at)
+SELECT id, version, 'migration', '[]'::jsonb, 'initial revision',
+       "group", title, artist, release_date, release_date_precision, text, link, updated_at
+FROM songs
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,6 @@
+-- Песни из корзины удаляются окончательно, иначе после отката они снова станут видны
+DELETE FROM song_revisions WHERE song_id IN (SELECT id FROM songs WHERE deleted_at IS NOT NULL);
+DELETE FROM songs WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_songs_deleted_at;
+ALTER TABLE songs DROP COLUMN IF EXISTS deleted_at;
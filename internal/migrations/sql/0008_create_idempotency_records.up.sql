@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS idempotency_records (
+    key          text PRIMARY KEY,
+    request_hash text NOT NULL,
+    completed    boolean NOT NULL DEFAULT false,
+    status       integer,
+    header       jsonb,
+    body         bytea,
+    created_at   timestamptz NOT NULL DEFAULT now(),
+    expires_at   timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_records_expires_at ON idempotency_records (expires_at);
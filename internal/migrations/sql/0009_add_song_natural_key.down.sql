@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_songs_natural_key;
+ALTER TABLE songs DROP COLUMN IF EXISTS natural_key;
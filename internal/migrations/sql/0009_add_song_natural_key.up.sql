@@ -0,0 +1,19 @@
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS natural_key text;
+
+-- То же, что models.NaturalKey: регистр, пробелы и ё/е не различаются
+UPDATE songs SET natural_key =
+    btrim(regexp_replace(lower(translate("group", 'Ёё', 'Ее')), '\s+', ' ', 'g')) || chr(31) ||
+    btrim(regexp_replace(lower(translate(title, 'Ёё', 'Ее')), '\s+', ' ', 'g'));
+
+-- Уже существующие дубликаты остаются без ключа, пока их не объединят
+-- через POST /api/admin/duplicates/merge. Ключ пересчитывается при каждом
+-- изменении песни, поэтому изменение такого дубликата возвращает 409 со ссылкой
+-- на оригинал. Количество таких песен пишется в лог после migrate up и при запуске
+-- сервера, сами группы дубликатов возвращает GET /api/admin/duplicates.
+UPDATE songs s SET natural_key = NULL
+WHERE s.deleted_at IS NULL AND EXISTS (
+    SELECT 1 FROM songs o
+    WHERE o.natural_key = s.natural_key AND o.deleted_at IS NULL AND o.id < s.id
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_songs_natural_key ON songs (natural_key) WHERE deleted_at IS NULL;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS song_artists;
+DROP TABLE IF EXISTS song_groups;
+DROP TABLE IF EXISTS artists;
+DROP TABLE IF EXISTS "groups";
+DROP FUNCTION IF EXISTS normalize_name(text);
@@ -0,0 +1,63 @@
+-- То же, что models.NormalizeName
+CREATE OR REPLACE FUNCTION normalize_name(s text) RETURNS text
+    LANGUAGE sql IMMUTABLE AS
+$$ SELECT btrim(regexp_replace(lower(translate(s, 'Ёё', 'Ее')), '\s+', ' ', 'g')) $$;
+
+CREATE TABLE IF NOT EXISTS "groups" (
+    id              bigserial PRIMARY KEY,
+    name            text NOT NULL,
+    normalized_name text NOT NULL UNIQUE,
+    created_at      timestamptz NOT NULL DEFAULT now(),
+    updated_at      timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS artists (
+    id              bigserial PRIMARY KEY,
+    name            text NOT NULL,
+    normalized_name text NOT NULL UNIQUE,
+    created_at      timestamptz NOT NULL DEFAULT now(),
+    updated_at      timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS song_groups (
+    song_id  bigint NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    group_id bigint NOT NULL REFERENCES "groups" (id) ON DELETE CASCADE,
+    PRIMARY KEY (song_id, group_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_song_groups_group_id ON song_groups (group_id);
+
+CREATE TABLE IF NOT EXISTS song_artists (
+    song_id   bigint NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    artist_id bigint NOT NULL REFERENCES artists (id) ON DELETE CASCADE,
+    PRIMARY KEY (song_id, artist_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_song_artists_artist_id ON song_artists (artist_id);
+
+-- Перенос строковых полей песен в новые таблицы
+INSERT INTO "groups" (name, normalized_name)
+SELECT min(btrim(regexp_replace("group", '\s+', ' ', 'g'))), normalize_name("group")
+FROM songs
+WHERE normalize_name("group") <> ''
+GROUP BY normalize_name("group")
+ON CONFLICT (normalized_name) DO NOTHING;
+
+INSERT INTO song_groups (song_id, group_id)
+SELECT s.id, g.id
+FROM songs s
+JOIN "groups" g ON g.normalized_name = normalize_name(s."group")
+ON CONFLICT DO NOTHING;
+
+INSERT INTO artists (name, normalized_name)
+SELECT min(btrim(regexp_replace(artist, '\s+', ' ', 'g'))), normalize_name(artist)
+FROM songs
+WHERE normalize_name(artist) <> ''
+GROUP BY normalize_name(artist)
+ON CONFLICT (normalized_name) DO NOTHING;
+
+INSERT INTO song_artists (song_id, artist_id)
+SELECT s.id, a.id
+FROM songs s
+JOIN artists a ON a.normalized_name = normalize_name(s.artist)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS albums (
+    id                     bigserial PRIMARY KEY,
+    title                  text NOT NULL,
+    "group"                text,
+    group_id               bigint REFERENCES "groups" (id) ON DELETE SET NULL,
+    release_date           date,
+    release_date_precision text,
+    cover_link             text,
+    created_at             timestamptz NOT NULL DEFAULT now(),
+    updated_at             timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_albums_group_id ON albums (group_id);
+
+-- Уникальность позиции проверяется в конце транзакции, чтобы треки можно было переставлять
+CREATE TABLE IF NOT EXISTS album_tracks (
+    album_id bigint NOT NULL REFERENCES albums (id) ON DELETE CASCADE,
+    song_id  bigint NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    position integer NOT NULL,
+    PRIMARY KEY (album_id, song_id),
+    CONSTRAINT album_tracks_position_key UNIQUE (album_id, position) DEFERRABLE INITIALLY DEFERRED
+);
+
+CREATE INDEX IF NOT EXISTS idx_album_tracks_song_id ON album_tracks (song_id);
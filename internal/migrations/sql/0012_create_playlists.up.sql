@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS playlists (
+    id         bigserial PRIMARY KEY,
+    name       text NOT NULL,
+    owner      text NOT NULL,
+    visibility text NOT NULL DEFAULT 'private' CHECK (visibility IN ('public', 'private')),
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_playlists_owner ON playlists (owner);
+
+-- Порядок записей задается рангом: вставка между соседями не меняет остальные строки
+CREATE TABLE IF NOT EXISTS playlist_entries (
+    id          bigserial PRIMARY KEY,
+    playlist_id bigint NOT NULL REFERENCES playlists (id) ON DELETE CASCADE,
+    song_id     bigint NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    rank        double precision NOT NULL,
+    created_at  timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_playlist_entries_playlist_rank ON playlist_entries (playlist_id, rank);
+CREATE INDEX IF NOT EXISTS idx_playlist_entries_song_id ON playlist_entries (song_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS song_tags;
+DROP TABLE IF EXISTS song_genres;
+DROP TABLE IF EXISTS genres;
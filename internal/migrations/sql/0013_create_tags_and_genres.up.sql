@@ -0,0 +1,42 @@
+-- Контролируемый словарь жанров, совпадает с models.DefaultGenres
+CREATE TABLE IF NOT EXISTS genres (
+    id   bigserial PRIMARY KEY,
+    slug text NOT NULL UNIQUE,
+    name text NOT NULL
+);
+
+INSERT INTO genres (slug, name) VALUES
+    ('alternative', 'Alternative'),
+    ('blues', 'Blues'),
+    ('classical', 'Classical'),
+    ('country', 'Country'),
+    ('electronic', 'Electronic'),
+    ('folk', 'Folk'),
+    ('hip-hop', 'Hip-Hop'),
+    ('jazz', 'Jazz'),
+    ('metal', 'Metal'),
+    ('pop', 'Pop'),
+    ('punk', 'Punk'),
+    ('reggae', 'Reggae'),
+    ('rnb', 'R&B'),
+    ('rock', 'Rock'),
+    ('soul', 'Soul'),
+    ('soundtrack', 'Soundtrack')
+ON CONFLICT (slug) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS song_genres (
+    song_id  bigint NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    genre_id bigint NOT NULL REFERENCES genres (id) ON DELETE RESTRICT,
+    PRIMARY KEY (song_id, genre_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_song_genres_genre_id ON song_genres (genre_id);
+
+-- Свободные теги хранятся в нормализованном виде, см. models.NormalizeTag
+CREATE TABLE IF NOT EXISTS song_tags (
+    song_id bigint NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    tag     text NOT NULL,
+    PRIMARY KEY (song_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_song_tags_tag ON song_tags (tag);
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Album альбом с упорядоченным списком треков
+// @Description Альбом группы. Песня может входить в несколько альбомов
+type Album struct {
+	ID          uint        `json:"id"`
+	Title       string      `json:"title" gorm:"not null"`
+	Group       string      `json:"group"`
+	GroupID     *uint       `json:"group_id"`
+	ReleaseDate ReleaseDate `json:"release_date" gorm:"embedded;embeddedPrefix:release_" swaggertype:"string" example:"2006-07-03"`
+	CoverLink   string      `json:"cover_link"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+	// Tracks заполняется только при получении одного альбома
+	Tracks []AlbumTrack `json:"tracks,omitempty" gorm:"-"`
+}
+
+// AlbumTrack трек альбома
+// @Description Песня на позиции в альбоме
+type AlbumTrack struct {
+	AlbumID  uint   `json:"-" gorm:"primaryKey"`
+	SongID   uint   `json:"song_id" gorm:"primaryKey"`
+	Position int    `json:"position"`
+	Title    string `json:"title" gorm:"->;-:migration"`
+}
+
+// AlbumRequest данные для создания альбома
+// @Description Альбом и, при необходимости, его треки в порядке song_ids
+type AlbumRequest struct {
+	Title       string `json:"title" validate:"required,max=255" example:"Black Holes and Revelations"`
+	Group       string `json:"group" validate:"max=255" example:"Muse"`
+	ReleaseDate string `json:"release_date" validate:"release_date" example:"03.07.2006"`
+	CoverLink   string `json:"cover_link" validate:"max=2048,url"`
+	SongIDs     []uint `json:"song_ids" example:"1,2"`
+}
+
+// AlbumTracksRequest новый порядок треков альбома
+// @Description Полный список ID песен альбома в нужном порядке: отсутствующие в списке песни исключаются из альбома
+type AlbumTracksRequest struct {
+	SongIDs []uint `json:"song_ids" example:"2,1"`
+}
@@ -0,0 +1,31 @@
+package models
+
+// DuplicateGroup песни с совпадающим SimilarityKey
+// @Description Группа похожих песен, кандидатов на объединение
+type DuplicateGroup struct {
+	Key   string `json:"key"`
+	Songs []Song `json:"songs"`
+}
+
+// MergeSongsRequest данные для объединения дубликатов
+// @Description Песни source_ids переносятся в корзину, их незаполненные у target_id поля копируются в target_id
+type MergeSongsRequest struct {
+	TargetID  uint   `json:"target_id" example:"1"`
+	SourceIDs []uint `json:"source_ids" example:"2,3"`
+}
+
+// FillMissing копирует из other поля, которые не заполнены у песни
+func (s *Song) FillMissing(other *Song) {
+	if s.Artist == "" {
+		s.Artist = other.Artist
+	}
+	if s.ReleaseDate.IsZero() {
+		s.ReleaseDate = other.ReleaseDate
+	}
+	if s.Text == "" {
+		s.Text = other.Text
+	}
+	if s.Link == "" {
+		s.Link = other.Link
+	}
+}
@@ -0,0 +1,63 @@
+package models
+
+// ErrorResponse описание ошибки в формате RFC 7807 (application/problem+json)
+// @Description Ошибка в формате problem+json с машиночитаемым кодом
+// @Properties:
+//   type: string "URI типа ошибки"
+//   title: string "Краткое описание типа ошибки"
+//   status: int "HTTP-статус"
+//   detail: string "Описание конкретной ошибки"
+//   instance: string "Путь запроса, вызвавшего ошибку"
+//   code: string "Машиночитаемый код ошибки"
+//   request_id: string "ID запроса (заголовок X-Request-ID)"
+//   errors: array "Ошибки в отдельных полях"
+type ErrorResponse struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError ошибка в отдельном поле запроса
+// @Description Ошибка в поле тела или параметре запроса
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Машиночитаемые коды ошибок
+const (
+	ErrCodeInvalidID             = "invalid_id"
+	ErrCodeInvalidParameter      = "invalid_parameter"
+	ErrCodeInvalidJSON           = "invalid_json"
+	ErrCodeValidationFailed      = "validation_failed"
+	ErrCodeInvalidPatch          = "invalid_patch"
+	ErrCodePatchTestFailed       = "patch_test_failed"
+	ErrCodeUnsupportedMedia      = "unsupported_media_type"
+	ErrCodeNotAcceptable         = "not_acceptable"
+	ErrCodePreconditionFailed    = "precondition_failed"
+	ErrCodeIdempotencyKeyReused  = "idempotency_key_reused"
+	ErrCodeIdempotencyInProgress = "idempotency_in_progress"
+	ErrCodeSongExists            = "song_exists"
+	ErrCodeAlbumNotFound         = "album_not_found"
+	ErrCodeGroupExists           = "group_exists"
+	ErrCodeGroupNotFound         = "group_not_found"
+	ErrCodeGroupInUse            = "group_in_use"
+	ErrCodePlaylistNotFound      = "playlist_not_found"
+	ErrCodeEntryNotFound         = "entry_not_found"
+	ErrCodeForbidden             = "forbidden"
+	ErrCodeUnauthorized          = "unauthorized"
+	ErrCodeSongNotFound          = "song_not_found"
+	ErrCodeJobNotFound           = "job_not_found"
+	ErrCodeRevisionNotFound      = "revision_not_found"
+	ErrCodeSongInfoNotFound      = "song_info_not_found"
+	ErrCodeUpstreamBadPayload    = "upstream_bad_payload"
+	ErrCodeUpstreamUnavailable   = "upstream_unavailable"
+	ErrCodeQueueFull             = "queue_full"
+	ErrCodeInternal              = "internal_error"
+)
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Group музыкальная группа
+// @Description Группа, с которой связаны песни. Группы создаются автоматически по полю group песни
+type Group struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name" gorm:"not null"`
+	// NormalizedName имя в форме NormalizeName, уникально среди групп
+	NormalizedName string    `json:"-" gorm:"not null;uniqueIndex"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Artist исполнитель
+// @Description Исполнитель, с которым связаны песни. Исполнители создаются автоматически по полю artist песни
+type Artist struct {
+	ID             uint      `json:"id"`
+	Name           string    `json:"name" gorm:"not null"`
+	NormalizedName string    `json:"-" gorm:"not null;uniqueIndex"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// GroupRequest данные для создания или переименования группы
+// @Description Название группы. При переименовании меняется и поле group связанных песен
+type GroupRequest struct {
+	Name string `json:"name" validate:"required,max=255" example:"Muse"`
+}
+
+// CleanName убирает лишние пробелы в имени группы или исполнителя
+func CleanName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// IdempotencyRecord сохраненный ответ на запрос с заголовком Idempotency-Key.
+// Пока запрос выполняется, Completed равен false.
+type IdempotencyRecord struct {
+	Key string `gorm:"primaryKey"`
+	// RequestHash хеш метода, пути и тела запроса, для которого зарезервирован ключ
+	RequestHash string `gorm:"not null"`
+	Completed   bool
+	Status      int
+	Header      map[string]string `gorm:"serializer:json"`
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time `gorm:"index"`
+}
@@ -0,0 +1,53 @@
+package models
+
+// Статусы строки импорта
+const (
+	ImportCreated = "created"
+	ImportSkipped = "skipped"
+	ImportFailed  = "failed"
+)
+
+// ImportSongRequest строка импорта песен: колонка CSV или поле объекта NDJSON
+// @Description Песня для массового импорта. Пустые поля можно заполнить из внешнего API (enrich=true)
+type ImportSongRequest struct {
+	Group       string `json:"group" validate:"required,max=255" example:"Muse"`
+	Title       string `json:"title" validate:"required,max=255" example:"Supermassive Black Hole"`
+	Artist      string `json:"artist" validate:"max=255"`
+	ReleaseDate string `json:"release_date" validate:"release_date" example:"16.07.2006"`
+	Text        string `json:"text" validate:"max=50000"`
+	Link        string `json:"link" validate:"max=2048,url"`
+}
+
+// ImportRowResult результат импорта одной строки
+// @Description Строка создана, пропущена как уже существующая или не импортирована из-за ошибки
+type ImportRowResult struct {
+	// Line номер строки во входных данных, включая заголовок CSV
+	Line   int    `json:"line" example:"2"`
+	Status string `json:"status" example:"created"`
+	// SongID созданная песня или уже существующая для пропущенной строки
+	SongID uint         `json:"song_id,omitempty" example:"1"`
+	Error  string       `json:"error,omitempty"`
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// ImportReport отчет об импорте песен
+// @Description Количество созданных, пропущенных и ошибочных строк и результат каждой строки
+type ImportReport struct {
+	Created int               `json:"created"`
+	Skipped int               `json:"skipped"`
+	Failed  int               `json:"failed"`
+	Rows    []ImportRowResult `json:"rows"`
+}
+
+// Add добавляет результат строки и обновляет счетчики
+func (r *ImportReport) Add(row ImportRowResult) {
+	switch row.Status {
+	case ImportCreated:
+		r.Created++
+	case ImportSkipped:
+		r.Skipped++
+	case ImportFailed:
+		r.Failed++
+	}
+	r.Rows = append(r.Rows, row)
+}
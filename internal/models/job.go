@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Job задача фонового обогащения песни данными внешнего API
+// @Description Статус асинхронного добавления песни
+type Job struct {
+	ID          string    `json:"id" gorm:"primaryKey"`
+	SongID      uint      `json:"song_id" gorm:"index"`
+	Status      string    `json:"status" gorm:"index"`
+	Attempts    int       `json:"attempts"`
+	MaxAttempts int       `json:"max_attempts"`
+	LastError   string    `json:"last_error,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Статусы задачи
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+)
+
+// JobAcceptedResponse ответ на асинхронное добавление песни
+// @Description Ответ 202 с идентификатором задачи
+type JobAcceptedResponse struct {
+	JobID  string `json:"job_id"`
+	SongID uint   `json:"song_id"`
+	Status string `json:"status"`
+}
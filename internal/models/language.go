@@ -0,0 +1,23 @@
+package models
+
+import "unicode"
+
+// DetectLanguage выбирает конфигурацию полнотекстового поиска по преобладающему
+// алфавиту: кириллица - russian, иначе english.
+func DetectLanguage(texts ...string) string {
+	var cyrillic, latin int
+	for _, text := range texts {
+		for _, r := range text {
+			switch {
+			case unicode.Is(unicode.Cyrillic, r):
+				cyrillic++
+			case unicode.Is(unicode.Latin, r):
+				latin++
+			}
+		}
+	}
+	if cyrillic > latin {
+		return LanguageRussian
+	}
+	return LanguageEnglish
+}
@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+// Видимость плейлиста
+const (
+	PlaylistPublic  = "public"
+	PlaylistPrivate = "private"
+)
+
+// AnonymousUser автор изменений и пользователь запроса без заголовка X-User
+const AnonymousUser = "anonymous"
+
+// Playlist плейлист пользователя
+// @Description Упорядоченный список песен. Приватный плейлист виден только владельцу
+type Playlist struct {
+	ID         uint      `json:"id"`
+	Name       string    `json:"name" gorm:"not null"`
+	Owner      string    `json:"owner" gorm:"not null;index"`
+	Visibility string    `json:"visibility" gorm:"not null;default:private"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	// Entries заполняется только при получении одного плейлиста
+	Entries []PlaylistEntry `json:"entries,omitempty" gorm:"-"`
+}
+
+// VisibleTo сообщает, может ли пользователь viewer видеть плейлист
+func (p *Playlist) VisibleTo(viewer string) bool {
+	return p.Visibility == PlaylistPublic || p.OwnedBy(viewer)
+}
+
+// OwnedBy сообщает, что user - владелец плейлиста. Анонимный пользователь
+// не владеет ни одним плейлистом, даже созданным без владельца.
+func (p *Playlist) OwnedBy(user string) bool {
+	return user != "" && user != AnonymousUser && p.Owner == user
+}
+
+// PlaylistEntry запись плейлиста. Одна песня может встречаться в плейлисте несколько раз.
+// @Description Песня на позиции в плейлисте
+type PlaylistEntry struct {
+	ID         uint `json:"id"`
+	PlaylistID uint `json:"-" gorm:"not null"`
+	SongID     uint `json:"song_id" gorm:"not null"`
+	// Rank определяет порядок записей: вставка и перемещение меняют только ранг
+	// одной записи, выбирая его между рангами соседей
+	Rank      float64   `json:"-" gorm:"not null"`
+	Position  int       `json:"position" gorm:"-"`
+	Group     string    `json:"group" gorm:"->;-:migration"`
+	Title     string    `json:"title" gorm:"->;-:migration"`
+	Artist    string    `json:"artist" gorm:"->;-:migration"`
+	Link      string    `json:"link" gorm:"->;-:migration"`
+	CreatedAt time.Time `json:"added_at"`
+}
+
+// PlaylistRequest данные для создания или изменения плейлиста
+// @Description Название и видимость плейлиста, по умолчанию private
+type PlaylistRequest struct {
+	Name       string `json:"name" validate:"required,max=255" example:"Road trip"`
+	Visibility string `json:"visibility" validate:"oneof=public private" example:"private"`
+}
+
+// PlaylistEntryRequest данные для добавления песни в плейлист
+// @Description Песня вставляется перед before_id или после after_id, без них - в конец плейлиста
+type PlaylistEntryRequest struct {
+	SongID   uint `json:"song_id" example:"1"`
+	BeforeID uint `json:"before_id,omitempty"`
+	AfterID  uint `json:"after_id,omitempty"`
+}
+
+// MoveEntryRequest новое место записи плейлиста
+// @Description Запись ставится перед before_id или после after_id, без них - в конец плейлиста
+type MoveEntryRequest struct {
+	BeforeID uint `json:"before_id,omitempty"`
+	AfterID  uint `json:"after_id,omitempty"`
+}
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DatePrecision точность даты релиза
+type DatePrecision string
+
+const (
+	PrecisionYear  DatePrecision = "year"
+	PrecisionMonth DatePrecision = "month"
+	PrecisionDay   DatePrecision = "day"
+)
+
+// ReleaseDate дата релиза с точностью до года, месяца или дня.
+// Хранится в колонках release_date (первый день периода, NULL если дата неизвестна)
+// и release_date_precision, в JSON сериализуется как "2006", "2006-07" или "2006-07-16".
+type ReleaseDate struct {
+	Date      *time.Time    `gorm:"column:date;type:date"`
+	Precision DatePrecision `gorm:"column:date_precision"`
+}
+
+// releaseDateLayouts форматы дат, которые встречаются во внешнем API и у клиентов
+var releaseDateLayouts = []struct {
+	layout    string
+	precision DatePrecision
+}{
+	{"02.01.2006", PrecisionDay},
+	{"2.1.2006", PrecisionDay},
+	{"2006-01-02", PrecisionDay},
+	{"02/01/2006", PrecisionDay},
+	{time.RFC3339, PrecisionDay},
+	{"January 2, 2006", PrecisionDay},
+	{"Jan 2, 2006", PrecisionDay},
+	{"2 January 2006", PrecisionDay},
+	{"2 Jan 2006", PrecisionDay},
+	{"01.2006", PrecisionMonth},
+	{"1.2006", PrecisionMonth},
+	{"2006-01", PrecisionMonth},
+	{"01/2006", PrecisionMonth},
+	{"January 2006", PrecisionMonth},
+	{"Jan 2006", PrecisionMonth},
+	{"2006", PrecisionYear},
+}
+
+// ParseReleaseDate разбирает дату релиза в одном из распространенных форматов:
+// "16.07.2006", "2006-07-16", "07.2006", "2006-07", "2006", "July 16, 2006" и т.п.
+// Пустая строка дает нулевую дату без ошибки.
+func ParseReleaseDate(s string) (ReleaseDate, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return ReleaseDate{}, nil
+	}
+	for _, f := range releaseDateLayouts {
+		if t, err := time.Parse(f.layout, s); err == nil {
+			return NewReleaseDate(t, f.precision), nil
+		}
+	}
+	return ReleaseDate{}, fmt.Errorf("unrecognized release date %q", s)
+}
+
+// NewReleaseDate усекает t до начала периода указанной точности
+func NewReleaseDate(t time.Time, precision DatePrecision) ReleaseDate {
+	y, m, d := t.Date()
+	switch precision {
+	case PrecisionYear:
+		m, d = time.January, 1
+	case PrecisionMonth:
+		d = 1
+	}
+	date := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	return ReleaseDate{Date: &date, Precision: precision}
+}
+
+func (d ReleaseDate) IsZero() bool {
+	return d.Date == nil || d.Date.IsZero()
+}
+
+// Start возвращает первый день периода даты
+func (d ReleaseDate) Start() time.Time {
+	if d.Date == nil {
+		return time.Time{}
+	}
+	return *d.Date
+}
+
+// End возвращает первый момент после периода даты: для "2006" это 2007-01-01
+func (d ReleaseDate) End() time.Time {
+	switch d.Precision {
+	case PrecisionYear:
+		return d.Start().AddDate(1, 0, 0)
+	case PrecisionMonth:
+		return d.Start().AddDate(0, 1, 0)
+	default:
+		return d.Start().AddDate(0, 0, 1)
+	}
+}
+
+func (d ReleaseDate) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	switch d.Precision {
+	case PrecisionYear:
+		return d.Date.Format("2006")
+	case PrecisionMonth:
+		return d.Date.Format("2006-01")
+	default:
+		return d.Date.Format("2006-01-02")
+	}
+}
+
+func (d ReleaseDate) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(d.String())
+}
+
+func (d *ReleaseDate) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*d = ReleaseDate{}
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := ParseReleaseDate(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
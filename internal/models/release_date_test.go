@@ -0,0 +1,106 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseReleaseDate(t *testing.T) {
+	tests := []struct {
+		in        string
+		want      string
+		precision DatePrecision
+		wantErr   bool
+	}{
+		{"", "", "", false},
+		{"   ", "", "", false},
+		{"16.07.2006", "2006-07-16", PrecisionDay, false},
+		{"6.7.2006", "2006-07-06", PrecisionDay, false},
+		{"2006-07-16", "2006-07-16", PrecisionDay, false},
+		{" 2006-07-16 ", "2006-07-16", PrecisionDay, false},
+		{"16/07/2006", "2006-07-16", PrecisionDay, false},
+		{"2006-07-16T23:30:00+03:00", "2006-07-16", PrecisionDay, false},
+		{"July 16, 2006", "2006-07-16", PrecisionDay, false},
+		{"Jul 16, 2006", "2006-07-16", PrecisionDay, false},
+		{"16 July 2006", "2006-07-16", PrecisionDay, false},
+		{"16 Jul 2006", "2006-07-16", PrecisionDay, false},
+		{"07.2006", "2006-07", PrecisionMonth, false},
+		{"7.2006", "2006-07", PrecisionMonth, false},
+		{"2006-07", "2006-07", PrecisionMonth, false},
+		{"07/2006", "2006-07", PrecisionMonth, false},
+		{"July 2006", "2006-07", PrecisionMonth, false},
+		{"Jul 2006", "2006-07", PrecisionMonth, false},
+		{"2006", "2006", PrecisionYear, false},
+		{"31.02.2006", "", "", true},
+		{"2006-13", "", "", true},
+		{"06", "", "", true},
+		{"someday", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseReleaseDate(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.String() != tt.want || got.Precision != tt.precision {
+				t.Errorf("ParseReleaseDate = %q (%s), want %q (%s)", got, got.Precision, tt.want, tt.precision)
+			}
+		})
+	}
+}
+
+func TestReleaseDatePeriod(t *testing.T) {
+	tests := []struct {
+		in         string
+		start, end time.Time
+	}{
+		{"2006", time.Date(2006, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2007, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"2006-12", time.Date(2006, 12, 1, 0, 0, 0, 0, time.UTC), time.Date(2007, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"2006-07-16", time.Date(2006, 7, 16, 0, 0, 0, 0, time.UTC), time.Date(2006, 7, 17, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			d, err := ParseReleaseDate(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !d.Start().Equal(tt.start) || !d.End().Equal(tt.end) {
+				t.Errorf("period = [%s, %s), want [%s, %s)", d.Start(), d.End(), tt.start, tt.end)
+			}
+		})
+	}
+}
+
+func TestReleaseDateJSON(t *testing.T) {
+	tests := []struct {
+		json string
+		want string
+	}{
+		{`null`, `null`},
+		{`""`, `null`},
+		{`"16.07.2006"`, `"2006-07-16"`},
+		{`"07.2006"`, `"2006-07"`},
+		{`"2006"`, `"2006"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.json, func(t *testing.T) {
+			var d ReleaseDate
+			if err := json.Unmarshal([]byte(tt.json), &d); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			got, err := json.Marshal(d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Marshal = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	var d ReleaseDate
+	if err := json.Unmarshal([]byte(`"someday"`), &d); err == nil {
+		t.Error("invalid date unmarshaled without error")
+	}
+}
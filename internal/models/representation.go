@@ -0,0 +1,132 @@
+package models
+
+import (
+	"encoding/xml"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Представления песен для форматов ответа, кроме JSON: XML, таблица для CSV
+// и простой текст. См. handlers.responseEncoders.
+
+// MarshalXML пишет выбранные поля элементами; теги и жанры - списком
+// <tags><tag>...</tag></tags>, пустые поля с omitempty в Song пропускаются
+func (v SongView) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	strs := v.Strings()
+	for i, value := range v.Values() {
+		field := v.Fields[i]
+		if omitted(field, value) {
+			continue
+		}
+		el := xml.StartElement{Name: xml.Name{Local: field}}
+		var err error
+		if list, ok := value.([]string); ok {
+			// Имя элемента списка - единственное число имени поля
+			err = e.EncodeElement(xmlList{Name: strings.TrimSuffix(field, "s"), Items: list}, el)
+		} else {
+			err = e.EncodeElement(strs[i], el)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// xmlList список строк с заданным именем элементов
+type xmlList struct {
+	Name  string
+	Items []string
+}
+
+func (l xmlList) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, item := range l.Items {
+		if err := e.EncodeElement(item, xml.StartElement{Name: xml.Name{Local: l.Name}}); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+func (s *Song) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return SongView{Song: s, Fields: SongFields}.MarshalXML(e, xml.StartElement{Name: xml.Name{Local: "song"}})
+}
+
+func (p SongPage) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return e.Encode(struct {
+		XMLName    xml.Name   `xml:"songs"`
+		Total      int64      `xml:"total,attr"`
+		Limit      int        `xml:"limit,attr"`
+		NextCursor string     `xml:"next_cursor,attr,omitempty"`
+		PrevCursor string     `xml:"prev_cursor,attr,omitempty"`
+		Songs      []SongView `xml:"song"`
+		Facets     *Facets    `xml:"facets,omitempty"`
+	}{
+		Total:      p.Total,
+		Limit:      p.Limit,
+		NextCursor: p.NextCursor,
+		PrevCursor: p.PrevCursor,
+		Songs:      p.Views(),
+		Facets:     p.Facets,
+	})
+}
+
+// Table возвращает песни страницы таблицей: заголовок из полей и строка на песню
+func (p SongPage) Table() ([]string, [][]string) {
+	views := p.Views()
+	rows := make([][]string, len(views))
+	for i, view := range views {
+		rows[i] = view.Strings()
+	}
+	return p.fields(), rows
+}
+
+// songDetailFields поля одной песни: релевантность и позиция в альбоме имеют смысл только в списке
+var songDetailFields = slices.DeleteFunc(slices.Clone(SongFields), func(f string) bool {
+	return f == "score" || f == "position"
+})
+
+// Table возвращает песню таблицей из одной строки
+func (s *Song) Table() ([]string, [][]string) {
+	return songDetailFields, [][]string{SongView{Song: s, Fields: songDetailFields}.Strings()}
+}
+
+// Table возвращает секции текста на странице: тип, номер, позиция, повторы и текст
+func (t SongTextResponse) Table() ([]string, [][]string) {
+	rows := make([][]string, len(t.Sections))
+	for i, section := range t.Sections {
+		rows[i] = []string{section.Type, strconv.Itoa(section.Index), strconv.Itoa(section.Position), strconv.Itoa(section.Repeats), section.Text}
+	}
+	return []string{"type", "index", "position", "repeats", "text"}, rows
+}
+
+// PlainText возвращает строку на песню с выбранными полями через табуляцию
+func (p SongPage) PlainText() string {
+	var b strings.Builder
+	for _, view := range p.Views() {
+		b.WriteString(strings.Join(view.Strings(), "\t"))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// PlainText возвращает текст песни
+func (s *Song) PlainText() string {
+	return s.Text
+}
+
+// PlainText возвращает секции на странице, разделенные пустой строкой
+func (t SongTextResponse) PlainText() string {
+	texts := make([]string, len(t.Sections))
+	for i, section := range t.Sections {
+		texts[i] = section.Text
+	}
+	return strings.Join(texts, "\n\n")
+}
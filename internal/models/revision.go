@@ -0,0 +1,132 @@
+package models
+
+import "time"
+
+// SongRevision неизменяемый снимок песни после каждого изменения.
+// Номер ревизии совпадает с версией песни.
+// @Description Ревизия песни: кто, когда и какие поля изменил
+type SongRevision struct {
+	ID            uint        `json:"-" gorm:"primaryKey"`
+	SongID        uint        `json:"song_id" gorm:"not null"`
+	Revision      int         `json:"revision" gorm:"not null"`
+	ChangedBy     string      `json:"changed_by"`
+	ChangedFields []string    `json:"changed_fields" gorm:"serializer:json"`
+	Note          string      `json:"note,omitempty"`
+	Group         string      `json:"group"`
+	Title         string      `json:"title"`
+	Artist        string      `json:"artist"`
+	ReleaseDate   ReleaseDate `json:"release_date" gorm:"embedded;embeddedPrefix:release_" swaggertype:"string" example:"2006-07-16"`
+	Text          string      `json:"text"`
+	Link          string      `json:"link"`
+	CreatedAt     time.Time   `json:"created_at"`
+}
+
+// NewSongRevision создает ревизию для нового состояния песни. previous - состояние
+// до изменения или nil для новой песни; в ChangedFields попадают отличающиеся поля.
+func NewSongRevision(previous, song *Song, changedBy, note string) *SongRevision {
+	if previous == nil {
+		previous = &Song{}
+	}
+
+	var changed []string
+	for _, f := range []struct {
+		name     string
+		old, new string
+	}{
+		{"group", previous.Group, song.Group},
+		{"title", previous.Title, song.Title},
+		{"artist", previous.Artist, song.Artist},
+		{"release_date", previous.ReleaseDate.String(), song.ReleaseDate.String()},
+		{"text", previous.Text, song.Text},
+		{"link", previous.Link, song.Link},
+	} {
+		if f.old != f.new {
+			changed = append(changed, f.name)
+		}
+	}
+	if changed == nil {
+		changed = []string{}
+	}
+
+	return &SongRevision{
+		SongID:        song.ID,
+		Revision:      song.Version,
+		ChangedBy:     changedBy,
+		ChangedFields: changed,
+		Note:          note,
+		Group:         song.Group,
+		Title:         song.Title,
+		Artist:        song.Artist,
+		ReleaseDate:   song.ReleaseDate,
+		Text:          song.Text,
+		Link:          song.Link,
+	}
+}
+
+// ApplyTo возвращает песне состояние из ревизии
+func (rev *SongRevision) ApplyTo(song *Song) {
+	song.Group = rev.Group
+	song.Title = rev.Title
+	song.Artist = rev.Artist
+	song.ReleaseDate = rev.ReleaseDate
+	song.Text = rev.Text
+	song.Link = rev.Link
+}
+
+// FieldChanges возвращает изменения полей, кроме текста, между ревизией и to
+func (rev *SongRevision) FieldChanges(to *SongRevision) []FieldChange {
+	changes := []FieldChange{}
+	for _, f := range []FieldChange{
+		{"group", rev.Group, to.Group},
+		{"title", rev.Title, to.Title},
+		{"artist", rev.Artist, to.Artist},
+		{"release_date", rev.ReleaseDate.String(), to.ReleaseDate.String()},
+		{"link", rev.Link, to.Link},
+	} {
+		if f.From != f.To {
+			changes = append(changes, f)
+		}
+	}
+	return changes
+}
+
+// RevisionDiff разница между двумя ревизиями песни
+// @Description Изменения полей и секций текста между двумя ревизиями
+type RevisionDiff struct {
+	SongID   uint          `json:"song_id"`
+	From     int           `json:"from"`
+	To       int           `json:"to"`
+	Fields   []FieldChange `json:"fields"`
+	Sections []SectionDiff `json:"sections"`
+}
+
+// FieldChange изменение поля песни, кроме текста
+type FieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// Операции в разнице секций и строк
+const (
+	DiffEqual    = "equal"
+	DiffAdded    = "added"
+	DiffRemoved  = "removed"
+	DiffModified = "modified"
+)
+
+// SectionDiff изменение секции текста. Для modified в Lines приводится построчная разница.
+type SectionDiff struct {
+	Op    string     `json:"op"`
+	Type  string     `json:"type"`
+	Index int        `json:"index"`
+	From  string     `json:"from,omitempty"`
+	To    string     `json:"to,omitempty"`
+	Lines []LineDiff `json:"lines,omitempty"`
+}
+
+// LineDiff изменение строки внутри секции
+type LineDiff struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
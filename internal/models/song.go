@@ -1,50 +1,170 @@
 package models
 
+import (
+	"encoding/xml"
+	"time"
+
+	"gorm.io/gorm"
+)
+
 // Song модель для песни
 // @Description Структура для описания песни
 // @Properties:
 //   id: integer "ID песни"
-//   created_at: string "Дата создания"
-//   updated_at: string "Дата последнего обновления"
+//   created_at: string "Дата и время создания (RFC 3339)"
+//   updated_at: string "Дата и время последнего обновления (RFC 3339)"
 //   artist: string "Имя артиста"
 //   title: string "Название песни"
-//   release_date: string "Дата релиза"
+//   release_date: string "Дата релиза с точностью до года, месяца или дня: 2006, 2006-07, 2006-07-16"
 //   text: string "Текст песни"
 //   link: string "Ссылка на песню"
 //   group: string "Группа, к которой принадлежит песня"
+//   status: string "Статус обогащения: pending, ready, failed"
+//   language: string "Конфигурация полнотекстового поиска: russian или english"
+//   score: number "Релевантность при полнотекстовом поиске"
+//   position: int "Позиция в альбоме при фильтре по альбому"
+//   version: int "Версия записи, увеличивается при каждом изменении (ETag)"
+//   deleted_at: string "Дата и время перемещения в корзину (RFC 3339), null для неудаленных песен"
+//   tags: array "Теги песни"
+//   genres: array "Жанры песни из словаря"
 type Song struct {
-	ID          uint   `json:"id"`
-	CreatedAt   string `json:"created_at"`
-	UpdatedAt   string `json:"updated_at"`
-	Artist      string `json:"artist"`
-	Title       string `json:"title"`
-	ReleaseDate string `json:"release_date"`
-	Text        string `json:"text"`
-	Link        string `json:"link"`
-	Group       string `json:"group"`
+	ID          uint           `json:"id"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	Artist      string         `json:"artist"`
+	Title       string         `json:"title"`
+	ReleaseDate ReleaseDate    `json:"release_date" gorm:"embedded;embeddedPrefix:release_" swaggertype:"string" example:"2006-07-16"`
+	Text        string         `json:"text"`
+	Link        string         `json:"link"`
+	Group       string         `json:"group"`
+	Status      string         `json:"status" gorm:"not null;default:ready"`
+	Language    string         `json:"language" gorm:"not null;default:english"`
+	Score       float64        `json:"score,omitempty" gorm:"->;-:migration"`
+	Position    int            `json:"position,omitempty" gorm:"->;-:migration"`
+	Version     int            `json:"version" gorm:"not null;default:1"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index" swaggertype:"string"`
+	// NaturalKey заполняется хранилищем из Group и Title, см. NaturalKey
+	NaturalKey string `json:"-"`
+	// Tags и Genres заполняются хранилищем при получении песни и списка песен
+	Tags   []string `json:"tags,omitempty" gorm:"-"`
+	Genres []string `json:"genres,omitempty" gorm:"-"`
+}
+
+// Статусы песни
+const (
+	SongStatusPending = "pending"
+	SongStatusReady   = "ready"
+	SongStatusFailed  = "failed"
+)
+
+// Конфигурации полнотекстового поиска PostgreSQL
+const (
+	LanguageRussian = "russian"
+	LanguageEnglish = "english"
+)
+
+// SongPage страница списка песен
+// @Description Песни на странице, общее количество подходящих песен и курсоры соседних страниц
+type SongPage struct {
+	Songs []Song `json:"songs"`
+	// Total количество всех песен, подходящих под фильтры
+	Total int64 `json:"total" example:"42"`
+	Limit int   `json:"limit" example:"10"`
+	// NextCursor и PrevCursor пусты, если соседней страницы нет
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	// Facets заполняются по запросу facets=true
+	Facets *Facets `json:"facets,omitempty"`
+	// Fields поля песен в ответе, пустой означает все, см. SongView
+	Fields []string `json:"-"`
 }
 
 // SongTextResponse структура для ответа с текстом песни
 // @Description Структура для ответа на запрос получения текста песни
 // @Properties:
-//   total_verses: int "Общее количество куплетов"
+//   total_verses: int "Общее количество секций с учетом фильтра"
 //   page: int "Номер страницы"
 //   limit: int "Лимит результатов на странице"
-//   verses: array "Массив строк с куплетами песни"
+//   verses: array "Массив строк с текстом секций на странице"
+//   sections: array "Секции на странице с типом и номером"
 type SongTextResponse struct {
-	TotalVerses int      `json:"total_verses"`
-	Page        int      `json:"page"`
-	Limit       int      `json:"limit"`
-	Verses      []string `json:"verses"`
+	XMLName     xml.Name      `json:"-" xml:"song_text"`
+	TotalVerses int           `json:"total_verses" xml:"total_verses"`
+	Page        int           `json:"page" xml:"page"`
+	Limit       int           `json:"limit" xml:"limit"`
+	Verses      []string      `json:"verses" xml:"verses>verse"`
+	Sections    []SongSection `json:"sections" xml:"sections>section"`
 }
 
-type ErrorResponse struct {
-	Message string `json:"message"`
-	Code    int    `json:"code"`
+// SongSection секция текста песни
+// @Description Куплет, припев, бридж, вступление или концовка
+// @Properties:
+//   type: string "Тип секции: verse, chorus, bridge, intro, outro"
+//   index: int "Порядковый номер среди секций того же типа"
+//   position: int "Порядковый номер секции в песне"
+//   text: string "Текст секции"
+//   repeats: int "Сколько раз припев повторяется в песне (при collapse=true)"
+type SongSection struct {
+	Type     string `json:"type" xml:"type,attr"`
+	Index    int    `json:"index" xml:"index,attr"`
+	Position int    `json:"position" xml:"position,attr"`
+	Text     string `json:"text" xml:",chardata"`
+	Repeats  int    `json:"repeats,omitempty" xml:"repeats,attr,omitempty"`
 }
 
+// AddSongRequest данные для добавления песни
+// @Description Группа и название песни, остальные данные запрашиваются во внешнем API
 type AddSongRequest struct {
-	Title  string `json:"title"`
-	Artist string `json:"artist"`
-	Text   string `json:"text"`
+	Group string `json:"group" validate:"required,max=255" example:"Muse"`
+	Song  string `json:"song" validate:"required,max=255" example:"Supermassive Black Hole"`
+	// AlbumID альбом, в конец которого добавляется песня
+	AlbumID uint `json:"album_id,omitempty" example:"1"`
+}
+
+// UpdateSongRequest данные для обновления песни. Незаданные поля не изменяются.
+// @Description Поля песни для обновления, отсутствующие поля сохраняют текущее значение
+type UpdateSongRequest struct {
+	Group       *string `json:"group" validate:"notblank,max=255"`
+	Title       *string `json:"title" validate:"notblank,max=255"`
+	Artist      *string `json:"artist" validate:"max=255"`
+	ReleaseDate *string `json:"release_date" validate:"release_date" example:"16.07.2006"`
+	Text        *string `json:"text" validate:"max=50000"`
+	Link        *string `json:"link" validate:"max=2048,url"`
+}
+
+// NewUpdateSongRequest возвращает запрос со всеми изменяемыми полями песни.
+// Используется как исходный документ при применении PATCH.
+func NewUpdateSongRequest(song *Song) UpdateSongRequest {
+	releaseDate := song.ReleaseDate.String()
+	return UpdateSongRequest{
+		Group:       &song.Group,
+		Title:       &song.Title,
+		Artist:      &song.Artist,
+		ReleaseDate: &releaseDate,
+		Text:        &song.Text,
+		Link:        &song.Link,
+	}
+}
+
+// Apply переносит заданные поля в песню. Запрос должен быть предварительно
+// проверен validation.Validate, иначе нераспознанная дата релиза сбрасывается.
+func (req *UpdateSongRequest) Apply(song *Song) {
+	if req.Group != nil {
+		song.Group = *req.Group
+	}
+	if req.Title != nil {
+		song.Title = *req.Title
+	}
+	if req.Artist != nil {
+		song.Artist = *req.Artist
+	}
+	if req.ReleaseDate != nil {
+		song.ReleaseDate, _ = ParseReleaseDate(*req.ReleaseDate)
+	}
+	if req.Text != nil {
+		song.Text = *req.Text
+	}
+	if req.Link != nil {
+		song.Link = *req.Link
+	}
 }
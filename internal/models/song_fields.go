@@ -0,0 +1,166 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// songFieldValues значения полей песни по их именам в JSON
+var songFieldValues = map[string]func(s *Song) any{
+	"id":           func(s *Song) any { return s.ID },
+	"created_at":   func(s *Song) any { return s.CreatedAt },
+	"updated_at":   func(s *Song) any { return s.UpdatedAt },
+	"artist":       func(s *Song) any { return s.Artist },
+	"title":        func(s *Song) any { return s.Title },
+	"release_date": func(s *Song) any { return s.ReleaseDate },
+	"text":         func(s *Song) any { return s.Text },
+	"link":         func(s *Song) any { return s.Link },
+	"group":        func(s *Song) any { return s.Group },
+	"status":       func(s *Song) any { return s.Status },
+	"language":     func(s *Song) any { return s.Language },
+	"score":        func(s *Song) any { return s.Score },
+	"position":     func(s *Song) any { return s.Position },
+	"version":      func(s *Song) any { return s.Version },
+	"deleted_at":   func(s *Song) any { return s.DeletedAt },
+	"tags":         func(s *Song) any { return s.Tags },
+	"genres":       func(s *Song) any { return s.Genres },
+}
+
+// SongFields поля песни, которые можно выбрать параметром fields, в порядке вывода
+var SongFields = []string{
+	"id", "created_at", "updated_at", "artist", "title", "release_date", "text", "link", "group",
+	"status", "language", "score", "position", "version", "deleted_at", "tags", "genres",
+}
+
+// DefaultListFields поля списка песен по умолчанию: все, кроме текста песни
+var DefaultListFields = slices.DeleteFunc(slices.Clone(SongFields), func(f string) bool { return f == "text" })
+
+// omitEmptyFields поля, которые, как и в Song, не выводятся с пустым значением
+var omitEmptyFields = map[string]bool{"score": true, "position": true, "tags": true, "genres": true}
+
+// ParseSongFields разбирает список полей через запятую, например "id,title,group".
+// Повторы убираются, порядок вывода всегда как в SongFields.
+func ParseSongFields(s string) ([]string, error) {
+	requested := make(map[string]bool)
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, ok := songFieldValues[name]; !ok {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+		requested[name] = true
+	}
+	if len(requested) == 0 {
+		return nil, fmt.Errorf("at least one field is required")
+	}
+	return slices.DeleteFunc(slices.Clone(SongFields), func(f string) bool { return !requested[f] }), nil
+}
+
+// SongView песня, ограниченная выбранными полями
+type SongView struct {
+	Song   *Song
+	Fields []string
+}
+
+// Values возвращает значения выбранных полей в порядке Fields
+func (v SongView) Values() []any {
+	values := make([]any, len(v.Fields))
+	for i, f := range v.Fields {
+		values[i] = songFieldValues[f](v.Song)
+	}
+	return values
+}
+
+func (v SongView) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	first := true
+	for i, value := range v.Values() {
+		if omitted(v.Fields[i], value) {
+			continue
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		key, _ := json.Marshal(v.Fields[i])
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(data)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Strings возвращает значения выбранных полей строками для CSV и текстовых форматов:
+// время в RFC 3339, пустая дата и время удаления - пустая строка, теги и жанры через ";"
+func (v SongView) Strings() []string {
+	values := v.Values()
+	result := make([]string, len(values))
+	for i, value := range values {
+		switch value := value.(type) {
+		case time.Time:
+			result[i] = value.Format(time.RFC3339Nano)
+		case gorm.DeletedAt:
+			if value.Valid {
+				result[i] = value.Time.Format(time.RFC3339Nano)
+			}
+		case ReleaseDate:
+			result[i] = value.String()
+		case []string:
+			result[i] = strings.Join(value, ";")
+		default:
+			result[i] = fmt.Sprint(value)
+		}
+	}
+	return result
+}
+
+// omitted сообщает, что поле не выводится, как поле Song с omitempty
+func omitted(field string, value any) bool {
+	if !omitEmptyFields[field] {
+		return false
+	}
+	if list, ok := value.([]string); ok {
+		return len(list) == 0
+	}
+	return reflect.ValueOf(value).IsZero()
+}
+
+// Views возвращает песни страницы, ограниченные полями Fields
+func (p SongPage) Views() []SongView {
+	fields := p.fields()
+	views := make([]SongView, len(p.Songs))
+	for i := range p.Songs {
+		views[i] = SongView{Song: &p.Songs[i], Fields: fields}
+	}
+	return views
+}
+
+func (p SongPage) fields() []string {
+	if len(p.Fields) == 0 {
+		return SongFields
+	}
+	return p.Fields
+}
+
+func (p SongPage) MarshalJSON() ([]byte, error) {
+	type page SongPage
+	return json.Marshal(struct {
+		Songs []SongView `json:"songs"`
+		page
+	}{p.Views(), page(p)})
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseSongFields(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []string
+		wantErr bool
+	}{
+		{"id,title", []string{"id", "title"}, false},
+		{"group, title ,id", []string{"id", "title", "group"}, false},
+		{"title,title,,", []string{"title"}, false},
+		{"text", []string{"text"}, false},
+		{",", nil, true},
+		{"id,lyrics", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseSongFields(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSongFields = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSongViewMarshalJSON(t *testing.T) {
+	song := &Song{ID: 7, Title: "Uprising", Group: "Muse", Text: "They will not force us"}
+
+	tests := []struct {
+		name   string
+		fields []string
+		want   string
+	}{
+		{"selected fields", []string{"id", "title"}, `{"id":7,"title":"Uprising"}`},
+		{"empty optional fields", []string{"id", "score", "tags"}, `{"id":7}`},
+		{"empty required field", []string{"id", "link"}, `{"id":7,"link":""}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(SongView{Song: song, Fields: tt.fields})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("json = %s, want %s", data, tt.want)
+			}
+		})
+	}
+
+	data, err := json.Marshal(SongPage{Songs: []Song{*song}, Total: 1, Limit: 10, Fields: DefaultListFields})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var page map[string]any
+	if err := json.Unmarshal(data, &page); err != nil {
+		t.Fatal(err)
+	}
+	songs, _ := page["songs"].([]any)
+	if len(songs) != 1 || page["total"] != 1.0 {
+		t.Fatalf("page = %s", data)
+	}
+	if _, ok := songs[0].(map[string]any)["text"]; ok {
+		t.Errorf("default list fields include text: %s", data)
+	}
+}
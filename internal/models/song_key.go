@@ -0,0 +1,36 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+// naturalKeySeparator разделяет группу и название в ключе песни
+const naturalKeySeparator = "\x1f"
+
+// NaturalKey возвращает нормализованный ключ пары группа+название: регистр,
+// повторяющиеся пробелы и ё/е не различаются. Ключ уникален среди неудаленных песен.
+func NaturalKey(group, title string) string {
+	return NormalizeName(group) + naturalKeySeparator + NormalizeName(title)
+}
+
+// SimilarityKey более грубый ключ для поиска похожих песен: дополнительно
+// отбрасываются знаки препинания и пробелы, так что "AC/DC" и "ACDC" совпадают.
+func SimilarityKey(group, title string) string {
+	strip := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return r
+			}
+			return -1
+		}, NormalizeName(s))
+	}
+	return strip(group) + "/" + strip(title)
+}
+
+// NormalizeName приводит имя к нижнему регистру, заменяет ё на е и схлопывает пробелы
+func NormalizeName(s string) string {
+	s = strings.ToLower(s)
+	s = strings.ReplaceAll(s, "ё", "е")
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxTagLength максимальная длина тега в символах
+const MaxTagLength = 50
+
+// Genre жанр из контролируемого словаря. Словарь заполняется миграциями.
+// @Description Жанр, который можно назначить песне
+type Genre struct {
+	ID   uint   `json:"-"`
+	Slug string `json:"slug" gorm:"not null;uniqueIndex" example:"rock"`
+	Name string `json:"name" gorm:"not null" example:"Rock"`
+}
+
+// DefaultGenres начальный словарь жанров. Совпадает с миграцией 0013_create_tags_and_genres.
+var DefaultGenres = []Genre{
+	{Slug: "alternative", Name: "Alternative"},
+	{Slug: "blues", Name: "Blues"},
+	{Slug: "classical", Name: "Classical"},
+	{Slug: "country", Name: "Country"},
+	{Slug: "electronic", Name: "Electronic"},
+	{Slug: "folk", Name: "Folk"},
+	{Slug: "hip-hop", Name: "Hip-Hop"},
+	{Slug: "jazz", Name: "Jazz"},
+	{Slug: "metal", Name: "Metal"},
+	{Slug: "pop", Name: "Pop"},
+	{Slug: "punk", Name: "Punk"},
+	{Slug: "reggae", Name: "Reggae"},
+	{Slug: "rnb", Name: "R&B"},
+	{Slug: "rock", Name: "Rock"},
+	{Slug: "soul", Name: "Soul"},
+	{Slug: "soundtrack", Name: "Soundtrack"},
+}
+
+// NormalizeTag приводит тег к нижнему регистру и убирает лишние пробелы
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.Join(strings.Fields(tag), " "))
+}
+
+// NormalizeTags нормализует теги, проверяет их длину и убирает повторы
+func NormalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = NormalizeTag(tag)
+		if tag == "" {
+			return nil, fmt.Errorf("tag must not be blank")
+		}
+		if utf8.RuneCountInString(tag) > MaxTagLength {
+			return nil, fmt.Errorf("tag %q is longer than %d characters", tag, MaxTagLength)
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			result = append(result, tag)
+		}
+	}
+	return result, nil
+}
+
+// BulkTagRequest данные для массового добавления или удаления тегов и жанров
+// @Description Теги и жанры, которые добавляются песням song_ids или удаляются у них
+type BulkTagRequest struct {
+	SongIDs []uint   `json:"song_ids" example:"1,2"`
+	Tags    []string `json:"tags" example:"live,cover"`
+	Genres  []string `json:"genres" example:"rock"`
+}
+
+// BulkTagResult результат массового изменения тегов
+// @Description Количество песен, у которых изменились теги или жанры
+type BulkTagResult struct {
+	Updated int `json:"updated" example:"2"`
+}
+
+// FacetCount количество песен со значением тега или жанра
+// @Description Значение фасета и число подходящих песен
+type FacetCount struct {
+	Value string `json:"value" xml:"value,attr" example:"rock"`
+	Count int64  `json:"count" xml:"count,attr" example:"12"`
+}
+
+// Facets количество песен по тегам и жанрам среди результатов фильтрации
+// @Description Фасеты по тегам и жанрам, от самых частых значений
+type Facets struct {
+	Tags   []FacetCount `json:"tags" xml:"tags>tag"`
+	Genres []FacetCount `json:"genres" xml:"genres>genre"`
+}
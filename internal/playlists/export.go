@@ -0,0 +1,113 @@
+// Package playlists содержит форматы выгрузки плейлистов
+package playlists
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/w212w/GoProjectEM/internal/models"
+)
+
+// Форматы выгрузки
+const (
+	FormatM3U  = "m3u"
+	FormatXSPF = "xspf"
+)
+
+// ContentType возвращает MIME-тип формата и расширение файла.
+// Для неизвестного формата ok равно false.
+func ContentType(format string) (contentType, ext string, ok bool) {
+	switch format {
+	case FormatM3U:
+		return "audio/x-mpegurl", "m3u", true
+	case FormatXSPF:
+		return "application/xspf+xml", "xspf", true
+	}
+	return "", "", false
+}
+
+// Write выгружает плейлист в формате format
+func Write(w io.Writer, format string, playlist *models.Playlist) error {
+	switch format {
+	case FormatM3U:
+		return WriteM3U(w, playlist)
+	case FormatXSPF:
+		return WriteXSPF(w, playlist)
+	}
+	return fmt.Errorf("unknown playlist format %q", format)
+}
+
+// WriteM3U выгружает плейлист в расширенном M3U. Записи без ссылки пропускаются.
+func WriteM3U(w io.Writer, playlist *models.Playlist) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "#EXTM3U")
+	fmt.Fprintf(bw, "#PLAYLIST:%s\n", oneLine(playlist.Name))
+	for _, entry := range playlist.Entries {
+		if entry.Link == "" {
+			continue
+		}
+		fmt.Fprintf(bw, "#EXTINF:-1,%s\n", oneLine(trackName(entry)))
+		fmt.Fprintln(bw, oneLine(entry.Link))
+	}
+	return bw.Flush()
+}
+
+type xspfPlaylist struct {
+	XMLName   xml.Name    `xml:"http://xspf.org/ns/0/ playlist"`
+	Version   string      `xml:"version,attr"`
+	Title     string      `xml:"title"`
+	Creator   string      `xml:"creator,omitempty"`
+	TrackList []xspfTrack `xml:"trackList>track"`
+}
+
+type xspfTrack struct {
+	Location string `xml:"location"`
+	Title    string `xml:"title,omitempty"`
+	Creator  string `xml:"creator,omitempty"`
+}
+
+// WriteXSPF выгружает плейлист в XSPF. Записи без ссылки пропускаются.
+func WriteXSPF(w io.Writer, playlist *models.Playlist) error {
+	doc := xspfPlaylist{
+		Version:   "1",
+		Title:     playlist.Name,
+		Creator:   playlist.Owner,
+		TrackList: []xspfTrack{},
+	}
+	for _, entry := range playlist.Entries {
+		if entry.Link == "" {
+			continue
+		}
+		doc.TrackList = append(doc.TrackList, xspfTrack{
+			Location: entry.Link,
+			Title:    entry.Title,
+			Creator:  entry.Group,
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func trackName(entry models.PlaylistEntry) string {
+	if entry.Group == "" {
+		return entry.Title
+	}
+	return entry.Group + " - " + entry.Title
+}
+
+// oneLine убирает переводы строк, которые сломали бы построчный формат M3U
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,93 @@
+package playlists
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/w212w/GoProjectEM/internal/models"
+)
+
+func testPlaylist() *models.Playlist {
+	return &models.Playlist{
+		Name:  "Road\ntrip",
+		Owner: "alice",
+		Entries: []models.PlaylistEntry{
+			{Group: "Muse", Title: "Uprising", Link: "https://example.com/uprising"},
+			{Group: "Queen", Title: "Bohemian Rhapsody"},
+			{Title: "Untitled\r\nsong", Link: "https://example.com/a?b=1&c=2"},
+		},
+	}
+}
+
+func TestWriteM3U(t *testing.T) {
+	var b strings.Builder
+	if err := Write(&b, FormatM3U, testPlaylist()); err != nil {
+		t.Fatal(err)
+	}
+	want := "#EXTM3U\n" +
+		"#PLAYLIST:Road trip\n" +
+		"#EXTINF:-1,Muse - Uprising\n" +
+		"https://example.com/uprising\n" +
+		"#EXTINF:-1,Untitled song\n" +
+		"https://example.com/a?b=1&c=2\n"
+	if b.String() != want {
+		t.Errorf("M3U =\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestWriteXSPF(t *testing.T) {
+	var b strings.Builder
+	if err := Write(&b, FormatXSPF, testPlaylist()); err != nil {
+		t.Fatal(err)
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<playlist xmlns="http://xspf.org/ns/0/" version="1">
+  <title>Road&#xA;trip</title>
+  <creator>alice</creator>
+  <trackList>
+    <track>
+      <location>https://example.com/uprising</location>
+      <title>Uprising</title>
+      <creator>Muse</creator>
+    </track>
+    <track>
+      <location>https://example.com/a?b=1&amp;c=2</location>
+      <title>Untitled&#xD;&#xA;song</title>
+    </track>
+  </trackList>
+</playlist>
+`
+	if b.String() != want {
+		t.Errorf("XSPF =\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestWriteEmpty(t *testing.T) {
+	var b strings.Builder
+	if err := WriteXSPF(&b, &models.Playlist{Name: "Empty"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "<trackList></trackList>") {
+		t.Errorf("XSPF without tracks must keep an empty trackList:\n%s", b.String())
+	}
+}
+
+func TestContentType(t *testing.T) {
+	tests := []struct {
+		format, contentType, ext string
+		ok                       bool
+	}{
+		{FormatM3U, "audio/x-mpegurl", "m3u", true},
+		{FormatXSPF, "application/xspf+xml", "xspf", true},
+		{"pls", "", "", false},
+	}
+	for _, tt := range tests {
+		contentType, ext, ok := ContentType(tt.format)
+		if contentType != tt.contentType || ext != tt.ext || ok != tt.ok {
+			t.Errorf("ContentType(%q) = %q, %q, %v", tt.format, contentType, ext, ok)
+		}
+	}
+	if err := Write(&strings.Builder{}, "pls", testPlaylist()); err == nil {
+		t.Error("unknown format written without error")
+	}
+}
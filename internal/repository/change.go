@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/w212w/GoProjectEM/internal/models"
+)
+
+// ChangeInfo сведения об изменении, которые записываются в ревизию песни
+type ChangeInfo struct {
+	Actor string
+	Note  string
+}
+
+type changeInfoKey struct{}
+
+// WithChangeInfo добавляет в контекст автора и комментарий изменения
+func WithChangeInfo(ctx context.Context, info ChangeInfo) context.Context {
+	return context.WithValue(ctx, changeInfoKey{}, info)
+}
+
+// ChangeInfoFromContext возвращает сведения об изменении из контекста.
+// Если автор не указан, используется models.AnonymousUser.
+func ChangeInfoFromContext(ctx context.Context) ChangeInfo {
+	info, _ := ctx.Value(changeInfoKey{}).(ChangeInfo)
+	if info.Actor == "" {
+		info.Actor = models.AnonymousUser
+	}
+	return info
+}
+
+// withRestoreNote добавляет комментарий ревизии, которая записывается при
+// восстановлении песни из корзины, если комментарий не задан вызывающим
+func withRestoreNote(ctx context.Context) context.Context {
+	info := ChangeInfoFromContext(ctx)
+	if info.Note != "" {
+		return ctx
+	}
+	info.Note = "restored from trash"
+	return WithChangeInfo(ctx, info)
+}
+
+func newRevision(ctx context.Context, previous, song *models.Song) *models.SongRevision {
+	info := ChangeInfoFromContext(ctx)
+	return models.NewSongRevision(previous, song, info.Actor, info.Note)
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/w212w/GoProjectEM/internal/models"
+)
+
+// Cursor позиция в списке песен для keyset-пагинации: значения полей сортировки
+// песни на границе страницы. Страница начинается после этой песни или, с Before,
+// заканчивается перед ней, поэтому вставка новых песен не сдвигает страницы.
+type Cursor struct {
+	// Sort полный порядок выборки, для которого выдан курсор
+	Sort   string            `json:"s"`
+	Values []json.RawMessage `json:"v"`
+	Before bool              `json:"b,omitempty"`
+}
+
+// NewCursor возвращает курсор страницы после песни song или, с before, перед ней
+// для выборки с параметрами opts
+func NewCursor(opts ListOptions, song *models.Song, before bool) string {
+	fields := effectiveSort(opts)
+	cursor := Cursor{Sort: sortString(fields), Before: before}
+	for _, f := range fields {
+		raw, _ := json.Marshal(sortColumnFor(f.Field).value(song))
+		cursor.Values = append(cursor.Values, raw)
+	}
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// ParseCursor разбирает курсор, выданный NewCursor
+func ParseCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// values разбирает значения курсора для полей fields. Курсор другой
+// сортировки или фильтра по поиску и альбому - ErrInvalidCursor.
+func (c *Cursor) values(fields []SortField) ([]any, error) {
+	if c.Sort != sortString(fields) || len(c.Values) != len(fields) {
+		return nil, ErrInvalidCursor
+	}
+	values := make([]any, len(fields))
+	for i, f := range fields {
+		if string(c.Values[i]) == "null" {
+			continue
+		}
+		v, err := sortColumnFor(f.Field).decode(c.Values[i])
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// compareToCursor сравнивает песню с позицией курсора в порядке fields
+func compareToCursor(song *models.Song, fields []SortField, values []any) int {
+	for i, f := range fields {
+		col := sortColumnFor(f.Field)
+		if c := compareValues(col, col.value(song), values[i], f.Desc); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// keysetCondition строит условие WHERE для строк после позиции курсора
+// или, с before, перед ней в порядке fields с пустыми значениями в конце
+func keysetCondition(fields []SortField, values []any, before bool) (string, []any) {
+	var terms []string
+	var args []any
+	for i, f := range fields {
+		col := sortColumnSQL(f.Field)
+		parts := make([]string, 0, i+1)
+		var termArgs []any
+		for j := 0; j < i; j++ {
+			if values[j] == nil {
+				parts = append(parts, sortColumnSQL(fields[j].Field)+" IS NULL")
+			} else {
+				parts = append(parts, sortColumnSQL(fields[j].Field)+" = ?")
+				termArgs = append(termArgs, values[j])
+			}
+		}
+
+		op := ">"
+		if f.Desc != before {
+			op = "<"
+		}
+		switch {
+		case values[i] == nil && !before:
+			// После пустого значения идут только такие же пустые значения
+			continue
+		case values[i] == nil:
+			parts = append(parts, col+" IS NOT NULL")
+		case before:
+			parts = append(parts, col+" "+op+" ?")
+			termArgs = append(termArgs, values[i])
+		default:
+			parts = append(parts, "("+col+" "+op+" ? OR "+col+" IS NULL)")
+			termArgs = append(termArgs, values[i])
+		}
+		terms = append(terms, "("+strings.Join(parts, " AND ")+")")
+		args = append(args, termArgs...)
+	}
+	if len(terms) == 0 {
+		return "FALSE", nil
+	}
+	return strings.Join(terms, " OR "), args
+}
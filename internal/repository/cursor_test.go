@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/w212w/GoProjectEM/internal/models"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	song := &models.Song{
+		ID:          42,
+		Title:       "Uprising",
+		Group:       "Muse",
+		ReleaseDate: models.NewReleaseDate(time.Date(2009, 9, 7, 0, 0, 0, 0, time.UTC), models.PrecisionDay),
+		CreatedAt:   time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC),
+		Score:       0.5,
+	}
+
+	tests := []struct {
+		name   string
+		opts   ListOptions
+		before bool
+		sort   string
+		values []any
+	}{
+		{"default", ListOptions{}, false, "id", []any{uint(42)}},
+		{"before", ListOptions{}, true, "id", []any{uint(42)}},
+		{"explicit sort", ListOptions{Sort: []SortField{{Field: "title"}, {Field: "created_at", Desc: true}}}, false,
+			"title,-created_at,id", []any{"Uprising", song.CreatedAt, uint(42)}},
+		{"sort with id", ListOptions{Sort: []SortField{{Field: "id", Desc: true}}}, false, "-id", []any{uint(42)}},
+		{"release date", ListOptions{Sort: []SortField{{Field: "release_date"}}}, false,
+			"release_date,id", []any{"2009-09-07", uint(42)}},
+		{"search", ListOptions{Filter: SongFilter{Query: "muse"}}, false, "-score,id", []any{0.5, uint(42)}},
+		{"album", ListOptions{Filter: SongFilter{AlbumID: 3}}, false, "position,id", []any{0, uint(42)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor, err := ParseCursor(NewCursor(tt.opts, song, tt.before))
+			if err != nil {
+				t.Fatalf("ParseCursor: %v", err)
+			}
+			if cursor.Sort != tt.sort || cursor.Before != tt.before {
+				t.Errorf("cursor = %q before=%v, want %q before=%v", cursor.Sort, cursor.Before, tt.sort, tt.before)
+			}
+
+			values, err := cursor.values(effectiveSort(tt.opts))
+			if err != nil {
+				t.Fatalf("values: %v", err)
+			}
+			if !reflect.DeepEqual(values, tt.values) {
+				t.Errorf("values = %#v, want %#v", values, tt.values)
+			}
+		})
+	}
+}
+
+func TestCursorEmptyValue(t *testing.T) {
+	opts := ListOptions{Sort: []SortField{{Field: "release_date"}}}
+	cursor, err := ParseCursor(NewCursor(opts, &models.Song{ID: 1}, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err := cursor.values(effectiveSort(opts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values[0] != nil {
+		t.Errorf("empty release date decoded as %#v, want nil", values[0])
+	}
+}
+
+func TestParseCursorInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "!!"},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"s":"id","v":[10]}`))},
+		{"not json", base64.RawURLEncoding.EncodeToString([]byte("id=1"))},
+		{"wrong shape", base64.RawURLEncoding.EncodeToString([]byte(`{"s":["id"]}`))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseCursor(tt.cursor); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("err = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+func TestCursorValuesMismatch(t *testing.T) {
+	song := &models.Song{ID: 1, Title: "Uprising"}
+	byTitle := ListOptions{Sort: []SortField{{Field: "title"}}}
+
+	tests := []struct {
+		name   string
+		cursor *Cursor
+		opts   ListOptions
+	}{
+		{"other sort", mustParseCursor(t, NewCursor(byTitle, song, false)), ListOptions{}},
+		{"other direction", mustParseCursor(t, NewCursor(byTitle, song, false)), ListOptions{Sort: []SortField{{Field: "title", Desc: true}}}},
+		{"search added", mustParseCursor(t, NewCursor(ListOptions{}, song, false)), ListOptions{Filter: SongFilter{Query: "muse"}}},
+		{"values missing", &Cursor{Sort: "title,id"}, byTitle},
+		{"value of wrong type", &Cursor{Sort: "id", Values: []json.RawMessage{json.RawMessage(`"one"`)}}, ListOptions{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.cursor.values(effectiveSort(tt.opts)); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("err = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+func mustParseCursor(t *testing.T, s string) *Cursor {
+	t.Helper()
+
+	cursor, err := ParseCursor(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cursor
+}
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		sort    string
+		want    []SortField
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"title", []SortField{{Field: "title"}}, false},
+		{"-release_date, id", []SortField{{Field: "release_date", Desc: true}, {Field: "id"}}, false},
+		{"group,,", []SortField{{Field: "group"}}, false},
+		{"text", nil, true},
+		{"score", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			got, err := ParseSort(tt.sort)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSort = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package repository
+
+import "github.com/w212w/GoProjectEM/internal/models"
+
+// groupDuplicates группирует песни по models.SimilarityKey и возвращает группы
+// из нескольких песен в порядке появления первой песни группы
+func groupDuplicates(songs []models.Song) []models.DuplicateGroup {
+	index := make(map[string]int)
+	var groups []models.DuplicateGroup
+	for _, song := range songs {
+		key := models.SimilarityKey(song.Group, song.Title)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, models.DuplicateGroup{Key: key})
+		}
+		groups[i].Songs = append(groups[i].Songs, song)
+	}
+
+	duplicates := make([]models.DuplicateGroup, 0)
+	for _, group := range groups {
+		if len(group.Songs) > 1 {
+			duplicates = append(duplicates, group)
+		}
+	}
+	return duplicates
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"fmt"
+	"slices"
+)
+
+// songFieldColumns колонки таблицы songs для полей песни из models.SongFields.
+// Score, position, теги и жанры вычисляются или загружаются отдельно.
+var songFieldColumns = map[string][]string{
+	"id":           {"id"},
+	"created_at":   {"created_at"},
+	"updated_at":   {"updated_at"},
+	"artist":       {"artist"},
+	"title":        {"title"},
+	"release_date": {"release_date", "release_date_precision"},
+	"text":         {"text"},
+	"link":         {"link"},
+	"group":        {"group"},
+	"status":       {"status"},
+	"language":     {"language"},
+	"version":      {"version"},
+	"deleted_at":   {"deleted_at"},
+}
+
+// selectColumns возвращает колонки songs, которые нужно выбрать для полей
+// и сортировки списка. Пустой fields означает все колонки.
+func selectColumns(fields []string, sort []SortField) []string {
+	if len(fields) == 0 {
+		return []string{"songs.*"}
+	}
+	var columns []string
+	add := func(field string) {
+		for _, column := range songFieldColumns[field] {
+			if c := fmt.Sprintf(`songs.%q`, column); !slices.Contains(columns, c) {
+				columns = append(columns, c)
+			}
+		}
+	}
+	add("id")
+	for _, f := range fields {
+		add(f)
+	}
+	for _, f := range sort {
+		add(f.Field)
+	}
+	return columns
+}
+
+// wantsTags сообщает, нужно ли загружать теги и жанры для полей списка
+func wantsTags(fields []string) bool {
+	return len(fields) == 0 || slices.Contains(fields, "tags") || slices.Contains(fields, "genres")
+}
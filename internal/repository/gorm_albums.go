@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/w212w/GoProjectEM/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (r *GormSongRepository) ListAlbums(ctx context.Context, offset, limit int) ([]models.Album, error) {
+	query := r.db.WithContext(ctx).Order("id")
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var albums []models.Album
+	if err := query.Find(&albums).Error; err != nil {
+		return nil, err
+	}
+	return albums, nil
+}
+
+func (r *GormSongRepository) GetAlbum(ctx context.Context, id uint) (*models.Album, error) {
+	db := r.db.WithContext(ctx)
+
+	var album models.Album
+	if err := db.First(&album, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	err := db.Model(&models.AlbumTrack{}).
+		Select("album_tracks.*, songs.title").
+		Joins("JOIN songs ON songs.id = album_tracks.song_id AND songs.deleted_at IS NULL").
+		Where("album_tracks.album_id = ?", id).
+		Order("album_tracks.position").
+		Find(&album.Tracks).Error
+	if err != nil {
+		return nil, err
+	}
+	return &album, nil
+}
+
+func (r *GormSongRepository) CreateAlbum(ctx context.Context, album *models.Album, songIDs []uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		album.Group = models.CleanName(album.Group)
+		album.GroupID = nil
+		if models.NormalizeName(album.Group) != "" {
+			id, err := upsertName(tx, `"groups"`, album.Group)
+			if err != nil {
+				return err
+			}
+			album.GroupID = &id
+		}
+
+		if err := tx.Create(album).Error; err != nil {
+			return err
+		}
+		return setTracks(tx, album.ID, songIDs)
+	})
+}
+
+func (r *GormSongRepository) SetTracks(ctx context.Context, albumID uint, songIDs []uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockRow(tx, &models.Album{}, albumID); err != nil {
+			return err
+		}
+		if err := tx.Where("album_id = ?", albumID).Delete(&models.AlbumTrack{}).Error; err != nil {
+			return err
+		}
+		if err := setTracks(tx, albumID, songIDs); err != nil {
+			return err
+		}
+		return tx.Model(&models.Album{ID: albumID}).Update("updated_at", tx.NowFunc()).Error
+	})
+}
+
+func (r *GormSongRepository) AddTrack(ctx context.Context, albumID, songID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockRow(tx, &models.Album{}, albumID); err != nil {
+			return err
+		}
+		if err := checkSongsExist(tx, []uint{songID}); err != nil {
+			return err
+		}
+
+		var position int
+		err := tx.Model(&models.AlbumTrack{}).
+			Select("COALESCE(MAX(position), 0)").
+			Where("album_id = ?", albumID).
+			Scan(&position).Error
+		if err != nil {
+			return err
+		}
+		track := models.AlbumTrack{AlbumID: albumID, SongID: songID, Position: position + 1}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&track).Error
+	})
+}
+
+// lockRow блокирует запись model с указанным id до конца транзакции,
+// чтобы изменения связанных с ней записей не пересекались
+func lockRow(tx *gorm.DB, model any, id uint) error {
+	result := tx.Model(model).Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", id).Find(&[]uint{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// setTracks добавляет треки songIDs с позициями 1..n в альбом без треков
+func setTracks(tx *gorm.DB, albumID uint, songIDs []uint) error {
+	if len(songIDs) == 0 {
+		return nil
+	}
+	if err := checkSongsExist(tx, songIDs); err != nil {
+		return err
+	}
+
+	tracks := make([]models.AlbumTrack, len(songIDs))
+	for i, id := range songIDs {
+		tracks[i] = models.AlbumTrack{AlbumID: albumID, SongID: id, Position: i + 1}
+	}
+	return tx.Create(&tracks).Error
+}
+
+// checkSongsExist проверяет, что все песни ids существуют и не удалены. ids не должны повторяться.
+func checkSongsExist(tx *gorm.DB, ids []uint) error {
+	var count int64
+	if err := tx.Model(&models.Song{}).Where("id IN ?", ids).Count(&count).Error; err != nil {
+		return err
+	}
+	if count != int64(len(ids)) {
+		return ErrInvalidReference
+	}
+	return nil
+}
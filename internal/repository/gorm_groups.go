@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/w212w/GoProjectEM/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// syncLinks связывает песню с группой и исполнителем по полям Group и Artist,
+// при необходимости создавая их
+func syncLinks(tx *gorm.DB, song *models.Song) error {
+	if err := tx.Exec("DELETE FROM song_groups WHERE song_id = ?", song.ID).Error; err != nil {
+		return err
+	}
+	if err := tx.Exec("DELETE FROM song_artists WHERE song_id = ?", song.ID).Error; err != nil {
+		return err
+	}
+
+	if models.NormalizeName(song.Group) != "" {
+		id, err := upsertName(tx, `"groups"`, song.Group)
+		if err != nil {
+			return err
+		}
+		if err := tx.Exec("INSERT INTO song_groups (song_id, group_id) VALUES (?, ?)", song.ID, id).Error; err != nil {
+			return err
+		}
+	}
+	if models.NormalizeName(song.Artist) != "" {
+		id, err := upsertName(tx, "artists", song.Artist)
+		if err != nil {
+			return err
+		}
+		if err := tx.Exec("INSERT INTO song_artists (song_id, artist_id) VALUES (?, ?)", song.ID, id).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// upsertName возвращает ID записи table с тем же нормализованным именем, создавая ее при отсутствии
+func upsertName(tx *gorm.DB, table, name string) (uint, error) {
+	var id uint
+	err := tx.Raw(`INSERT INTO `+table+` (name, normalized_name) VALUES (?, ?)
+		ON CONFLICT (normalized_name) DO UPDATE SET normalized_name = EXCLUDED.normalized_name
+		RETURNING id`, models.CleanName(name), models.NormalizeName(name)).Scan(&id).Error
+	return id, err
+}
+
+func (r *GormSongRepository) ListGroups(ctx context.Context, offset, limit int) ([]models.Group, error) {
+	query := r.db.WithContext(ctx).Order("normalized_name").Order("id")
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var groups []models.Group
+	if err := query.Find(&groups).Error; err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+func (r *GormSongRepository) GetGroup(ctx context.Context, id uint) (*models.Group, error) {
+	return firstGroup(r.db.WithContext(ctx), "id = ?", id)
+}
+
+func (r *GormSongRepository) FindGroupByName(ctx context.Context, name string) (*models.Group, error) {
+	return firstGroup(r.db.WithContext(ctx), "normalized_name = ?", models.NormalizeName(name))
+}
+
+func firstGroup(db *gorm.DB, query string, args ...any) (*models.Group, error) {
+	var group models.Group
+	if err := db.Where(query, args...).First(&group).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (r *GormSongRepository) CreateGroup(ctx context.Context, group *models.Group) error {
+	group.Name = models.CleanName(group.Name)
+	group.NormalizedName = models.NormalizeName(group.Name)
+	return translateDuplicate(r.db.WithContext(ctx).Create(group).Error)
+}
+
+func (r *GormSongRepository) UpdateGroup(ctx context.Context, group *models.Group) error {
+	group.Name = models.CleanName(group.Name)
+	group.NormalizedName = models.NormalizeName(group.Name)
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		current, err := firstGroup(tx.Clauses(clause.Locking{Strength: "UPDATE"}), "id = ?", group.ID)
+		if err != nil {
+			return err
+		}
+		group.CreatedAt = current.CreatedAt
+		if err := tx.Model(group).Select("name", "normalized_name", "updated_at").Updates(group).Error; err != nil {
+			return err
+		}
+		if current.Name == group.Name {
+			return nil
+		}
+
+		var songs []models.Song
+		err = tx.Joins("JOIN song_groups ON song_groups.song_id = songs.id").
+			Where("song_groups.group_id = ?", group.ID).
+			Order("songs.id").
+			Find(&songs).Error
+		if err != nil {
+			return err
+		}
+		for i := range songs {
+			songs[i].Group = group.Name
+			if err := updateSong(ctx, tx, &songs[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return translateDuplicate(err)
+}
+
+func (r *GormSongRepository) DeleteGroup(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var songs int64
+		err := tx.Table("song_groups").
+			Joins("JOIN songs ON songs.id = song_groups.song_id AND songs.deleted_at IS NULL").
+			Where("song_groups.group_id = ?", id).
+			Count(&songs).Error
+		if err != nil {
+			return err
+		}
+		if songs > 0 {
+			return ErrInUse
+		}
+
+		result := tx.Delete(&models.Group{}, "id = ?", id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (r *GormSongRepository) ListGroupSongs(ctx context.Context, groupID uint, offset, limit int) ([]models.Song, error) {
+	if _, err := r.GetGroup(ctx, groupID); err != nil {
+		return nil, err
+	}
+
+	query := r.db.WithContext(ctx).
+		Joins("JOIN song_groups ON song_groups.song_id = songs.id").
+		Where("song_groups.group_id = ?", groupID).
+		Order("songs.id")
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var songs []models.Song
+	if err := query.Find(&songs).Error; err != nil {
+		return nil, err
+	}
+	return songs, nil
+}
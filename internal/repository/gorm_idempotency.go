@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/w212w/GoProjectEM/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormIdempotencyRepository реализация IdempotencyRepository поверх GORM (PostgreSQL)
+type GormIdempotencyRepository struct {
+	db *gorm.DB
+}
+
+func NewGormIdempotencyRepository(db *gorm.DB) *GormIdempotencyRepository {
+	return &GormIdempotencyRepository{db: db}
+}
+
+func (r *GormIdempotencyRepository) Create(ctx context.Context, rec *models.IdempotencyRecord) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Истекшие ключи удаляются здесь же, отдельная очистка не нужна
+		if err := tx.Where("expires_at <= ?", time.Now()).Delete(&models.IdempotencyRecord{}).Error; err != nil {
+			return err
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(rec)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrConflict
+		}
+		return nil
+	})
+}
+
+func (r *GormIdempotencyRepository) Get(ctx context.Context, key string) (*models.IdempotencyRecord, error) {
+	var rec models.IdempotencyRecord
+	err := r.db.WithContext(ctx).First(&rec, "key = ? AND expires_at > ?", key, time.Now()).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &rec, nil
+}
+
+func (r *GormIdempotencyRepository) Complete(ctx context.Context, rec *models.IdempotencyRecord) error {
+	rec.Completed = true
+	result := r.db.WithContext(ctx).Model(rec).
+		Select("completed", "status", "header", "body").
+		Updates(rec)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *GormIdempotencyRepository) Delete(ctx context.Context, key string) error {
+	return r.db.WithContext(ctx).Delete(&models.IdempotencyRecord{}, "key = ?", key).Error
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/w212w/GoProjectEM/internal/models"
+	"gorm.io/gorm"
+)
+
+// GormJobRepository реализация JobRepository поверх GORM (PostgreSQL)
+type GormJobRepository struct {
+	db *gorm.DB
+}
+
+func NewGormJobRepository(db *gorm.DB) *GormJobRepository {
+	return &GormJobRepository{db: db}
+}
+
+func (r *GormJobRepository) Create(ctx context.Context, job *models.Job) error {
+	return r.db.WithContext(ctx).Create(job).Error
+}
+
+func (r *GormJobRepository) Get(ctx context.Context, id string) (*models.Job, error) {
+	var job models.Job
+	if err := r.db.WithContext(ctx).First(&job, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *GormJobRepository) Update(ctx context.Context, job *models.Job) error {
+	result := r.db.WithContext(ctx).Model(job).Select("*").Omit("id", "created_at").Updates(job)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *GormJobRepository) ListUnfinished(ctx context.Context) ([]models.Job, error) {
+	var jobs []models.Job
+	err := r.db.WithContext(ctx).
+		Where("status IN ?", []string{models.JobStatusQueued, models.JobStatusRunning}).
+		Order("created_at").
+		Find(&jobs).Error
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
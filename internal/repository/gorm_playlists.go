@@ -0,0 +1,234 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/w212w/GoProjectEM/internal/models"
+	"gorm.io/gorm"
+)
+
+// rankStep расстояние между рангами записей при добавлении в конец и перенумерации
+const rankStep = 1024.0
+
+// rankBetween возвращает ранг между соседями prev и next (nil - край списка).
+// ok == false, если между соседями не осталось места и записи нужно перенумеровать.
+func rankBetween(prev, next *float64) (rank float64, ok bool) {
+	switch {
+	case prev == nil && next == nil:
+		return rankStep, true
+	case prev == nil:
+		return *next - rankStep, true
+	case next == nil:
+		return *prev + rankStep, true
+	}
+	mid := *prev + (*next-*prev)/2
+	return mid, mid > *prev && mid < *next
+}
+
+func (r *GormSongRepository) ListPlaylists(ctx context.Context, owner string, offset, limit int) ([]models.Playlist, error) {
+	query := r.db.WithContext(ctx).
+		Where("visibility = ? OR (owner = ? AND owner NOT IN ('', ?))", models.PlaylistPublic, owner, models.AnonymousUser).
+		Order("id")
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var playlists []models.Playlist
+	if err := query.Find(&playlists).Error; err != nil {
+		return nil, err
+	}
+	return playlists, nil
+}
+
+func (r *GormSongRepository) GetPlaylist(ctx context.Context, id uint) (*models.Playlist, error) {
+	db := r.db.WithContext(ctx)
+
+	var playlist models.Playlist
+	if err := db.First(&playlist, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	err := db.Model(&models.PlaylistEntry{}).
+		Select(`playlist_entries.*, songs."group", songs.title, songs.artist, songs.link`).
+		Joins("JOIN songs ON songs.id = playlist_entries.song_id AND songs.deleted_at IS NULL").
+		Where("playlist_entries.playlist_id = ?", id).
+		Order("playlist_entries.rank").
+		Order("playlist_entries.id").
+		Find(&playlist.Entries).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range playlist.Entries {
+		playlist.Entries[i].Position = i + 1
+	}
+	return &playlist, nil
+}
+
+func (r *GormSongRepository) CreatePlaylist(ctx context.Context, playlist *models.Playlist) error {
+	return r.db.WithContext(ctx).Create(playlist).Error
+}
+
+func (r *GormSongRepository) UpdatePlaylist(ctx context.Context, playlist *models.Playlist) error {
+	result := r.db.WithContext(ctx).Model(playlist).Select("name", "visibility", "updated_at").Updates(playlist)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *GormSongRepository) DeletePlaylist(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Delete(&models.Playlist{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *GormSongRepository) InsertEntry(ctx context.Context, entry *models.PlaylistEntry, pos EntryPosition) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockRow(tx, &models.Playlist{}, entry.PlaylistID); err != nil {
+			return err
+		}
+		if err := checkSongsExist(tx, []uint{entry.SongID}); err != nil {
+			return err
+		}
+
+		rank, err := entryRank(tx, entry.PlaylistID, 0, pos)
+		if err != nil {
+			return err
+		}
+		entry.Rank = rank
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		return touchPlaylist(tx, entry.PlaylistID)
+	})
+}
+
+func (r *GormSongRepository) MoveEntry(ctx context.Context, playlistID, entryID uint, pos EntryPosition) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockRow(tx, &models.Playlist{}, playlistID); err != nil {
+			return err
+		}
+		if _, err := anchorRank(tx, playlistID, entryID); err != nil {
+			return err
+		}
+
+		rank, err := entryRank(tx, playlistID, entryID, pos)
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&models.PlaylistEntry{}).Where("id = ?", entryID).Update("rank", rank).Error
+		if err != nil {
+			return err
+		}
+		return touchPlaylist(tx, playlistID)
+	})
+}
+
+func (r *GormSongRepository) DeleteEntry(ctx context.Context, playlistID, entryID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.PlaylistEntry{}, "playlist_id = ? AND id = ?", playlistID, entryID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return touchPlaylist(tx, playlistID)
+	})
+}
+
+func touchPlaylist(tx *gorm.DB, id uint) error {
+	return tx.Model(&models.Playlist{ID: id}).Update("updated_at", tx.NowFunc()).Error
+}
+
+// entryRank выбирает ранг для записи на месте pos, не учитывая запись excludeID.
+// Если между соседями не осталось места, записи плейлиста перенумеровываются.
+func entryRank(tx *gorm.DB, playlistID, excludeID uint, pos EntryPosition) (float64, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		prev, next, err := entryNeighbours(tx, playlistID, excludeID, pos)
+		if err != nil {
+			return 0, err
+		}
+		if rank, ok := rankBetween(prev, next); ok {
+			return rank, nil
+		}
+		err = tx.Exec(`UPDATE playlist_entries e SET rank = n.rn * ?
+			FROM (SELECT id, row_number() OVER (ORDER BY rank, id) AS rn FROM playlist_entries WHERE playlist_id = ?) n
+			WHERE e.id = n.id`, rankStep, playlistID).Error
+		if err != nil {
+			return 0, err
+		}
+	}
+	return 0, errors.New("no room between playlist entries")
+}
+
+// entryNeighbours возвращает ранги записей, между которыми окажется запись на месте pos
+func entryNeighbours(tx *gorm.DB, playlistID, excludeID uint, pos EntryPosition) (prev, next *float64, err error) {
+	others := func(column string) *gorm.DB {
+		return tx.Model(&models.PlaylistEntry{}).
+			Select(column).
+			Where("playlist_id = ? AND id <> ?", playlistID, excludeID)
+	}
+
+	switch {
+	case pos.AfterID != 0:
+		anchor, err := anchorRank(tx, playlistID, pos.AfterID)
+		if err != nil {
+			return nil, nil, err
+		}
+		next, err = scanRank(others("MIN(rank)").Where("rank > ?", anchor))
+		return &anchor, next, err
+	case pos.BeforeID != 0:
+		anchor, err := anchorRank(tx, playlistID, pos.BeforeID)
+		if err != nil {
+			return nil, nil, err
+		}
+		prev, err = scanRank(others("MAX(rank)").Where("rank < ?", anchor))
+		return prev, &anchor, err
+	default:
+		prev, err = scanRank(others("MAX(rank)"))
+		return prev, nil, err
+	}
+}
+
+func anchorRank(tx *gorm.DB, playlistID, entryID uint) (float64, error) {
+	rank, err := scanRank(tx.Model(&models.PlaylistEntry{}).Select("rank").Where("playlist_id = ? AND id = ?", playlistID, entryID))
+	if err != nil {
+		return 0, err
+	}
+	if rank == nil {
+		return 0, ErrNotFound
+	}
+	return *rank, nil
+}
+
+// scanRank читает единственное значение ранга, nil - если строк нет или значение NULL
+func scanRank(query *gorm.DB) (*float64, error) {
+	var rank sql.NullFloat64
+	if err := query.Row().Scan(&rank); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !rank.Valid {
+		return nil, nil
+	}
+	return &rank.Float64, nil
+}
@@ -0,0 +1,558 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/w212w/GoProjectEM/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormSongRepository реализация SongRepository поверх GORM (PostgreSQL)
+type GormSongRepository struct {
+	db *gorm.DB
+}
+
+func NewGormSongRepository(db *gorm.DB) *GormSongRepository {
+	return &GormSongRepository{db: db}
+}
+
+func (r *GormSongRepository) List(ctx context.Context, opts ListOptions) ([]models.Song, error) {
+	db := r.db.WithContext(ctx)
+	query, reverse, err := listQuery(db, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var songs []models.Song
+	if err := query.Find(&songs).Error; err != nil {
+		return nil, err
+	}
+	if reverse {
+		slices.Reverse(songs)
+	}
+	if wantsTags(opts.Fields) {
+		if err := loadTags(db, songs); err != nil {
+			return nil, err
+		}
+	}
+	return songs, nil
+}
+
+// exportBatchSize количество песен, для которых теги загружаются одним запросом при выгрузке
+const exportBatchSize = 500
+
+func (r *GormSongRepository) Export(ctx context.Context, opts ListOptions, fn func(song *models.Song) error) error {
+	db := r.db.WithContext(ctx)
+	query, _, err := listQuery(db, ListOptions{Filter: opts.Filter, Sort: opts.Sort, Fields: opts.Fields})
+	if err != nil {
+		return err
+	}
+	rows, err := query.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	batch := make([]models.Song, 0, exportBatchSize)
+	flush := func() error {
+		if wantsTags(opts.Fields) {
+			if err := loadTags(db, batch); err != nil {
+				return err
+			}
+		}
+		for i := range batch {
+			if err := fn(&batch[i]); err != nil {
+				return err
+			}
+		}
+		batch = batch[:0]
+		return nil
+	}
+	for rows.Next() {
+		var song models.Song
+		if err := query.ScanRows(rows, &song); err != nil {
+			return err
+		}
+		if batch = append(batch, song); len(batch) == exportBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
+
+// listQuery строит запрос страницы песен. reverse означает, что страница перед
+// курсором выбрана в обратном порядке и ее нужно развернуть.
+func listQuery(db *gorm.DB, opts ListOptions) (query *gorm.DB, reverse bool, err error) {
+	fields := effectiveSort(opts)
+
+	// Релевантность и позиция в альбоме вычисляются во вложенном запросе,
+	// чтобы внешний запрос сортировал и сравнивал их с курсором как обычные колонки
+	columns, args := selectColumns(opts.Fields, fields), []any{}
+	if opts.Filter.Query != "" {
+		columns = append(columns, "ts_rank(search_vector, websearch_to_tsquery(language::regconfig, ?)) AS score")
+		args = append(args, opts.Filter.Query)
+	}
+	if opts.Filter.AlbumID != 0 {
+		columns = append(columns, "album_tracks.position AS position")
+	}
+	inner := filterSongs(db.Model(&models.Song{}).Select(strings.Join(columns, ", "), args...), opts.Filter)
+	// Внешний запрос без Unscoped добавил бы условие на deleted_at, которой может не быть
+	// среди выбранных колонок. Удаленные песни уже отброшены во вложенном запросе.
+	query = db.Unscoped().Table("(?) AS songs", inner)
+
+	if opts.Cursor != nil {
+		values, err := opts.Cursor.values(fields)
+		if err != nil {
+			return nil, false, err
+		}
+		cond, condArgs := keysetCondition(fields, values, opts.Cursor.Before)
+		query = query.Where(cond, condArgs...)
+		reverse = opts.Cursor.Before
+	} else if opts.Offset > 0 {
+		query = query.Offset(opts.Offset)
+	}
+	if opts.Limit > 0 {
+		query = query.Limit(opts.Limit)
+	}
+	return query.Order(orderClause(fields, reverse)), reverse, nil
+}
+
+func (r *GormSongRepository) Count(ctx context.Context, filter SongFilter) (int64, error) {
+	var count int64
+	err := filterSongs(r.db.WithContext(ctx).Model(&models.Song{}), filter).Count(&count).Error
+	return count, err
+}
+
+// filterSongs добавляет к запросу по таблице songs условия фильтра
+func filterSongs(query *gorm.DB, filter SongFilter) *gorm.DB {
+	if filter.Artist != "" {
+		query = query.Where("artist ILIKE ?", "%"+filter.Artist+"%")
+	}
+	if filter.Title != "" {
+		query = query.Where("title ILIKE ?", "%"+filter.Title+"%")
+	}
+	if filter.Group != "" {
+		query = query.Where(`"group" ILIKE ?`, "%"+filter.Group+"%")
+	}
+	if filter.HasLink != nil {
+		if *filter.HasLink {
+			query = query.Where("link IS NOT NULL AND link <> ''")
+		} else {
+			query = query.Where("(link IS NULL OR link = '')")
+		}
+	}
+	if !filter.ReleasedFrom.IsZero() {
+		query = query.Where("release_date >= ?", filter.ReleasedFrom)
+	}
+	if !filter.ReleasedTo.IsZero() {
+		query = query.Where("release_date < ?", filter.ReleasedTo)
+	}
+	if !filter.CreatedFrom.IsZero() {
+		query = query.Where("songs.created_at >= ?", filter.CreatedFrom)
+	}
+	if !filter.CreatedTo.IsZero() {
+		query = query.Where("songs.created_at < ?", filter.CreatedTo)
+	}
+	if !filter.UpdatedFrom.IsZero() {
+		query = query.Where("songs.updated_at >= ?", filter.UpdatedFrom)
+	}
+	if !filter.UpdatedTo.IsZero() {
+		query = query.Where("songs.updated_at < ?", filter.UpdatedTo)
+	}
+	if filter.Expr != nil {
+		sql, args := filter.Expr.SQL()
+		query = query.Where(sql, args...)
+	}
+	if filter.AlbumID != 0 {
+		query = query.Joins("JOIN album_tracks ON album_tracks.song_id = songs.id AND album_tracks.album_id = ?", filter.AlbumID)
+	}
+	for _, tag := range filter.Tags {
+		query = query.Where("EXISTS (SELECT 1 FROM song_tags WHERE song_tags.song_id = songs.id AND song_tags.tag = ?)", tag)
+	}
+	for _, genre := range filter.Genres {
+		query = query.Where("EXISTS (SELECT 1 FROM song_genres JOIN genres ON genres.id = song_genres.genre_id"+
+			" WHERE song_genres.song_id = songs.id AND genres.slug = ?)", genre)
+	}
+	if filter.Query != "" {
+		query = query.Where("search_vector @@ websearch_to_tsquery(language::regconfig, ?)", filter.Query)
+	}
+	return query
+}
+
+func (r *GormSongRepository) Get(ctx context.Context, id uint) (*models.Song, error) {
+	var song models.Song
+	if err := r.db.WithContext(ctx).First(&song, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	songs := []models.Song{song}
+	if err := loadTags(r.db.WithContext(ctx), songs); err != nil {
+		return nil, err
+	}
+	return &songs[0], nil
+}
+
+func (r *GormSongRepository) FindByNaturalKey(ctx context.Context, group, title string) (*models.Song, error) {
+	var song models.Song
+	err := r.db.WithContext(ctx).First(&song, "natural_key = ?", models.NaturalKey(group, title)).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &song, nil
+}
+
+func (r *GormSongRepository) Create(ctx context.Context, song *models.Song) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return createSong(ctx, tx, song)
+	})
+	return translateDuplicate(err)
+}
+
+func (r *GormSongRepository) CreateBatch(ctx context.Context, songs []*models.Song) ([]error, error) {
+	errs := make([]error, len(songs))
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i, song := range songs {
+			// Вложенная транзакция - точка сохранения: дубликат откатывается
+			// без потери уже созданных песен пачки
+			err := translateDuplicate(tx.Transaction(func(tx *gorm.DB) error {
+				return createSong(ctx, tx, song)
+			}))
+			if errors.Is(err, ErrDuplicate) {
+				song.ID = 0
+				errs[i] = err
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		for _, song := range songs {
+			song.ID = 0
+		}
+		return nil, err
+	}
+	return errs, nil
+}
+
+// createSong создает песню в транзакции tx и записывает первую ревизию
+func createSong(ctx context.Context, tx *gorm.DB, song *models.Song) error {
+	song.Language = models.DetectLanguage(song.Group, song.Title, song.Text)
+	song.NaturalKey = models.NaturalKey(song.Group, song.Title)
+	song.Version = 1
+	if err := tx.Create(song).Error; err != nil {
+		return err
+	}
+	if err := syncLinks(tx, song); err != nil {
+		return err
+	}
+	return tx.Create(newRevision(ctx, nil, song)).Error
+}
+
+// translateDuplicate заменяет нарушение уникального индекса natural_key на ErrDuplicate.
+// Требует gorm.Config.TranslateError.
+func translateDuplicate(err error) error {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrDuplicate
+	}
+	return err
+}
+
+func (r *GormSongRepository) Update(ctx context.Context, song *models.Song) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return updateSong(ctx, tx, song)
+	})
+	return translateDuplicate(err)
+}
+
+// updateSong обновляет песню в транзакции tx, проверяя версию, и записывает ревизию
+func updateSong(ctx context.Context, tx *gorm.DB, song *models.Song) error {
+	song.Language = models.DetectLanguage(song.Group, song.Title, song.Text)
+	song.NaturalKey = models.NaturalKey(song.Group, song.Title)
+
+	var current models.Song
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, "id = ?", song.ID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound
+		}
+		return err
+	}
+	if current.Version != song.Version {
+		return ErrConflict
+	}
+
+	song.Version++
+	err := tx.Model(song).Select("*").Omit("id", "created_at").Updates(song).Error
+	if err == nil && (current.Group != song.Group || current.Artist != song.Artist) {
+		err = syncLinks(tx, song)
+	}
+	if err == nil {
+		err = tx.Create(newRevision(ctx, &current, song)).Error
+	}
+	if err != nil {
+		song.Version = current.Version
+	}
+	return err
+}
+
+func (r *GormSongRepository) SetStatus(ctx context.Context, id uint, status string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var song models.Song
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&song, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+		previous := song
+		song.Status = status
+		return touchSong(ctx, tx, &previous, &song, "status")
+	})
+}
+
+// touchSong сохраняет новую версию песни и ее столбцы columns и записывает ревизию.
+// natural_key не пересчитывается: группа и название песни при этом не меняются.
+// previous - состояние песни, заблокированной в транзакции tx.
+func touchSong(ctx context.Context, tx *gorm.DB, previous, song *models.Song, columns ...string) error {
+	song.Version = previous.Version + 1
+	song.UpdatedAt = time.Now()
+	columns = append([]string{"version", "updated_at"}, columns...)
+	if err := tx.Model(song).Select(columns).Updates(song).Error; err != nil {
+		song.Version = previous.Version
+		return err
+	}
+	return tx.Create(newRevision(ctx, previous, song)).Error
+}
+
+func (r *GormSongRepository) Delete(ctx context.Context, id uint, version int) error {
+	query := r.db.WithContext(ctx).Where("id = ?", id)
+	if version > 0 {
+		query = query.Where("version = ?", version)
+	}
+	result := query.Delete(&models.Song{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return r.missingOrConflict(ctx, id)
+	}
+	return nil
+}
+
+// missingOrConflict различает отсутствующую запись и запись в другой версии
+func (r *GormSongRepository) missingOrConflict(ctx context.Context, id uint) error {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&models.Song{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+	return ErrConflict
+}
+
+func (r *GormSongRepository) ListRevisions(ctx context.Context, songID uint) ([]models.SongRevision, error) {
+	var revisions []models.SongRevision
+	if err := r.db.WithContext(ctx).Where("song_id = ?", songID).Order("revision").Find(&revisions).Error; err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (r *GormSongRepository) GetRevision(ctx context.Context, songID uint, revision int) (*models.SongRevision, error) {
+	var rev models.SongRevision
+	err := r.db.WithContext(ctx).First(&rev, "song_id = ? AND revision = ?", songID, revision).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &rev, nil
+}
+
+func (r *GormSongRepository) ListDeleted(ctx context.Context, offset, limit int) ([]models.Song, error) {
+	query := r.db.WithContext(ctx).Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Order("id")
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var songs []models.Song
+	if err := query.Find(&songs).Error; err != nil {
+		return nil, err
+	}
+	return songs, nil
+}
+
+func (r *GormSongRepository) Restore(ctx context.Context, id uint) (*models.Song, error) {
+	ctx = withRestoreNote(ctx)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var song models.Song
+		err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("deleted_at IS NOT NULL").First(&song, "id = ?", id).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		previous := song
+		song.Version++
+		err = tx.Unscoped().Model(&song).Updates(map[string]any{"deleted_at": nil, "version": song.Version}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(newRevision(ctx, &previous, &song)).Error
+	})
+	if err != nil {
+		return nil, translateDuplicate(err)
+	}
+	return r.Get(ctx, id)
+}
+
+func (r *GormSongRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		if err := tx.Unscoped().Model(&models.Song{}).Where("deleted_at < ?", before).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		if err := tx.Where("song_id IN ?", ids).Delete(&models.SongRevision{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("song_id IN ?", ids).Delete(&models.Job{}).Error; err != nil {
+			return err
+		}
+		result := tx.Unscoped().Where("id IN ?", ids).Delete(&models.Song{})
+		purged = result.RowsAffected
+		return result.Error
+	})
+	return purged, err
+}
+
+func (r *GormSongRepository) ListDuplicates(ctx context.Context) ([]models.DuplicateGroup, error) {
+	var keys []models.Song
+	if err := r.db.WithContext(ctx).Select("id", "group", "title").Order("id").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+
+	var ids []uint
+	for _, group := range groupDuplicates(keys) {
+		for _, song := range group.Songs {
+			ids = append(ids, song.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return []models.DuplicateGroup{}, nil
+	}
+
+	var songs []models.Song
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Order("id").Find(&songs).Error; err != nil {
+		return nil, err
+	}
+	return groupDuplicates(songs), nil
+}
+
+func (r *GormSongRepository) Merge(ctx context.Context, targetID uint, sourceIDs []uint) (*models.Song, error) {
+	var target models.Song
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&target, "id = ?", targetID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		var sources []models.Song
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", sourceIDs).Order("id").Find(&sources).Error
+		if err != nil {
+			return err
+		}
+		if len(sources) != len(sourceIDs) {
+			return ErrNotFound
+		}
+		for i := range sources {
+			target.FillMissing(&sources[i])
+		}
+
+		if err := moveSongLinks(tx, targetID, sourceIDs); err != nil {
+			return err
+		}
+		if err := tx.Where("id IN ?", sourceIDs).Delete(&models.Song{}).Error; err != nil {
+			return err
+		}
+		return updateSong(ctx, tx, &target)
+	})
+	if err != nil {
+		return nil, translateDuplicate(err)
+	}
+	songs := []models.Song{target}
+	if err := loadTags(r.db.WithContext(ctx), songs); err != nil {
+		return nil, err
+	}
+	return &songs[0], nil
+}
+
+// moveSongLinks переносит на песню targetID треки альбомов и записи плейлистов
+// песен sourceIDs и копирует ей их теги и жанры. В каждом альбоме остается один
+// трек объединенной песни на самой ранней позиции, позиции остальных треков сдвигаются.
+func moveSongLinks(tx *gorm.DB, targetID uint, sourceIDs []uint) error {
+	ids := append([]uint{targetID}, sourceIDs...)
+
+	var albumIDs []uint
+	err := tx.Raw(`DELETE FROM album_tracks t USING album_tracks k
+		WHERE t.song_id IN ? AND k.song_id IN ? AND k.album_id = t.album_id AND k.position < t.position
+		RETURNING t.album_id`, ids, ids).Scan(&albumIDs).Error
+	if err != nil {
+		return err
+	}
+	if err := tx.Exec("UPDATE album_tracks SET song_id = ? WHERE song_id IN ?", targetID, sourceIDs).Error; err != nil {
+		return err
+	}
+	if len(albumIDs) > 0 {
+		err := tx.Exec(`UPDATE album_tracks a SET position = n.position
+			FROM (SELECT album_id, song_id, row_number() OVER (PARTITION BY album_id ORDER BY position) AS position
+			      FROM album_tracks WHERE album_id IN ?) n
+			WHERE a.album_id = n.album_id AND a.song_id = n.song_id AND a.position <> n.position`, albumIDs).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Exec("UPDATE playlist_entries SET song_id = ? WHERE song_id IN ?", targetID, sourceIDs).Error; err != nil {
+		return err
+	}
+
+	// Теги и жанры копируются, чтобы песня, восстановленная из корзины, сохранила свои
+	err = tx.Exec(`INSERT INTO song_tags (song_id, tag) SELECT ?, tag FROM song_tags WHERE song_id IN ?
+		ON CONFLICT DO NOTHING`, targetID, sourceIDs).Error
+	if err != nil {
+		return err
+	}
+	return tx.Exec(`INSERT INTO song_genres (song_id, genre_id) SELECT ?, genre_id FROM song_genres WHERE song_id IN ?
+		ON CONFLICT DO NOTHING`, targetID, sourceIDs).Error
+}
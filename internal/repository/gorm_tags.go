@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/w212w/GoProjectEM/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// facetLimit максимальное количество значений в одном фасете
+const facetLimit = 50
+
+// loadTags заполняет Tags и Genres песен двумя запросами
+func loadTags(db *gorm.DB, songs []models.Song) error {
+	if len(songs) == 0 {
+		return nil
+	}
+	index := make(map[uint]int, len(songs))
+	ids := make([]uint, len(songs))
+	for i, song := range songs {
+		index[song.ID] = i
+		ids[i] = song.ID
+	}
+
+	var tags []struct {
+		SongID uint
+		Value  string
+	}
+	if err := db.Raw("SELECT song_id, tag AS value FROM song_tags WHERE song_id IN ? ORDER BY tag", ids).
+		Scan(&tags).Error; err != nil {
+		return err
+	}
+	for _, t := range tags {
+		songs[index[t.SongID]].Tags = append(songs[index[t.SongID]].Tags, t.Value)
+	}
+
+	var genres []struct {
+		SongID uint
+		Value  string
+	}
+	if err := db.Raw("SELECT song_genres.song_id, genres.slug AS value FROM song_genres"+
+		" JOIN genres ON genres.id = song_genres.genre_id WHERE song_genres.song_id IN ? ORDER BY genres.slug", ids).
+		Scan(&genres).Error; err != nil {
+		return err
+	}
+	for _, g := range genres {
+		songs[index[g.SongID]].Genres = append(songs[index[g.SongID]].Genres, g.Value)
+	}
+	return nil
+}
+
+func (r *GormSongRepository) ListGenres(ctx context.Context) ([]models.Genre, error) {
+	var genres []models.Genre
+	if err := r.db.WithContext(ctx).Order("slug").Find(&genres).Error; err != nil {
+		return nil, err
+	}
+	return genres, nil
+}
+
+func (r *GormSongRepository) TagSongs(ctx context.Context, songIDs []uint, tags, genres []string) (int, error) {
+	return r.retag(ctx, songIDs, genres, func(tx *gorm.DB, songID uint, genreIDs []uint) (int64, error) {
+		var changed int64
+		for _, tag := range tags {
+			result := tx.Exec("INSERT INTO song_tags (song_id, tag) VALUES (?, ?) ON CONFLICT DO NOTHING", songID, tag)
+			if result.Error != nil {
+				return 0, result.Error
+			}
+			changed += result.RowsAffected
+		}
+		for _, genreID := range genreIDs {
+			result := tx.Exec("INSERT INTO song_genres (song_id, genre_id) VALUES (?, ?) ON CONFLICT DO NOTHING", songID, genreID)
+			if result.Error != nil {
+				return 0, result.Error
+			}
+			changed += result.RowsAffected
+		}
+		return changed, nil
+	})
+}
+
+func (r *GormSongRepository) UntagSongs(ctx context.Context, songIDs []uint, tags, genres []string) (int, error) {
+	return r.retag(ctx, songIDs, genres, func(tx *gorm.DB, songID uint, genreIDs []uint) (int64, error) {
+		var changed int64
+		if len(tags) > 0 {
+			result := tx.Exec("DELETE FROM song_tags WHERE song_id = ? AND tag IN ?", songID, tags)
+			if result.Error != nil {
+				return 0, result.Error
+			}
+			changed += result.RowsAffected
+		}
+		if len(genreIDs) > 0 {
+			result := tx.Exec("DELETE FROM song_genres WHERE song_id = ? AND genre_id IN ?", songID, genreIDs)
+			if result.Error != nil {
+				return 0, result.Error
+			}
+			changed += result.RowsAffected
+		}
+		return changed, nil
+	})
+}
+
+// retag применяет apply к каждой песне в одной транзакции. Песни, у которых
+// apply изменил строки, получают новую версию и ревизию.
+func (r *GormSongRepository) retag(ctx context.Context, songIDs []uint, genres []string,
+	apply func(tx *gorm.DB, songID uint, genreIDs []uint) (int64, error)) (int, error) {
+	updated := 0
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkSongsExist(tx, songIDs); err != nil {
+			return err
+		}
+		ids, err := genreIDs(tx, genres)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range songIDs {
+			changed, err := apply(tx, id, ids)
+			if err != nil {
+				return err
+			}
+			if changed == 0 {
+				continue
+			}
+			var song models.Song
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&song, "id = ?", id).Error; err != nil {
+				return err
+			}
+			previous := song
+			if err := touchSong(ctx, tx, &previous, &song); err != nil {
+				return err
+			}
+			updated++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return updated, nil
+}
+
+// genreIDs возвращает ID жанров по их slug, жанр не из словаря - ErrUnknownGenre
+func genreIDs(tx *gorm.DB, slugs []string) ([]uint, error) {
+	if len(slugs) == 0 {
+		return nil, nil
+	}
+	var ids []uint
+	if err := tx.Model(&models.Genre{}).Where("slug IN ?", slugs).Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) != len(slugs) {
+		return nil, ErrUnknownGenre
+	}
+	return ids, nil
+}
+
+func (r *GormSongRepository) Facets(ctx context.Context, filter SongFilter) (*models.Facets, error) {
+	db := r.db.WithContext(ctx)
+	matching := filterSongs(db.Model(&models.Song{}).Select("songs.id"), filter)
+
+	facets := &models.Facets{Tags: []models.FacetCount{}, Genres: []models.FacetCount{}}
+	if err := db.Raw("SELECT tag AS value, count(*) AS count FROM song_tags WHERE song_id IN (?)"+
+		" GROUP BY tag ORDER BY count DESC, tag LIMIT ?", matching, facetLimit).
+		Scan(&facets.Tags).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Raw("SELECT genres.slug AS value, count(*) AS count FROM song_genres"+
+		" JOIN genres ON genres.id = song_genres.genre_id WHERE song_genres.song_id IN (?)"+
+		" GROUP BY genres.slug ORDER BY count DESC, genres.slug LIMIT ?", matching, facetLimit).
+		Scan(&facets.Genres).Error; err != nil {
+		return nil, err
+	}
+	return facets, nil
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/w212w/GoProjectEM/internal/models"
+)
+
+func (r *MemorySongRepository) ListAlbums(ctx context.Context, offset, limit int) ([]models.Album, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	albums := make([]models.Album, 0, len(r.albums))
+	for _, album := range r.albums {
+		albums = append(albums, album)
+	}
+	slices.SortFunc(albums, func(a, b models.Album) int { return cmp.Compare(a.ID, b.ID) })
+	return paginate(albums, offset, limit), nil
+}
+
+func (r *MemorySongRepository) GetAlbum(ctx context.Context, id uint) (*models.Album, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	album, ok := r.albums[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	album.Tracks = []models.AlbumTrack{}
+	for i, songID := range r.albumTracks[id] {
+		song := r.songs[songID]
+		if song.DeletedAt.Valid {
+			continue
+		}
+		album.Tracks = append(album.Tracks, models.AlbumTrack{
+			AlbumID:  id,
+			SongID:   songID,
+			Position: i + 1,
+			Title:    song.Title,
+		})
+	}
+	return &album, nil
+}
+
+func (r *MemorySongRepository) CreateAlbum(ctx context.Context, album *models.Album, songIDs []uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkSongsExist(songIDs); err != nil {
+		return err
+	}
+
+	album.Group = models.CleanName(album.Group)
+	album.GroupID = nil
+	if key := models.NormalizeName(album.Group); key != "" {
+		group, ok := r.findGroup(key, 0)
+		if !ok {
+			group = r.insertGroup(album.Group)
+		}
+		album.GroupID = &group.ID
+	}
+
+	album.ID = r.nextAlbumID
+	album.CreatedAt = time.Now()
+	album.UpdatedAt = album.CreatedAt
+	r.nextAlbumID++
+	r.albums[album.ID] = *album
+	r.albumTracks[album.ID] = slices.Clone(songIDs)
+	return nil
+}
+
+func (r *MemorySongRepository) SetTracks(ctx context.Context, albumID uint, songIDs []uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	album, ok := r.albums[albumID]
+	if !ok {
+		return ErrNotFound
+	}
+	if err := r.checkSongsExist(songIDs); err != nil {
+		return err
+	}
+	album.UpdatedAt = time.Now()
+	r.albums[albumID] = album
+	r.albumTracks[albumID] = slices.Clone(songIDs)
+	return nil
+}
+
+func (r *MemorySongRepository) AddTrack(ctx context.Context, albumID, songID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.albums[albumID]; !ok {
+		return ErrNotFound
+	}
+	if err := r.checkSongsExist([]uint{songID}); err != nil {
+		return err
+	}
+	if !slices.Contains(r.albumTracks[albumID], songID) {
+		r.albumTracks[albumID] = append(r.albumTracks[albumID], songID)
+	}
+	return nil
+}
+
+func (r *MemorySongRepository) checkSongsExist(ids []uint) error {
+	for _, id := range ids {
+		if song, ok := r.songs[id]; !ok || song.DeletedAt.Valid {
+			return ErrInvalidReference
+		}
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/w212w/GoProjectEM/internal/models"
+)
+
+// syncLinks связывает песню с группой и исполнителем по полям Group и Artist,
+// при необходимости создавая их. Вызывается под r.mu.
+func (r *MemorySongRepository) syncLinks(song *models.Song) {
+	delete(r.songGroups, song.ID)
+	delete(r.songArtists, song.ID)
+
+	if key := models.NormalizeName(song.Group); key != "" {
+		group, ok := r.findGroup(key, 0)
+		if !ok {
+			group = r.insertGroup(song.Group)
+		}
+		r.songGroups[song.ID] = group.ID
+	}
+	if key := models.NormalizeName(song.Artist); key != "" {
+		var artist models.Artist
+		found := false
+		for _, a := range r.artists {
+			if a.NormalizedName == key {
+				artist, found = a, true
+				break
+			}
+		}
+		if !found {
+			now := time.Now()
+			artist = models.Artist{
+				ID:             uint(len(r.artists) + 1),
+				Name:           models.CleanName(song.Artist),
+				NormalizedName: key,
+				CreatedAt:      now,
+				UpdatedAt:      now,
+			}
+			r.artists[artist.ID] = artist
+		}
+		r.songArtists[song.ID] = artist.ID
+	}
+}
+
+// findGroup ищет группу с нормализованным именем key, кроме группы exceptID
+func (r *MemorySongRepository) findGroup(key string, exceptID uint) (models.Group, bool) {
+	for _, group := range r.groups {
+		if group.ID != exceptID && group.NormalizedName == key {
+			return group, true
+		}
+	}
+	return models.Group{}, false
+}
+
+func (r *MemorySongRepository) insertGroup(name string) models.Group {
+	now := time.Now()
+	group := models.Group{
+		ID:             r.nextGroupID,
+		Name:           models.CleanName(name),
+		NormalizedName: models.NormalizeName(name),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	r.nextGroupID++
+	r.groups[group.ID] = group
+	return group
+}
+
+// groupSongIDs возвращает ID неудаленных песен группы по возрастанию
+func (r *MemorySongRepository) groupSongIDs(groupID uint) []uint {
+	var ids []uint
+	for songID, id := range r.songGroups {
+		if id == groupID && !r.songs[songID].DeletedAt.Valid {
+			ids = append(ids, songID)
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func (r *MemorySongRepository) ListGroups(ctx context.Context, offset, limit int) ([]models.Group, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	groups := make([]models.Group, 0, len(r.groups))
+	for _, group := range r.groups {
+		groups = append(groups, group)
+	}
+	slices.SortFunc(groups, func(a, b models.Group) int {
+		if c := strings.Compare(a.NormalizedName, b.NormalizedName); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return paginate(groups, offset, limit), nil
+}
+
+func (r *MemorySongRepository) GetGroup(ctx context.Context, id uint) (*models.Group, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	group, ok := r.groups[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &group, nil
+}
+
+func (r *MemorySongRepository) FindGroupByName(ctx context.Context, name string) (*models.Group, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	group, ok := r.findGroup(models.NormalizeName(name), 0)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &group, nil
+}
+
+func (r *MemorySongRepository) CreateGroup(ctx context.Context, group *models.Group) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.findGroup(models.NormalizeName(group.Name), 0); ok {
+		return ErrDuplicate
+	}
+	*group = r.insertGroup(group.Name)
+	return nil
+}
+
+func (r *MemorySongRepository) UpdateGroup(ctx context.Context, group *models.Group) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.groups[group.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	group.Name = models.CleanName(group.Name)
+	group.NormalizedName = models.NormalizeName(group.Name)
+	if _, ok := r.findGroup(group.NormalizedName, group.ID); ok {
+		return ErrDuplicate
+	}
+
+	// Проверяем ключи всех песен заранее, чтобы не переименовать их частично
+	ids := r.groupSongIDs(group.ID)
+	for _, id := range ids {
+		if _, ok := r.findByNaturalKey(models.NaturalKey(group.Name, r.songs[id].Title), id); ok {
+			return ErrDuplicate
+		}
+	}
+
+	group.CreatedAt = current.CreatedAt
+	group.UpdatedAt = time.Now()
+	r.groups[group.ID] = *group
+	if current.Name == group.Name {
+		return nil
+	}
+	for _, id := range ids {
+		song := r.songs[id]
+		song.Group = group.Name
+		if err := r.update(ctx, &song); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MemorySongRepository) DeleteGroup(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.groups[id]; !ok {
+		return ErrNotFound
+	}
+	if len(r.groupSongIDs(id)) > 0 {
+		return ErrInUse
+	}
+	delete(r.groups, id)
+	for songID, groupID := range r.songGroups {
+		if groupID == id {
+			delete(r.songGroups, songID)
+		}
+	}
+	return nil
+}
+
+func (r *MemorySongRepository) ListGroupSongs(ctx context.Context, groupID uint, offset, limit int) ([]models.Song, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.groups[groupID]; !ok {
+		return nil, ErrNotFound
+	}
+	var songs []models.Song
+	for _, id := range r.groupSongIDs(groupID) {
+		songs = append(songs, r.songs[id])
+	}
+	return paginate(songs, offset, limit), nil
+}
+
+// paginate возвращает срез items[offset:offset+limit], нулевой limit означает без ограничения
+func paginate[T any](items []T, offset, limit int) []T {
+	if offset > 0 {
+		if offset >= len(items) {
+			return []T{}
+		}
+		items = items[offset:]
+	}
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/w212w/GoProjectEM/internal/models"
+)
+
+// MemoryIdempotencyRepository потокобезопасная реализация IdempotencyRepository в памяти
+type MemoryIdempotencyRepository struct {
+	mu      sync.Mutex
+	records map[string]models.IdempotencyRecord
+}
+
+func NewMemoryIdempotencyRepository() *MemoryIdempotencyRepository {
+	return &MemoryIdempotencyRepository{records: make(map[string]models.IdempotencyRecord)}
+}
+
+func (r *MemoryIdempotencyRepository) Create(ctx context.Context, rec *models.IdempotencyRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for key, stored := range r.records {
+		if !stored.ExpiresAt.After(now) {
+			delete(r.records, key)
+		}
+	}
+	if _, ok := r.records[rec.Key]; ok {
+		return ErrConflict
+	}
+	rec.CreatedAt = now
+	r.records[rec.Key] = *rec
+	return nil
+}
+
+func (r *MemoryIdempotencyRepository) Get(ctx context.Context, key string) (*models.IdempotencyRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rec, ok := r.records[key]
+	if !ok || !rec.ExpiresAt.After(time.Now()) {
+		return nil, ErrNotFound
+	}
+	return &rec, nil
+}
+
+func (r *MemoryIdempotencyRepository) Complete(ctx context.Context, rec *models.IdempotencyRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.records[rec.Key]
+	if !ok {
+		return ErrNotFound
+	}
+	stored.Completed = true
+	stored.Status = rec.Status
+	stored.Header = rec.Header
+	stored.Body = rec.Body
+	r.records[rec.Key] = stored
+	rec.Completed = true
+	return nil
+}
+
+func (r *MemoryIdempotencyRepository) Delete(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.records, key)
+	return nil
+}
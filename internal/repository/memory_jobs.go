@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/w212w/GoProjectEM/internal/models"
+)
+
+// MemoryJobRepository потокобезопасная реализация JobRepository в памяти
+type MemoryJobRepository struct {
+	mu   sync.RWMutex
+	jobs map[string]models.Job
+}
+
+func NewMemoryJobRepository() *MemoryJobRepository {
+	return &MemoryJobRepository{jobs: make(map[string]models.Job)}
+}
+
+func (r *MemoryJobRepository) Create(ctx context.Context, job *models.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	r.jobs[job.ID] = *job
+	return nil
+}
+
+func (r *MemoryJobRepository) Get(ctx context.Context, id string) (*models.Job, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &job, nil
+}
+
+func (r *MemoryJobRepository) Update(ctx context.Context, job *models.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.jobs[job.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	job.CreatedAt = stored.CreatedAt
+	job.UpdatedAt = time.Now()
+	r.jobs[job.ID] = *job
+	return nil
+}
+
+func (r *MemoryJobRepository) ListUnfinished(ctx context.Context) ([]models.Job, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var jobs []models.Job
+	for _, job := range r.jobs {
+		if job.Status == models.JobStatusQueued || job.Status == models.JobStatusRunning {
+			jobs = append(jobs, job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].CreatedAt.Before(jobs[j].CreatedAt) })
+	return jobs, nil
+}
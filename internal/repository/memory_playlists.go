@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/w212w/GoProjectEM/internal/models"
+)
+
+func (r *MemorySongRepository) ListPlaylists(ctx context.Context, owner string, offset, limit int) ([]models.Playlist, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	playlists := make([]models.Playlist, 0)
+	for _, playlist := range r.playlists {
+		if playlist.VisibleTo(owner) {
+			playlists = append(playlists, playlist)
+		}
+	}
+	slices.SortFunc(playlists, func(a, b models.Playlist) int { return cmp.Compare(a.ID, b.ID) })
+	return paginate(playlists, offset, limit), nil
+}
+
+func (r *MemorySongRepository) GetPlaylist(ctx context.Context, id uint) (*models.Playlist, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	playlist, ok := r.playlists[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	playlist.Entries = []models.PlaylistEntry{}
+	for _, entry := range r.playlistEntries[id] {
+		song := r.songs[entry.SongID]
+		if song.DeletedAt.Valid {
+			continue
+		}
+		entry.Position = len(playlist.Entries) + 1
+		entry.Group = song.Group
+		entry.Title = song.Title
+		entry.Artist = song.Artist
+		entry.Link = song.Link
+		playlist.Entries = append(playlist.Entries, entry)
+	}
+	return &playlist, nil
+}
+
+func (r *MemorySongRepository) CreatePlaylist(ctx context.Context, playlist *models.Playlist) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	playlist.ID = r.nextPlaylistID
+	playlist.CreatedAt = time.Now()
+	playlist.UpdatedAt = playlist.CreatedAt
+	r.nextPlaylistID++
+	r.playlists[playlist.ID] = *playlist
+	return nil
+}
+
+func (r *MemorySongRepository) UpdatePlaylist(ctx context.Context, playlist *models.Playlist) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.playlists[playlist.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	stored.Name = playlist.Name
+	stored.Visibility = playlist.Visibility
+	stored.UpdatedAt = time.Now()
+	r.playlists[playlist.ID] = stored
+	playlist.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+func (r *MemorySongRepository) DeletePlaylist(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.playlists[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.playlists, id)
+	delete(r.playlistEntries, id)
+	return nil
+}
+
+func (r *MemorySongRepository) InsertEntry(ctx context.Context, entry *models.PlaylistEntry, pos EntryPosition) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.playlists[entry.PlaylistID]; !ok {
+		return ErrNotFound
+	}
+	if err := r.checkSongsExist([]uint{entry.SongID}); err != nil {
+		return err
+	}
+	entries := r.playlistEntries[entry.PlaylistID]
+	i, err := entryIndex(entries, pos)
+	if err != nil {
+		return err
+	}
+
+	entry.ID = r.nextEntryID
+	entry.CreatedAt = time.Now()
+	r.nextEntryID++
+	r.playlistEntries[entry.PlaylistID] = slices.Insert(entries, i, *entry)
+	r.touchPlaylist(entry.PlaylistID)
+	return nil
+}
+
+func (r *MemorySongRepository) MoveEntry(ctx context.Context, playlistID, entryID uint, pos EntryPosition) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.playlists[playlistID]; !ok {
+		return ErrNotFound
+	}
+	entries := slices.Clone(r.playlistEntries[playlistID])
+	from := slices.IndexFunc(entries, func(e models.PlaylistEntry) bool { return e.ID == entryID })
+	if from < 0 {
+		return ErrNotFound
+	}
+	entry := entries[from]
+	entries = slices.Delete(entries, from, from+1)
+
+	to, err := entryIndex(entries, pos)
+	if err != nil {
+		return err
+	}
+	r.playlistEntries[playlistID] = slices.Insert(entries, to, entry)
+	r.touchPlaylist(playlistID)
+	return nil
+}
+
+func (r *MemorySongRepository) DeleteEntry(ctx context.Context, playlistID, entryID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := r.playlistEntries[playlistID]
+	i := slices.IndexFunc(entries, func(e models.PlaylistEntry) bool { return e.ID == entryID })
+	if i < 0 {
+		return ErrNotFound
+	}
+	r.playlistEntries[playlistID] = slices.Delete(entries, i, i+1)
+	r.touchPlaylist(playlistID)
+	return nil
+}
+
+func (r *MemorySongRepository) touchPlaylist(id uint) {
+	playlist := r.playlists[id]
+	playlist.UpdatedAt = time.Now()
+	r.playlists[id] = playlist
+}
+
+// entryIndex возвращает индекс вставки записи на место pos
+func entryIndex(entries []models.PlaylistEntry, pos EntryPosition) (int, error) {
+	anchor := pos.AfterID
+	if anchor == 0 {
+		anchor = pos.BeforeID
+	}
+	if anchor == 0 {
+		return len(entries), nil
+	}
+
+	i := slices.IndexFunc(entries, func(e models.PlaylistEntry) bool { return e.ID == anchor })
+	if i < 0 {
+		return 0, ErrNotFound
+	}
+	if pos.AfterID != 0 {
+		i++
+	}
+	return i, nil
+}
@@ -0,0 +1,563 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/w212w/GoProjectEM/internal/models"
+	"gorm.io/gorm"
+)
+
+// MemorySongRepository потокобезопасная реализация SongRepository в памяти.
+// Используется в тестах и для запуска без PostgreSQL.
+type MemorySongRepository struct {
+	mu        sync.RWMutex
+	songs     map[uint]models.Song
+	revisions map[uint][]models.SongRevision
+	nextID    uint
+
+	// Группы и исполнители и связи песня -> группа, песня -> исполнитель
+	groups      map[uint]models.Group
+	artists     map[uint]models.Artist
+	songGroups  map[uint]uint
+	songArtists map[uint]uint
+	nextGroupID uint
+
+	// Альбомы и ID их песен в порядке треков
+	albums      map[uint]models.Album
+	albumTracks map[uint][]uint
+	nextAlbumID uint
+
+	// Плейлисты и их записи по порядку
+	playlists       map[uint]models.Playlist
+	playlistEntries map[uint][]models.PlaylistEntry
+	nextPlaylistID  uint
+	nextEntryID     uint
+
+	// Словарь жанров и отсортированные теги и жанры песен
+	genres     []models.Genre
+	songTags   map[uint][]string
+	songGenres map[uint][]string
+}
+
+func NewMemorySongRepository() *MemorySongRepository {
+	return &MemorySongRepository{
+		songs:     make(map[uint]models.Song),
+		revisions: make(map[uint][]models.SongRevision),
+		nextID:    1,
+
+		groups:      make(map[uint]models.Group),
+		artists:     make(map[uint]models.Artist),
+		songGroups:  make(map[uint]uint),
+		songArtists: make(map[uint]uint),
+		nextGroupID: 1,
+
+		albums:      make(map[uint]models.Album),
+		albumTracks: make(map[uint][]uint),
+		nextAlbumID: 1,
+
+		playlists:       make(map[uint]models.Playlist),
+		playlistEntries: make(map[uint][]models.PlaylistEntry),
+		nextPlaylistID:  1,
+		nextEntryID:     1,
+
+		genres:     slices.Clone(models.DefaultGenres),
+		songTags:   make(map[uint][]string),
+		songGenres: make(map[uint][]string),
+	}
+}
+
+func (r *MemorySongRepository) List(ctx context.Context, opts ListOptions) ([]models.Song, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	fields := effectiveSort(opts)
+	songs := r.filterSongs(opts.Filter)
+	slices.SortFunc(songs, func(a, b models.Song) int { return compareSongs(&a, &b, fields) })
+
+	if opts.Cursor != nil {
+		values, err := opts.Cursor.values(fields)
+		if err != nil {
+			return nil, err
+		}
+		songs = slices.DeleteFunc(songs, func(s models.Song) bool {
+			c := compareToCursor(&s, fields, values)
+			if opts.Cursor.Before {
+				return c >= 0
+			}
+			return c <= 0
+		})
+		if opts.Cursor.Before && opts.Limit > 0 && opts.Limit < len(songs) {
+			songs = songs[len(songs)-opts.Limit:]
+		}
+	} else if opts.Offset > 0 {
+		if opts.Offset >= len(songs) {
+			return []models.Song{}, nil
+		}
+		songs = songs[opts.Offset:]
+	}
+	if opts.Limit > 0 && opts.Limit < len(songs) {
+		songs = songs[:opts.Limit]
+	}
+	if wantsTags(opts.Fields) {
+		for i := range songs {
+			r.attachTags(&songs[i])
+		}
+	}
+	return songs, nil
+}
+
+func (r *MemorySongRepository) Export(ctx context.Context, opts ListOptions, fn func(song *models.Song) error) error {
+	songs, err := r.List(ctx, ListOptions{Filter: opts.Filter, Sort: opts.Sort, Fields: opts.Fields})
+	if err != nil {
+		return err
+	}
+	for i := range songs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(&songs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MemorySongRepository) Count(ctx context.Context, filter SongFilter) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return int64(len(r.filterSongs(filter))), nil
+}
+
+// filterSongs возвращает неудаленные песни, подходящие под filter, в произвольном порядке.
+// При поиске заполняется Score.
+func (r *MemorySongRepository) filterSongs(filter SongFilter) []models.Song {
+	var positions map[uint]int
+	if filter.AlbumID != 0 {
+		positions = r.albumPositions(filter.AlbumID)
+	}
+
+	songs := make([]models.Song, 0, len(r.songs))
+	for _, song := range r.songs {
+		if song.DeletedAt.Valid {
+			continue
+		}
+		if positions != nil {
+			if song.Position = positions[song.ID]; song.Position == 0 {
+				continue
+			}
+		}
+		if !containsFold(song.Artist, filter.Artist) || !containsFold(song.Title, filter.Title) || !containsFold(song.Group, filter.Group) {
+			continue
+		}
+		if filter.HasLink != nil && (song.Link != "") != *filter.HasLink {
+			continue
+		}
+		if !filter.ReleasedFrom.IsZero() && (song.ReleaseDate.IsZero() || song.ReleaseDate.Start().Before(filter.ReleasedFrom)) {
+			continue
+		}
+		if !filter.ReleasedTo.IsZero() && (song.ReleaseDate.IsZero() || !song.ReleaseDate.Start().Before(filter.ReleasedTo)) {
+			continue
+		}
+		if !inRange(song.CreatedAt, filter.CreatedFrom, filter.CreatedTo) || !inRange(song.UpdatedAt, filter.UpdatedFrom, filter.UpdatedTo) {
+			continue
+		}
+		if filter.Expr != nil && !filter.Expr.Match(&song) {
+			continue
+		}
+		if !containsAll(r.songTags[song.ID], filter.Tags) || !containsAll(r.songGenres[song.ID], filter.Genres) {
+			continue
+		}
+		if filter.Query != "" {
+			song.Score = matchScore(song, filter.Query)
+			if song.Score == 0 {
+				continue
+			}
+		}
+		songs = append(songs, song)
+	}
+	return songs
+}
+
+// albumPositions возвращает позиции треков альбома по ID песен, начиная с 1
+func (r *MemorySongRepository) albumPositions(albumID uint) map[uint]int {
+	positions := make(map[uint]int)
+	for i, id := range r.albumTracks[albumID] {
+		positions[id] = i + 1
+	}
+	return positions
+}
+
+func (r *MemorySongRepository) Get(ctx context.Context, id uint) (*models.Song, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	song, ok := r.songs[id]
+	if !ok || song.DeletedAt.Valid {
+		return nil, ErrNotFound
+	}
+	r.attachTags(&song)
+	return &song, nil
+}
+
+func (r *MemorySongRepository) FindByNaturalKey(ctx context.Context, group, title string) (*models.Song, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	song, ok := r.findByNaturalKey(models.NaturalKey(group, title), 0)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &song, nil
+}
+
+// findByNaturalKey ищет неудаленную песню с ключом key, кроме песни exceptID
+func (r *MemorySongRepository) findByNaturalKey(key string, exceptID uint) (models.Song, bool) {
+	for _, song := range r.songs {
+		if song.ID != exceptID && !song.DeletedAt.Valid && song.NaturalKey == key {
+			return song, true
+		}
+	}
+	return models.Song{}, false
+}
+
+func (r *MemorySongRepository) Create(ctx context.Context, song *models.Song) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.create(ctx, song)
+}
+
+func (r *MemorySongRepository) CreateBatch(ctx context.Context, songs []*models.Song) ([]error, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	errs := make([]error, len(songs))
+	for i, song := range songs {
+		errs[i] = r.create(ctx, song)
+	}
+	return errs, nil
+}
+
+func (r *MemorySongRepository) create(ctx context.Context, song *models.Song) error {
+	key := models.NaturalKey(song.Group, song.Title)
+	if _, ok := r.findByNaturalKey(key, 0); ok {
+		return ErrDuplicate
+	}
+
+	song.ID = r.nextID
+	song.NaturalKey = key
+	song.Version = 1
+	song.CreatedAt = time.Now()
+	song.UpdatedAt = song.CreatedAt
+	song.Language = models.DetectLanguage(song.Group, song.Title, song.Text)
+	r.nextID++
+	r.songs[song.ID] = *song
+	r.syncLinks(song)
+	r.addRevision(ctx, nil, song)
+	return nil
+}
+
+func (r *MemorySongRepository) Update(ctx context.Context, song *models.Song) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.update(ctx, song)
+}
+
+func (r *MemorySongRepository) update(ctx context.Context, song *models.Song) error {
+	stored, ok := r.songs[song.ID]
+	if !ok || stored.DeletedAt.Valid {
+		return ErrNotFound
+	}
+	if stored.Version != song.Version {
+		return ErrConflict
+	}
+	key := models.NaturalKey(song.Group, song.Title)
+	if _, ok := r.findByNaturalKey(key, song.ID); ok {
+		return ErrDuplicate
+	}
+	song.NaturalKey = key
+	song.Version++
+	song.CreatedAt = stored.CreatedAt
+	song.UpdatedAt = time.Now()
+	song.Language = models.DetectLanguage(song.Group, song.Title, song.Text)
+	r.songs[song.ID] = *song
+	if stored.Group != song.Group || stored.Artist != song.Artist {
+		r.syncLinks(song)
+	}
+	r.addRevision(ctx, &stored, song)
+	return nil
+}
+
+func (r *MemorySongRepository) SetStatus(ctx context.Context, id uint, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	song, ok := r.songs[id]
+	if !ok || song.DeletedAt.Valid {
+		return ErrNotFound
+	}
+	song.Status = status
+	r.touch(ctx, &song)
+	return nil
+}
+
+// touch сохраняет песню с новой версией и записывает ревизию, не проверяя
+// уникальность: группа и название песни при этом не меняются
+func (r *MemorySongRepository) touch(ctx context.Context, song *models.Song) {
+	previous := r.songs[song.ID]
+	song.Version = previous.Version + 1
+	song.UpdatedAt = time.Now()
+	r.songs[song.ID] = *song
+	r.addRevision(ctx, &previous, song)
+}
+
+func (r *MemorySongRepository) Delete(ctx context.Context, id uint, version int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.songs[id]
+	if !ok || stored.DeletedAt.Valid {
+		return ErrNotFound
+	}
+	if version > 0 && stored.Version != version {
+		return ErrConflict
+	}
+	stored.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	r.songs[id] = stored
+	return nil
+}
+
+func (r *MemorySongRepository) ListDeleted(ctx context.Context, offset, limit int) ([]models.Song, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	songs := make([]models.Song, 0)
+	for _, song := range r.songs {
+		if song.DeletedAt.Valid {
+			songs = append(songs, song)
+		}
+	}
+	slices.SortFunc(songs, func(a, b models.Song) int {
+		if c := b.DeletedAt.Time.Compare(a.DeletedAt.Time); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	if offset > 0 {
+		if offset >= len(songs) {
+			return []models.Song{}, nil
+		}
+		songs = songs[offset:]
+	}
+	if limit > 0 && limit < len(songs) {
+		songs = songs[:limit]
+	}
+	return songs, nil
+}
+
+func (r *MemorySongRepository) Restore(ctx context.Context, id uint) (*models.Song, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	song, ok := r.songs[id]
+	if !ok || !song.DeletedAt.Valid {
+		return nil, ErrNotFound
+	}
+	if _, ok := r.findByNaturalKey(song.NaturalKey, id); ok {
+		return nil, ErrDuplicate
+	}
+	previous := song
+	song.DeletedAt = gorm.DeletedAt{}
+	song.Version++
+	song.UpdatedAt = time.Now()
+	r.songs[id] = song
+	r.addRevision(withRestoreNote(ctx), &previous, &song)
+	return &song, nil
+}
+
+func (r *MemorySongRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purged int64
+	for id, song := range r.songs {
+		if song.DeletedAt.Valid && song.DeletedAt.Time.Before(before) {
+			delete(r.songs, id)
+			delete(r.revisions, id)
+			delete(r.songGroups, id)
+			delete(r.songArtists, id)
+			delete(r.songTags, id)
+			delete(r.songGenres, id)
+			for albumID, tracks := range r.albumTracks {
+				r.albumTracks[albumID] = slices.DeleteFunc(tracks, func(songID uint) bool { return songID == id })
+			}
+			for playlistID, entries := range r.playlistEntries {
+				r.playlistEntries[playlistID] = slices.DeleteFunc(entries, func(e models.PlaylistEntry) bool { return e.SongID == id })
+			}
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (r *MemorySongRepository) ListDuplicates(ctx context.Context) ([]models.DuplicateGroup, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	songs := make([]models.Song, 0, len(r.songs))
+	for _, song := range r.songs {
+		if !song.DeletedAt.Valid {
+			songs = append(songs, song)
+		}
+	}
+	slices.SortFunc(songs, func(a, b models.Song) int { return cmp.Compare(a.ID, b.ID) })
+	return groupDuplicates(songs), nil
+}
+
+func (r *MemorySongRepository) Merge(ctx context.Context, targetID uint, sourceIDs []uint) (*models.Song, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	target, ok := r.songs[targetID]
+	if !ok || target.DeletedAt.Valid {
+		return nil, ErrNotFound
+	}
+	sources := make([]models.Song, 0, len(sourceIDs))
+	for _, id := range sourceIDs {
+		source, ok := r.songs[id]
+		if !ok || source.DeletedAt.Valid {
+			return nil, ErrNotFound
+		}
+		sources = append(sources, source)
+	}
+
+	for i := range sources {
+		target.FillMissing(&sources[i])
+	}
+	if err := r.update(ctx, &target); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, source := range sources {
+		source.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+		r.songs[source.ID] = source
+	}
+	r.moveSongLinks(targetID, sourceIDs)
+	r.attachTags(&target)
+	return &target, nil
+}
+
+// moveSongLinks то же, что одноименная функция GORM-реализации
+func (r *MemorySongRepository) moveSongLinks(targetID uint, sourceIDs []uint) {
+	merged := func(id uint) bool { return id == targetID || slices.Contains(sourceIDs, id) }
+
+	for albumID, tracks := range r.albumTracks {
+		seen := false
+		r.albumTracks[albumID] = slices.DeleteFunc(tracks, func(id uint) bool {
+			if !merged(id) {
+				return false
+			}
+			if seen {
+				return true
+			}
+			seen = true
+			return false
+		})
+		if i := slices.IndexFunc(r.albumTracks[albumID], merged); i >= 0 {
+			r.albumTracks[albumID][i] = targetID
+		}
+	}
+
+	for playlistID, entries := range r.playlistEntries {
+		for i := range entries {
+			if slices.Contains(sourceIDs, entries[i].SongID) {
+				entries[i].SongID = targetID
+			}
+		}
+		r.playlistEntries[playlistID] = entries
+	}
+
+	for _, id := range sourceIDs {
+		for _, tag := range r.songTags[id] {
+			if i, ok := slices.BinarySearch(r.songTags[targetID], tag); !ok {
+				r.songTags[targetID] = slices.Insert(r.songTags[targetID], i, tag)
+			}
+		}
+		for _, genre := range r.songGenres[id] {
+			if i, ok := slices.BinarySearch(r.songGenres[targetID], genre); !ok {
+				r.songGenres[targetID] = slices.Insert(r.songGenres[targetID], i, genre)
+			}
+		}
+	}
+}
+
+// inRange проверяет, что t в периоде [from, to), нулевая граница не ограничивает
+func inRange(t, from, to time.Time) bool {
+	return (from.IsZero() || !t.Before(from)) && (to.IsZero() || t.Before(to))
+}
+
+func containsFold(s, substr string) bool {
+	if substr == "" {
+		return true
+	}
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func (r *MemorySongRepository) ListRevisions(ctx context.Context, songID uint) ([]models.SongRevision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return slices.Clone(r.revisions[songID]), nil
+}
+
+func (r *MemorySongRepository) GetRevision(ctx context.Context, songID uint, revision int) (*models.SongRevision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, rev := range r.revisions[songID] {
+		if rev.Revision == revision {
+			return &rev, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemorySongRepository) addRevision(ctx context.Context, previous, song *models.Song) {
+	rev := newRevision(ctx, previous, song)
+	rev.ID = uint(len(r.revisions[song.ID]) + 1)
+	rev.CreatedAt = song.UpdatedAt
+	r.revisions[song.ID] = append(r.revisions[song.ID], *rev)
+}
+
+// matchScore грубое приближение ts_rank: доля слов запроса, найденных в песне,
+// с весом по полю (название > группа > текст). Все слова должны совпасть.
+func matchScore(song models.Song, query string) float64 {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return 0
+	}
+
+	var score float64
+	for _, term := range terms {
+		switch {
+		case containsFold(song.Title, term):
+			score += 1
+		case containsFold(song.Group, term):
+			score += 0.4
+		case containsFold(song.Text, term):
+			score += 0.2
+		default:
+			return 0
+		}
+	}
+	return score / float64(len(terms))
+}
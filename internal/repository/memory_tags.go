@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/w212w/GoProjectEM/internal/models"
+)
+
+// attachTags копирует в песню ее теги и жанры
+func (r *MemorySongRepository) attachTags(song *models.Song) {
+	song.Tags = slices.Clone(r.songTags[song.ID])
+	song.Genres = slices.Clone(r.songGenres[song.ID])
+}
+
+// containsAll сообщает, есть ли в отсортированном списке values все значения want
+func containsAll(values, want []string) bool {
+	for _, v := range want {
+		if _, ok := slices.BinarySearch(values, v); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *MemorySongRepository) ListGenres(ctx context.Context) ([]models.Genre, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return slices.Clone(r.genres), nil
+}
+
+func (r *MemorySongRepository) TagSongs(ctx context.Context, songIDs []uint, tags, genres []string) (int, error) {
+	return r.retag(ctx, songIDs, tags, genres, func(values, change []string) []string {
+		for _, v := range change {
+			if i, ok := slices.BinarySearch(values, v); !ok {
+				values = slices.Insert(values, i, v)
+			}
+		}
+		return values
+	})
+}
+
+func (r *MemorySongRepository) UntagSongs(ctx context.Context, songIDs []uint, tags, genres []string) (int, error) {
+	return r.retag(ctx, songIDs, tags, genres, func(values, change []string) []string {
+		return slices.DeleteFunc(values, func(v string) bool { return slices.Contains(change, v) })
+	})
+}
+
+// retag применяет apply к тегам и жанрам каждой песни. Песни, у которых
+// они изменились, получают новую версию и ревизию.
+func (r *MemorySongRepository) retag(ctx context.Context, songIDs []uint, tags, genres []string,
+	apply func(values, change []string) []string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkSongsExist(songIDs); err != nil {
+		return 0, err
+	}
+	for _, slug := range genres {
+		if !slices.ContainsFunc(r.genres, func(g models.Genre) bool { return g.Slug == slug }) {
+			return 0, ErrUnknownGenre
+		}
+	}
+
+	updated := 0
+	for _, id := range songIDs {
+		newTags := apply(slices.Clone(r.songTags[id]), tags)
+		newGenres := apply(slices.Clone(r.songGenres[id]), genres)
+		if slices.Equal(newTags, r.songTags[id]) && slices.Equal(newGenres, r.songGenres[id]) {
+			continue
+		}
+		song := r.songs[id]
+		r.touch(ctx, &song)
+		r.songTags[id] = newTags
+		r.songGenres[id] = newGenres
+		updated++
+	}
+	return updated, nil
+}
+
+func (r *MemorySongRepository) Facets(ctx context.Context, filter SongFilter) (*models.Facets, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tagCounts := make(map[string]int64)
+	genreCounts := make(map[string]int64)
+	for _, song := range r.filterSongs(filter) {
+		for _, tag := range r.songTags[song.ID] {
+			tagCounts[tag]++
+		}
+		for _, genre := range r.songGenres[song.ID] {
+			genreCounts[genre]++
+		}
+	}
+	return &models.Facets{Tags: facetCounts(tagCounts), Genres: facetCounts(genreCounts)}, nil
+}
+
+// facetCounts сортирует значения по убыванию количества, затем по значению
+func facetCounts(counts map[string]int64) []models.FacetCount {
+	facets := make([]models.FacetCount, 0, len(counts))
+	for value, count := range counts {
+		facets = append(facets, models.FacetCount{Value: value, Count: count})
+	}
+	slices.SortFunc(facets, func(a, b models.FacetCount) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Value, b.Value)
+	})
+	if len(facets) > facetLimit {
+		facets = facets[:facetLimit]
+	}
+	return facets
+}
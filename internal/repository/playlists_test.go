@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/w212w/GoProjectEM/internal/models"
+)
+
+func rank(v float64) *float64 { return &v }
+
+func TestRankBetween(t *testing.T) {
+	tests := []struct {
+		name       string
+		prev, next *float64
+		want       float64
+	}{
+		{"empty playlist", nil, nil, rankStep},
+		{"first", nil, rank(1024), 0},
+		{"last", rank(2048), nil, 2048 + rankStep},
+		{"between", rank(1024), rank(2048), 1536},
+		{"negative", rank(-1024), rank(0), -512},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := rankBetween(tt.prev, tt.next)
+			if !ok || got != tt.want {
+				t.Errorf("rankBetween = %v, %v, want %v, true", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestRankBetweenExhausted(t *testing.T) {
+	// Вставки все время в одно и то же место делят промежуток пополам,
+	// пока между соседями не останется места
+	prev, next := 1024.0, 2048.0
+	for i := 0; ; i++ {
+		mid, ok := rankBetween(&prev, &next)
+		if !ok {
+			if i < 50 {
+				t.Errorf("no room after %d inserts", i)
+			}
+			return
+		}
+		if mid <= prev || mid >= next {
+			t.Fatalf("rank %v is outside (%v, %v)", mid, prev, next)
+		}
+		next = mid
+	}
+}
+
+func entryIDs(t *testing.T, repo *MemorySongRepository, playlistID uint) []uint {
+	t.Helper()
+
+	playlist, err := repo.GetPlaylist(context.Background(), playlistID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]uint, len(playlist.Entries))
+	for i, entry := range playlist.Entries {
+		if entry.Position != i+1 {
+			t.Errorf("entry %d has position %d, want %d", entry.ID, entry.Position, i+1)
+		}
+		ids[i] = entry.ID
+	}
+	return ids
+}
+
+func TestMemoryPlaylistEntries(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemorySongRepository()
+	song := &models.Song{Group: "Muse", Title: "Uprising"}
+	if err := repo.Create(ctx, song); err != nil {
+		t.Fatal(err)
+	}
+	playlist := &models.Playlist{Name: "Road trip", Owner: "alice", Visibility: models.PlaylistPrivate}
+	if err := repo.CreatePlaylist(ctx, playlist); err != nil {
+		t.Fatal(err)
+	}
+
+	insert := func(pos EntryPosition) uint {
+		entry := &models.PlaylistEntry{PlaylistID: playlist.ID, SongID: song.ID}
+		if err := repo.InsertEntry(ctx, entry, pos); err != nil {
+			t.Fatal(err)
+		}
+		return entry.ID
+	}
+	a := insert(EntryPosition{})
+	b := insert(EntryPosition{})
+	c := insert(EntryPosition{BeforeID: a})
+	d := insert(EntryPosition{AfterID: c})
+	if got, want := entryIDs(t, repo, playlist.ID), []uint{c, d, a, b}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("entries = %v, want %v", got, want)
+	}
+
+	if err := repo.MoveEntry(ctx, playlist.ID, c, EntryPosition{AfterID: b}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.MoveEntry(ctx, playlist.ID, b, EntryPosition{BeforeID: d}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := entryIDs(t, repo, playlist.ID), []uint{b, d, a, c}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("entries after move = %v, want %v", got, want)
+	}
+
+	if err := repo.MoveEntry(ctx, playlist.ID, a, EntryPosition{AfterID: 42}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("move after unknown entry: err = %v, want ErrNotFound", err)
+	}
+	if err := repo.InsertEntry(ctx, &models.PlaylistEntry{PlaylistID: playlist.ID, SongID: 42}, EntryPosition{}); !errors.Is(err, ErrInvalidReference) {
+		t.Errorf("insert unknown song: err = %v, want ErrInvalidReference", err)
+	}
+}
+
+func TestMemoryListPlaylists(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemorySongRepository()
+	for _, p := range []models.Playlist{
+		{Name: "public", Owner: "alice", Visibility: models.PlaylistPublic},
+		{Name: "alice", Owner: "alice", Visibility: models.PlaylistPrivate},
+		{Name: "ownerless", Visibility: models.PlaylistPrivate},
+	} {
+		if err := repo.CreatePlaylist(ctx, &p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for viewer, want := range map[string][]string{
+		"alice": {"public", "alice"},
+		"bob":   {"public"},
+		"":      {"public"},
+	} {
+		list, err := repo.ListPlaylists(ctx, viewer, 0, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, p := range list {
+			names = append(names, p.Name)
+		}
+		if !reflect.DeepEqual(names, want) {
+			t.Errorf("%q sees %v, want %v", viewer, names, want)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/w212w/GoProjectEM/internal/filterexpr"
+	"github.com/w212w/GoProjectEM/internal/models"
+)
+
+var (
+	// ErrNotFound возвращается, когда запись с указанным ID отсутствует в хранилище
+	ErrNotFound = errors.New("record not found")
+	// ErrConflict возвращается, когда версия записи изменилась с момента чтения
+	ErrConflict = errors.New("record version conflict")
+	// ErrDuplicate возвращается, когда песня с тем же models.NaturalKey уже существует
+	ErrDuplicate = errors.New("record already exists")
+	// ErrInUse возвращается при удалении записи, на которую ссылаются другие записи
+	ErrInUse = errors.New("record is in use")
+	// ErrInvalidReference возвращается, когда запись ссылается на отсутствующие записи
+	ErrInvalidReference = errors.New("referenced record not found")
+	// ErrUnknownGenre возвращается для жанра не из словаря
+	ErrUnknownGenre = errors.New("unknown genre")
+	// ErrInvalidCursor возвращается для курсора, который не удалось разобрать
+	// или который выдан для другого порядка выборки
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// SongFilter фильтры для выборки списка песен
+type SongFilter struct {
+	// Artist, Title и Group ищут подстроку без учета регистра
+	Artist string
+	Title  string
+	Group  string
+	// HasLink, если задан, оставляет песни со ссылкой или без нее
+	HasLink *bool
+	// Query строка полнотекстового поиска по названию, группе и тексту песни
+	Query string
+	// ReleasedFrom и ReleasedTo ограничивают дату релиза: [ReleasedFrom, ReleasedTo).
+	// Нулевое значение означает отсутствие ограничения.
+	ReleasedFrom time.Time
+	ReleasedTo   time.Time
+	// CreatedFrom, CreatedTo, UpdatedFrom и UpdatedTo ограничивают created_at и updated_at так же
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	UpdatedFrom time.Time
+	UpdatedTo   time.Time
+	// AlbumID оставляет только треки альбома. Без Sort и Query они упорядочены по позиции в альбоме.
+	AlbumID uint
+	// Tags и Genres оставляют песни, у которых есть все перечисленные теги и жанры
+	Tags   []string
+	Genres []string
+	// Expr произвольное выражение фильтра, см. filterexpr.Parse
+	Expr filterexpr.Expr
+}
+
+// ListOptions параметры выборки списка песен: фильтры, сортировка и пагинация.
+// Без Sort песни упорядочены по релевантности при поиске или по позиции в альбоме,
+// затем по ID. С Cursor выбирается страница после или перед курсором, Offset не используется.
+type ListOptions struct {
+	Filter SongFilter
+	Sort   []SortField
+	Cursor *Cursor
+	Offset int
+	Limit  int
+	// Fields поля песни из models.SongFields, которые нужно выбрать; пустой означает все.
+	// Остальные поля песен в результате могут быть не заполнены.
+	Fields []string
+}
+
+// SongRepository хранилище песен.
+//
+// Delete перемещает песню в корзину: удаленные песни не возвращаются List и Get
+// и не могут быть изменены, пока их не восстановят через TrashRepository.
+//
+// Update использует оптимистичную блокировку: запись обновляется, только если
+// ее версия равна song.Version, после чего версия увеличивается. Delete с
+// ненулевым version удаляет запись только в этой версии. При несовпадении
+// версии возвращается ErrConflict.
+//
+// Пара группа+название уникальна среди неудаленных песен с точностью до
+// models.NaturalKey: Create, Update и восстановление дубликата возвращают ErrDuplicate.
+type SongRepository interface {
+	// List возвращает страницу песен. Страница перед курсором тоже упорядочена по opts.Sort.
+	List(ctx context.Context, opts ListOptions) ([]models.Song, error)
+	// Count возвращает количество неудаленных песен, подходящих под filter
+	Count(ctx context.Context, filter SongFilter) (int64, error)
+	Get(ctx context.Context, id uint) (*models.Song, error)
+	// FindByNaturalKey ищет неудаленную песню с тем же models.NaturalKey
+	FindByNaturalKey(ctx context.Context, group, title string) (*models.Song, error)
+	Create(ctx context.Context, song *models.Song) error
+	Update(ctx context.Context, song *models.Song) error
+	// SetStatus меняет только статус обработки песни, увеличивая версию и записывая
+	// ревизию. Версия не проверяется, а уникальность не затрагивается.
+	SetStatus(ctx context.Context, id uint, status string) error
+	Delete(ctx context.Context, id uint, version int) error
+}
+
+// ExportRepository выгрузка каталога. Реализуется хранилищем песен.
+type ExportRepository interface {
+	// Export вызывает fn для каждой неудаленной песни, подходящей под opts.Filter,
+	// в порядке opts.Sort, не загружая все песни в память. Cursor, Offset и Limit
+	// не используются. Ошибка fn прерывает выгрузку и возвращается как есть.
+	Export(ctx context.Context, opts ListOptions, fn func(song *models.Song) error) error
+}
+
+// ImportRepository пакетное создание песен при импорте. Реализуется хранилищем песен.
+type ImportRepository interface {
+	// CreateBatch создает песни в одной транзакции так же, как Create. Песня, нарушающая
+	// уникальность, не создается, а errs[i] равна ErrDuplicate; остальные песни пачки
+	// при этом сохраняются. Любая другая ошибка откатывает всю пачку.
+	CreateBatch(ctx context.Context, songs []*models.Song) (errs []error, err error)
+}
+
+// TrashRepository корзина удаленных песен
+type TrashRepository interface {
+	// ListDeleted возвращает удаленные песни, начиная с удаленных последними
+	ListDeleted(ctx context.Context, offset, limit int) ([]models.Song, error)
+	// Restore возвращает песню из корзины, увеличивая ее версию и записывая ревизию
+	Restore(ctx context.Context, id uint) (*models.Song, error)
+	// Purge окончательно удаляет песни, перемещенные в корзину раньше before,
+	// вместе с их ревизиями и задачами, и возвращает количество удаленных песен
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+// DuplicateRepository поиск и объединение похожих песен
+type DuplicateRepository interface {
+	// ListDuplicates возвращает группы неудаленных песен с одинаковым models.SimilarityKey
+	ListDuplicates(ctx context.Context) ([]models.DuplicateGroup, error)
+	// Merge дополняет песню targetID незаполненными полями песен sourceIDs
+	// и перемещает их в корзину. Треки альбомов и записи плейлистов песен sourceIDs
+	// переходят к цели, их теги и жанры добавляются цели. Изменение цели записывается как ревизия.
+	Merge(ctx context.Context, targetID uint, sourceIDs []uint) (*models.Song, error)
+}
+
+// GroupRepository музыкальные группы. Группы и исполнители создаются и связываются
+// с песнями хранилищем песен по полям Group и Artist, поэтому реализуется им же.
+type GroupRepository interface {
+	ListGroups(ctx context.Context, offset, limit int) ([]models.Group, error)
+	GetGroup(ctx context.Context, id uint) (*models.Group, error)
+	// FindGroupByName ищет группу с тем же models.NormalizeName
+	FindGroupByName(ctx context.Context, name string) (*models.Group, error)
+	CreateGroup(ctx context.Context, group *models.Group) error
+	// UpdateGroup переименовывает группу и меняет поле Group ее неудаленных песен,
+	// записывая их ревизии
+	UpdateGroup(ctx context.Context, group *models.Group) error
+	// DeleteGroup удаляет группу без неудаленных песен, иначе возвращает ErrInUse
+	DeleteGroup(ctx context.Context, id uint) error
+	ListGroupSongs(ctx context.Context, groupID uint, offset, limit int) ([]models.Song, error)
+}
+
+// AlbumRepository альбомы и их треки. Реализуется хранилищем песен.
+// Ссылки на отсутствующие или удаленные песни возвращают ErrInvalidReference.
+type AlbumRepository interface {
+	ListAlbums(ctx context.Context, offset, limit int) ([]models.Album, error)
+	// GetAlbum возвращает альбом с треками, кроме удаленных песен
+	GetAlbum(ctx context.Context, id uint) (*models.Album, error)
+	// CreateAlbum создает альбом с треками songIDs и связывает его с группой по полю Group
+	CreateAlbum(ctx context.Context, album *models.Album, songIDs []uint) error
+	// SetTracks заменяет треки альбома на songIDs в указанном порядке
+	SetTracks(ctx context.Context, albumID uint, songIDs []uint) error
+	// AddTrack добавляет песню в конец альбома, если ее там еще нет
+	AddTrack(ctx context.Context, albumID, songID uint) error
+}
+
+// TagRepository теги и жанры песен. Реализуется хранилищем песен.
+// Каждая песня, у которой изменились теги или жанры, получает новую версию и ревизию.
+type TagRepository interface {
+	ListGenres(ctx context.Context) ([]models.Genre, error)
+	// TagSongs добавляет песням теги и жанры и возвращает количество измененных песен.
+	// Отсутствующие или удаленные песни - ErrInvalidReference, жанры не из словаря - ErrUnknownGenre.
+	TagSongs(ctx context.Context, songIDs []uint, tags, genres []string) (int, error)
+	// UntagSongs удаляет у песен теги и жанры и возвращает количество измененных песен
+	UntagSongs(ctx context.Context, songIDs []uint, tags, genres []string) (int, error)
+	// Facets считает песни, подходящие под filter, по тегам и жанрам
+	Facets(ctx context.Context, filter SongFilter) (*models.Facets, error)
+}
+
+// EntryPosition место записи плейлиста: перед BeforeID, после AfterID
+// или, если оба нулевые, в конце
+type EntryPosition struct {
+	BeforeID uint
+	AfterID  uint
+}
+
+// PlaylistRepository плейлисты. Реализуется хранилищем песен.
+// Вставка и перемещение записи меняют только саму запись, не переписывая остальные.
+type PlaylistRepository interface {
+	// ListPlaylists возвращает публичные плейлисты и плейлисты владельца owner
+	ListPlaylists(ctx context.Context, owner string, offset, limit int) ([]models.Playlist, error)
+	// GetPlaylist возвращает плейлист с записями, кроме удаленных песен
+	GetPlaylist(ctx context.Context, id uint) (*models.Playlist, error)
+	CreatePlaylist(ctx context.Context, playlist *models.Playlist) error
+	UpdatePlaylist(ctx context.Context, playlist *models.Playlist) error
+	DeletePlaylist(ctx context.Context, id uint) error
+	// InsertEntry добавляет запись. Отсутствующая песня - ErrInvalidReference,
+	// отсутствующая соседняя запись - ErrNotFound.
+	InsertEntry(ctx context.Context, entry *models.PlaylistEntry, pos EntryPosition) error
+	// MoveEntry переставляет запись. Отсутствующая запись или соседняя запись - ErrNotFound.
+	MoveEntry(ctx context.Context, playlistID, entryID uint, pos EntryPosition) error
+	DeleteEntry(ctx context.Context, playlistID, entryID uint) error
+}
+
+// JobRepository хранилище задач фонового обогащения
+type JobRepository interface {
+	Create(ctx context.Context, job *models.Job) error
+	Get(ctx context.Context, id string) (*models.Job, error)
+	Update(ctx context.Context, job *models.Job) error
+	// ListUnfinished возвращает задачи в статусах queued и running
+	ListUnfinished(ctx context.Context) ([]models.Job, error)
+}
+
+// IdempotencyRepository хранилище ответов на запросы с заголовком Idempotency-Key.
+// Записи с истекшим ExpiresAt считаются отсутствующими.
+type IdempotencyRepository interface {
+	// Create резервирует ключ перед выполнением запроса, если ключ занят - возвращает ErrConflict
+	Create(ctx context.Context, rec *models.IdempotencyRecord) error
+	Get(ctx context.Context, key string) (*models.IdempotencyRecord, error)
+	// Complete сохраняет ответ на запрос для повторов
+	Complete(ctx context.Context, rec *models.IdempotencyRecord) error
+	// Delete освобождает ключ, например после ошибки сервера, чтобы запрос можно было повторить
+	Delete(ctx context.Context, key string) error
+}
+
+// RevisionRepository история изменений песен. Ревизии создаются реализацией
+// SongRepository в той же транзакции, что и изменение песни.
+type RevisionRepository interface {
+	ListRevisions(ctx context.Context, songID uint) ([]models.SongRevision, error)
+	GetRevision(ctx context.Context, songID uint, revision int) (*models.SongRevision, error)
+}
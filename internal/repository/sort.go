@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/w212w/GoProjectEM/internal/models"
+)
+
+// SortField поле сортировки списка песен
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// sortColumn поле, по которому сортируется список и строится курсор.
+// Пустое значение (nil) сортируется последним, как NULLS LAST.
+type sortColumn struct {
+	// value возвращает значение поля песни или nil
+	value func(s *models.Song) any
+	// compare сравнивает два непустых значения
+	compare func(a, b any) int
+	// decode разбирает значение из курсора
+	decode func(raw json.RawMessage) (any, error)
+}
+
+func orderedColumn[T cmp.Ordered](get func(s *models.Song) (T, bool)) sortColumn {
+	return sortColumn{
+		value: func(s *models.Song) any {
+			if v, ok := get(s); ok {
+				return v
+			}
+			return nil
+		},
+		compare: func(a, b any) int { return cmp.Compare(a.(T), b.(T)) },
+		decode: func(raw json.RawMessage) (any, error) {
+			var v T
+			err := json.Unmarshal(raw, &v)
+			return v, err
+		},
+	}
+}
+
+func timeColumn(get func(s *models.Song) (time.Time, bool)) sortColumn {
+	return sortColumn{
+		value: func(s *models.Song) any {
+			if v, ok := get(s); ok {
+				return v
+			}
+			return nil
+		},
+		compare: func(a, b any) int { return a.(time.Time).Compare(b.(time.Time)) },
+		decode: func(raw json.RawMessage) (any, error) {
+			var v time.Time
+			err := json.Unmarshal(raw, &v)
+			return v, err
+		},
+	}
+}
+
+// songSortColumns поля, по которым разрешена сортировка. Ключ совпадает с
+// именем колонки в таблице songs. Текст песни не сортируется.
+var songSortColumns = map[string]sortColumn{
+	"id":       orderedColumn(func(s *models.Song) (uint, bool) { return s.ID, true }),
+	"title":    orderedColumn(func(s *models.Song) (string, bool) { return s.Title, true }),
+	"artist":   orderedColumn(func(s *models.Song) (string, bool) { return s.Artist, true }),
+	"group":    orderedColumn(func(s *models.Song) (string, bool) { return s.Group, true }),
+	"link":     orderedColumn(func(s *models.Song) (string, bool) { return s.Link, true }),
+	"status":   orderedColumn(func(s *models.Song) (string, bool) { return s.Status, true }),
+	"language": orderedColumn(func(s *models.Song) (string, bool) { return s.Language, true }),
+	"version":  orderedColumn(func(s *models.Song) (int, bool) { return s.Version, true }),
+	// Дата в виде 2006-01-02 сравнивается с колонкой типа date без учета часового пояса
+	"release_date": orderedColumn(func(s *models.Song) (string, bool) {
+		return s.ReleaseDate.Start().Format(time.DateOnly), !s.ReleaseDate.IsZero()
+	}),
+	"created_at": timeColumn(func(s *models.Song) (time.Time, bool) { return s.CreatedAt, true }),
+	"updated_at": timeColumn(func(s *models.Song) (time.Time, bool) { return s.UpdatedAt, true }),
+}
+
+// implicitSortColumns поля порядка по умолчанию, недоступные в параметре сортировки:
+// релевантность при поиске и позиция в альбоме
+var implicitSortColumns = map[string]sortColumn{
+	"score":    orderedColumn(func(s *models.Song) (float64, bool) { return s.Score, true }),
+	"position": orderedColumn(func(s *models.Song) (int, bool) { return s.Position, true }),
+}
+
+func sortColumnFor(field string) sortColumn {
+	if col, ok := songSortColumns[field]; ok {
+		return col
+	}
+	return implicitSortColumns[field]
+}
+
+// ParseSort разбирает параметр сортировки вида "-release_date,id":
+// поля через запятую, минус означает сортировку по убыванию.
+func ParseSort(s string) ([]SortField, error) {
+	var fields []SortField
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		field := SortField{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+		if _, ok := songSortColumns[field.Field]; !ok {
+			return nil, fmt.Errorf("unsupported sort field %q", field.Field)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// effectiveSort возвращает полный порядок выборки: явную сортировку или, без нее,
+// релевантность при поиске либо позицию в альбоме, и в конце ID для однозначности
+func effectiveSort(opts ListOptions) []SortField {
+	fields := slices.Clone(opts.Sort)
+	if len(fields) == 0 {
+		switch {
+		case opts.Filter.Query != "":
+			fields = append(fields, SortField{Field: "score", Desc: true})
+		case opts.Filter.AlbumID != 0:
+			fields = append(fields, SortField{Field: "position"})
+		}
+	}
+	if !slices.ContainsFunc(fields, func(f SortField) bool { return f.Field == "id" }) {
+		fields = append(fields, SortField{Field: "id"})
+	}
+	return fields
+}
+
+// sortString записывает сортировку в виде параметра sort
+func sortString(fields []SortField) string {
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		parts[i] = f.Field
+		if f.Desc {
+			parts[i] = "-" + f.Field
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// sortColumnSQL возвращает имя колонки сортировки в кавычках: group - ключевое слово SQL
+func sortColumnSQL(field string) string {
+	return `songs."` + field + `"`
+}
+
+// orderClause строит ORDER BY для GORM, пустые значения всегда в конце.
+// С reverse порядок обратный, для чтения страницы перед курсором.
+func orderClause(fields []SortField, reverse bool) string {
+	parts := make([]string, 0, len(fields))
+	for _, f := range fields {
+		dir, nulls := "ASC", "NULLS LAST"
+		if f.Desc != reverse {
+			dir = "DESC"
+		}
+		if reverse {
+			nulls = "NULLS FIRST"
+		}
+		parts = append(parts, sortColumnSQL(f.Field)+" "+dir+" "+nulls)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// compareValues сравнивает значения поля, пустое значение больше любого другого.
+// desc меняет порядок только непустых значений.
+func compareValues(col sortColumn, a, b any, desc bool) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	c := col.compare(a, b)
+	if desc {
+		c = -c
+	}
+	return c
+}
+
+// compareSongs сравнивает песни по полному списку полей сортировки
+func compareSongs(a, b *models.Song, fields []SortField) int {
+	for _, f := range fields {
+		col := sortColumnFor(f.Field)
+		if c := compareValues(col, col.value(a), col.value(b), f.Desc); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
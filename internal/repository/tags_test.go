@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/w212w/GoProjectEM/internal/models"
+)
+
+func TestMemoryTagSongs(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemorySongRepository()
+	a := &models.Song{Group: "Muse", Title: "Uprising"}
+	b := &models.Song{Group: "Muse", Title: "Resistance"}
+	for _, song := range []*models.Song{a, b} {
+		if err := repo.Create(ctx, song); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	updated, err := repo.TagSongs(ctx, []uint{a.ID, b.ID}, []string{"live", "favorite"}, []string{"rock"})
+	if err != nil || updated != 2 {
+		t.Fatalf("TagSongs = %d, %v, want 2, nil", updated, err)
+	}
+	// Повторная пометка ничего не меняет и не создает новых версий
+	if updated, err := repo.TagSongs(ctx, []uint{a.ID}, []string{"live"}, nil); err != nil || updated != 0 {
+		t.Errorf("repeated TagSongs = %d, %v, want 0, nil", updated, err)
+	}
+	if updated, err := repo.UntagSongs(ctx, []uint{b.ID}, []string{"live"}, []string{"rock"}); err != nil || updated != 1 {
+		t.Errorf("UntagSongs = %d, %v, want 1, nil", updated, err)
+	}
+
+	song, err := repo.Get(ctx, a.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"favorite", "live"}; !reflect.DeepEqual(song.Tags, want) {
+		t.Errorf("tags = %v, want %v", song.Tags, want)
+	}
+	if want := []string{"rock"}; !reflect.DeepEqual(song.Genres, want) {
+		t.Errorf("genres = %v, want %v", song.Genres, want)
+	}
+	if song.Version != 2 {
+		t.Errorf("version = %d, want 2", song.Version)
+	}
+
+	if _, err := repo.TagSongs(ctx, []uint{42}, []string{"live"}, nil); !errors.Is(err, ErrInvalidReference) {
+		t.Errorf("unknown song: err = %v, want ErrInvalidReference", err)
+	}
+	if _, err := repo.TagSongs(ctx, []uint{a.ID}, nil, []string{"polka"}); !errors.Is(err, ErrUnknownGenre) {
+		t.Errorf("unknown genre: err = %v, want ErrUnknownGenre", err)
+	}
+}
+
+func TestMemoryTagLegacyDuplicates(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemorySongRepository()
+	a := &models.Song{Group: "Muse", Title: "Uprising"}
+	if err := repo.Create(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+	// Дубликат, оставшийся с тех пор, когда natural_key не был уникальным
+	legacy := repo.songs[a.ID]
+	legacy.ID = a.ID + 1
+	repo.songs[legacy.ID] = legacy
+
+	if updated, err := repo.TagSongs(ctx, []uint{a.ID, legacy.ID}, []string{"live"}, nil); err != nil || updated != 2 {
+		t.Fatalf("TagSongs = %d, %v, want 2, nil", updated, err)
+	}
+	if err := repo.SetStatus(ctx, legacy.ID, models.SongStatusFailed); err != nil {
+		t.Fatalf("SetStatus: %v", err)
+	}
+	song, err := repo.Get(ctx, legacy.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if song.Status != models.SongStatusFailed || song.Version != 3 {
+		t.Errorf("status = %s, version = %d, want %s, 3", song.Status, song.Version, models.SongStatusFailed)
+	}
+}
+
+func TestMemoryMergeCopiesTags(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemorySongRepository()
+	target := &models.Song{Group: "Muse", Title: "Uprising"}
+	source := &models.Song{Group: "MUSE", Title: "Uprising (Live)"}
+	for _, song := range []*models.Song{target, source} {
+		if err := repo.Create(ctx, song); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := repo.TagSongs(ctx, []uint{target.ID}, []string{"studio"}, []string{"rock"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.TagSongs(ctx, []uint{source.ID}, []string{"live"}, []string{"alternative", "rock"}); err != nil {
+		t.Fatal(err)
+	}
+
+	merged, err := repo.Merge(ctx, target.ID, []uint{source.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"live", "studio"}; !reflect.DeepEqual(merged.Tags, want) {
+		t.Errorf("tags = %v, want %v", merged.Tags, want)
+	}
+	if want := []string{"alternative", "rock"}; !reflect.DeepEqual(merged.Genres, want) {
+		t.Errorf("genres = %v, want %v", merged.Genres, want)
+	}
+}
@@ -0,0 +1,92 @@
+package trash
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/w212w/GoProjectEM/internal/config"
+	"github.com/w212w/GoProjectEM/internal/logger"
+	"github.com/w212w/GoProjectEM/internal/repository"
+)
+
+// Config настройки очистки корзины
+type Config struct {
+	// Retention сколько хранить песни в корзине. Нулевое значение отключает очистку.
+	Retention time.Duration
+	Interval  time.Duration
+}
+
+// ConfigFromEnv читает настройки из переменных окружения TRASH_*
+func ConfigFromEnv() Config {
+	return Config{
+		Retention: time.Duration(config.Int("TRASH_RETENTION_DAYS", 30)) * 24 * time.Hour,
+		Interval:  config.Duration("TRASH_PURGE_INTERVAL", time.Hour),
+	}
+}
+
+// Purger периодически окончательно удаляет песни, которые пролежали
+// в корзине дольше Retention.
+type Purger struct {
+	trash repository.TrashRepository
+	cfg   Config
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func New(trash repository.TrashRepository, cfg Config) *Purger {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Hour
+	}
+	return &Purger{
+		trash: trash,
+		cfg:   cfg,
+		done:  make(chan struct{}),
+	}
+}
+
+// Start запускает очистку сразу и затем с интервалом cfg.Interval
+func (p *Purger) Start(ctx context.Context) {
+	if p.cfg.Retention <= 0 {
+		logger.Log.Info("Purger: retention is not set, trash will not be purged")
+		return
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		ticker := time.NewTicker(p.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			p.purge(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-p.done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	logger.Log.Infof("Purger: started, retention %s", p.cfg.Retention)
+}
+
+// Stop останавливает очистку и дожидается завершения текущего прохода
+func (p *Purger) Stop() {
+	close(p.done)
+	p.wg.Wait()
+}
+
+func (p *Purger) purge(ctx context.Context) {
+	purged, err := p.trash.Purge(ctx, time.Now().Add(-p.cfg.Retention))
+	if err != nil {
+		logger.Log.Errorf("Purger: failed to purge trash: %v", err)
+		return
+	}
+	if purged > 0 {
+		logger.Log.Infof("Purger: permanently deleted %d songs", purged)
+	}
+}
@@ -0,0 +1,131 @@
+package validation
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/w212w/GoProjectEM/internal/models"
+)
+
+// Validate проверяет строковые поля структуры по тегу validate и возвращает
+// все нарушения сразу. Имя поля в ошибке берется из тега json.
+//
+// Правила (через запятую):
+//
+//	required     - поле задано и не состоит из одних пробелов
+//	notblank     - если поле задано, оно не пустое
+//	max=N        - не длиннее N символов
+//	url          - абсолютный http(s) URL
+//	release_date - дата релиза в одном из форматов models.ParseReleaseDate
+//	oneof=A B    - одно из значений, перечисленных через пробел
+//
+// Поля-указатели со значением nil считаются незаданными: к ним применяется
+// только required. Пустые строки пропускают проверки url, release_date и oneof.
+func Validate(v any) []models.FieldError {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	rt := rv.Type()
+
+	var errs []models.FieldError
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag := field.Tag.Get("validate")
+		if tag == "" {
+			continue
+		}
+
+		name := jsonName(field)
+		value, present := stringValue(rv.Field(i))
+
+		for _, rule := range strings.Split(tag, ",") {
+			if err := check(rule, value, present); err != nil {
+				err.Field = name
+				errs = append(errs, *err)
+				break
+			}
+		}
+	}
+	return errs
+}
+
+func check(rule, value string, present bool) *models.FieldError {
+	rule, arg, _ := strings.Cut(rule, "=")
+
+	if rule == "required" {
+		if !present || strings.TrimSpace(value) == "" {
+			return &models.FieldError{Code: "required", Message: "field is required"}
+		}
+		return nil
+	}
+	if !present {
+		return nil
+	}
+
+	switch rule {
+	case "notblank":
+		if strings.TrimSpace(value) == "" {
+			return &models.FieldError{Code: "blank", Message: "field must not be blank"}
+		}
+	case "max":
+		limit, err := strconv.Atoi(arg)
+		if err != nil {
+			panic(fmt.Sprintf("validation: invalid max rule %q", arg))
+		}
+		if utf8.RuneCountInString(value) > limit {
+			return &models.FieldError{Code: "too_long", Message: fmt.Sprintf("must be at most %d characters", limit)}
+		}
+	case "url":
+		if value == "" {
+			return nil
+		}
+		u, err := url.Parse(value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return &models.FieldError{Code: "invalid_url", Message: "must be an absolute http(s) URL"}
+		}
+	case "release_date":
+		if value == "" {
+			return nil
+		}
+		if _, err := models.ParseReleaseDate(value); err != nil {
+			return &models.FieldError{Code: "invalid_date", Message: err.Error()}
+		}
+	case "oneof":
+		if value == "" {
+			return nil
+		}
+		allowed := strings.Fields(arg)
+		for _, v := range allowed {
+			if value == v {
+				return nil
+			}
+		}
+		return &models.FieldError{Code: "invalid", Message: "must be one of: " + strings.Join(allowed, ", ")}
+	default:
+		panic(fmt.Sprintf("validation: unknown rule %q", rule))
+	}
+	return nil
+}
+
+func stringValue(v reflect.Value) (string, bool) {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "", false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.String {
+		panic(fmt.Sprintf("validation: unsupported field kind %s", v.Kind()))
+	}
+	return v.String(), true
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
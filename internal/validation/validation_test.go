@@ -0,0 +1,104 @@
+package validation
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/w212w/GoProjectEM/internal/models"
+)
+
+func ptr(s string) *string { return &s }
+
+// fieldCodes возвращает коды ошибок по полям
+func fieldCodes(errs []models.FieldError) map[string]string {
+	codes := make(map[string]string, len(errs))
+	for _, e := range errs {
+		codes[e.Field] = e.Code
+	}
+	return codes
+}
+
+func TestValidateAddSongRequest(t *testing.T) {
+	tests := []struct {
+		name  string
+		input models.AddSongRequest
+		want  map[string]string
+	}{
+		{"valid", models.AddSongRequest{Group: "Muse", Song: "Uprising"}, map[string]string{}},
+		{"missing", models.AddSongRequest{}, map[string]string{"group": "required", "song": "required"}},
+		{"blank", models.AddSongRequest{Group: " \t", Song: "Uprising"}, map[string]string{"group": "required"}},
+		{"too long", models.AddSongRequest{Group: "Muse", Song: strings.Repeat("я", 256)}, map[string]string{"song": "too_long"}},
+		{"max counts characters", models.AddSongRequest{Group: "Muse", Song: strings.Repeat("я", 255)}, map[string]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fieldCodes(Validate(&tt.input)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateUpdateSongRequest(t *testing.T) {
+	tests := []struct {
+		name  string
+		input models.UpdateSongRequest
+		want  map[string]string
+	}{
+		{"empty", models.UpdateSongRequest{}, map[string]string{}},
+		{"valid", models.UpdateSongRequest{
+			Group: ptr("Muse"), Title: ptr("Uprising"), ReleaseDate: ptr("07.09.2009"), Link: ptr("https://example.com/uprising"),
+		}, map[string]string{}},
+		{"cleared optional fields", models.UpdateSongRequest{Artist: ptr(""), ReleaseDate: ptr(""), Link: ptr("")}, map[string]string{}},
+		{"blank title", models.UpdateSongRequest{Title: ptr("  ")}, map[string]string{"title": "blank"}},
+		{"invalid date", models.UpdateSongRequest{ReleaseDate: ptr("31.02.2009")}, map[string]string{"release_date": "invalid_date"}},
+		{"relative link", models.UpdateSongRequest{Link: ptr("/songs/1")}, map[string]string{"link": "invalid_url"}},
+		{"not http link", models.UpdateSongRequest{Link: ptr("ftp://example.com/song")}, map[string]string{"link": "invalid_url"}},
+		{"too long link", models.UpdateSongRequest{Link: ptr("https://example.com/" + strings.Repeat("a", 2048))}, map[string]string{"link": "too_long"}},
+		{"several fields", models.UpdateSongRequest{Group: ptr(""), Text: ptr(strings.Repeat("a", 50001))},
+			map[string]string{"group": "blank", "text": "too_long"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fieldCodes(Validate(&tt.input)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateOneOf(t *testing.T) {
+	tests := []struct {
+		visibility string
+		want       map[string]string
+	}{
+		{"", map[string]string{}},
+		{"public", map[string]string{}},
+		{"private", map[string]string{}},
+		{"friends", map[string]string{"visibility": "invalid"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.visibility, func(t *testing.T) {
+			input := models.PlaylistRequest{Name: "Road trip", Visibility: tt.visibility}
+			if got := fieldCodes(Validate(input)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateFieldName(t *testing.T) {
+	input := struct {
+		Plain  string `validate:"required"`
+		Tagged string `json:"tagged,omitempty" validate:"required"`
+	}{}
+
+	errs := Validate(input)
+	if len(errs) != 2 || errs[0].Field != "Plain" || errs[1].Field != "tagged" {
+		t.Errorf("Validate = %+v, want errors for Plain and tagged", errs)
+	}
+	if errs[0].Message == "" {
+		t.Error("error without message")
+	}
+}